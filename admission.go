@@ -0,0 +1,236 @@
+package LCache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrOverloaded 加载器过载，请求未能在限制内获得执行机会
+var ErrOverloaded = errors.New("loader overloaded")
+
+// AdaptiveLimitOptions AIMD 自适应并发限制配置
+// 加载延迟不超过 TargetLatency 时每轮加性增加并发限制（每次成功增加 1/limit），
+// 超过目标延迟或加载失败时按 Backoff 乘性减小，限制始终保持在 [MinLimit, MaxLimit] 内
+type AdaptiveLimitOptions struct {
+	InitialLimit  int           // 初始并发限制
+	MinLimit      int           // 最小并发限制
+	MaxLimit      int           // 最大并发限制
+	TargetLatency time.Duration // 目标加载延迟
+	Backoff       float64       // 乘性减小系数，取值 (0, 1)
+}
+
+// DefaultAdaptiveLimitOptions 返回默认的自适应并发限制配置
+func DefaultAdaptiveLimitOptions() AdaptiveLimitOptions {
+	return AdaptiveLimitOptions{
+		InitialLimit:  16,
+		MinLimit:      1,
+		MaxLimit:      256,
+		TargetLatency: 100 * time.Millisecond,
+		Backoff:       0.9,
+	}
+}
+
+// WithLoadConcurrency 限制同时执行的 Getter 调用数，n <= 0 表示不限制
+func WithLoadConcurrency(n int) GroupOption {
+	return func(g *Group) {
+		g.limiter().setLimit(float64(n))
+	}
+}
+
+// WithLoadRateLimit 用令牌桶限制 Getter 的调用速率：每秒补充 rate 个令牌，最多积累 burst 个
+func WithLoadRateLimit(rate float64, burst int) GroupOption {
+	return func(g *Group) {
+		if rate > 0 {
+			g.limiter().bucket = newTokenBucket(RateLimit{Rate: rate, Burst: burst})
+		}
+	}
+}
+
+// WithLoadQueueTimeout 设置超出限制的加载排队等待的最长时间，0 表示不排队，直接返回 ErrOverloaded
+// 等待时间同时受调用方 ctx 的截止时间约束
+func WithLoadQueueTimeout(d time.Duration) GroupOption {
+	return func(g *Group) {
+		g.limiter().queueTimeout = d
+	}
+}
+
+// WithAdaptiveLoadLimit 按加载延迟自适应地调整 Getter 的并发限制，覆盖 WithLoadConcurrency
+func WithAdaptiveLoadLimit(opts AdaptiveLimitOptions) GroupOption {
+	return func(g *Group) {
+		def := DefaultAdaptiveLimitOptions()
+		if opts.MinLimit <= 0 {
+			opts.MinLimit = def.MinLimit
+		}
+		if opts.MaxLimit < opts.MinLimit {
+			opts.MaxLimit = max(def.MaxLimit, opts.MinLimit)
+		}
+		if opts.InitialLimit <= 0 {
+			opts.InitialLimit = def.InitialLimit
+		}
+		opts.InitialLimit = min(max(opts.InitialLimit, opts.MinLimit), opts.MaxLimit)
+		if opts.TargetLatency <= 0 {
+			opts.TargetLatency = def.TargetLatency
+		}
+		if opts.Backoff <= 0 || opts.Backoff >= 1 {
+			opts.Backoff = def.Backoff
+		}
+
+		l := g.limiter()
+		l.adaptive = &opts
+		l.setLimit(float64(opts.InitialLimit))
+	}
+}
+
+func (g *Group) limiter() *loadLimiter {
+	if g.admission == nil {
+		g.admission = &loadLimiter{changed: make(chan struct{})}
+	}
+	return g.admission
+}
+
+// loadLimiter Getter 调用的准入控制：并发限制（固定或 AIMD 自适应）加令牌桶限速
+type loadLimiter struct {
+	mu           sync.Mutex
+	limit        float64 // 并发限制，0 表示不限制
+	inflight     int     // 正在执行的加载数
+	changed      chan struct{}
+	queueTimeout time.Duration
+	adaptive     *AdaptiveLimitOptions
+
+	bucket *tokenBucket // 加载速率限制，nil 表示不限速
+
+	waiting  int   // 正在排队的加载数
+	rejected int64 // 被拒绝的加载数
+}
+
+func (l *loadLimiter) setLimit(limit float64) {
+	if limit < 0 {
+		limit = 0
+	}
+	l.limit = limit
+}
+
+// acquire 获取一次加载的执行机会，返回的 release 必须在加载结束后调用
+func (l *loadLimiter) acquire(ctx context.Context) (release func(err error), err error) {
+	var deadline time.Time
+	if l.queueTimeout > 0 {
+		deadline = time.Now().Add(l.queueTimeout)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// 先占并发名额再取令牌，避免取了令牌却因并发已满而浪费
+	for l.limit > 0 && float64(l.inflight) >= math.Floor(l.limit) {
+		if err := l.wait(ctx, deadline, l.changed); err != nil {
+			return nil, err
+		}
+	}
+	l.inflight++
+
+	for l.bucket != nil {
+		wait := l.bucket.delay(time.Now(), 0)
+		if wait == 0 {
+			l.bucket.take()
+			break
+		}
+		if deadline.IsZero() || time.Now().Add(wait).After(deadline) {
+			l.inflight--
+			l.rejected++
+			l.notify()
+			return nil, fmt.Errorf("%w: load rate limit exceeded", ErrOverloaded)
+		}
+		refilled := make(chan struct{})
+		timer := time.AfterFunc(wait, func() { close(refilled) })
+		err := l.wait(ctx, deadline, refilled)
+		timer.Stop()
+		if err != nil {
+			l.inflight--
+			l.notify()
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+	return func(err error) { l.release(time.Since(start), err) }, nil
+}
+
+// wait 释放锁等待 ch 或超时，返回时重新持有锁，调用前必须持有锁
+func (l *loadLimiter) wait(ctx context.Context, deadline time.Time, ch <-chan struct{}) error {
+	if deadline.IsZero() {
+		l.rejected++
+		return fmt.Errorf("%w: %d loads in flight", ErrOverloaded, l.inflight)
+	}
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		l.rejected++
+		return fmt.Errorf("%w: queue timeout", ErrOverloaded)
+	}
+
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+
+	l.waiting++
+	l.mu.Unlock()
+	var err error
+	select {
+	case <-ch:
+	case <-timer.C:
+		err = fmt.Errorf("%w: queue timeout", ErrOverloaded)
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	l.mu.Lock()
+	l.waiting--
+
+	if err != nil && errors.Is(err, ErrOverloaded) {
+		l.rejected++
+	}
+	return err
+}
+
+// notify 唤醒所有排队的加载，调用前必须持有锁
+func (l *loadLimiter) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// release 归还并发名额，自适应模式下按本次加载的延迟和结果调整并发限制
+func (l *loadLimiter) release(latency time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inflight--
+	if a := l.adaptive; a != nil {
+		// 调用方取消不代表后端过载，不参与调整
+		if err != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+			err = nil
+		}
+		if err != nil || latency > a.TargetLatency {
+			l.limit = math.Max(float64(a.MinLimit), l.limit*a.Backoff)
+		} else {
+			l.limit = math.Min(float64(a.MaxLimit), l.limit+1/l.limit)
+		}
+	}
+	l.notify()
+}
+
+// stats 返回准入控制的统计信息
+func (l *loadLimiter) stats() map[string]interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return map[string]interface{}{
+		"limit":    l.limit,
+		"inflight": l.inflight,
+		"waiting":  l.waiting,
+		"rejected": l.rejected,
+	}
+}
@@ -0,0 +1,285 @@
+package LCache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrNotInteger 值不是十进制整数，不能执行 Incr/Decr
+var ErrNotInteger = errors.New("value is not an integer")
+
+// ErrIncrOverflow Incr/Decr 的结果超出 int64 范围
+var ErrIncrOverflow = errors.New("increment or decrement would overflow")
+
+// keyLockStripes 键锁的分段数
+const keyLockStripes = 256
+
+// VersionedPeer 支持版本号与原子操作的节点
+// 原子操作总是转发到 PickPeer 选出的键的所有者节点上执行，从而对同一个键保持线性一致
+type VersionedPeer interface {
+	GetVersioned(ctx context.Context, group string, key string) ([]byte, uint64, error)
+	Incr(ctx context.Context, group string, key string, delta int64) (int64, uint64, error)
+	SetIfAbsent(ctx context.Context, group string, key string, value []byte) (bool, uint64, error)
+	CompareAndSwap(ctx context.Context, group string, key string, value []byte, version uint64) (bool, uint64, error)
+}
+
+// keyLocks 按键哈希分段的互斥锁，保证同一个键上的读-改-写与普通写入互斥
+type keyLocks [keyLockStripes]sync.Mutex
+
+func (l *keyLocks) lock(key string) func() {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	mu := &l[h.Sum32()%keyLockStripes]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// Incr 将键的整数值加上 delta 并返回新值，键不存在时视为 0
+// 值以十进制字符串保存，操作在键的所有者节点上执行，只作用于缓存中的值，不会调用 Getter
+func (g *Group) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	view, err := g.incr(ctx, key, delta)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(view.String(), 10, 64)
+}
+
+// Decr 将键的整数值减去 delta 并返回新值，键不存在时视为 0
+func (g *Group) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	if delta == math.MinInt64 {
+		return 0, ErrIncrOverflow
+	}
+	return g.Incr(ctx, key, -delta)
+}
+
+// SetIfAbsent 仅当键不存在时写入，返回是否写入成功
+func (g *Group) SetIfAbsent(ctx context.Context, key string, value []byte) (bool, error) {
+	set, _, err := g.setIfAbsent(ctx, key, value)
+	return set, err
+}
+
+// CompareAndSwap 仅当键的当前版本号等于 version 时写入 value，version 为 0 表示期望键不存在
+// 版本号可以通过 Get 返回的 ByteView.Version 获得；写入成功时返回新版本号，否则返回当前版本号（键不存在时为 0）
+func (g *Group) CompareAndSwap(ctx context.Context, key string, value []byte, version uint64) (uint64, bool, error) {
+	swapped, current, err := g.compareAndSwap(ctx, key, value, version)
+	return current, swapped, err
+}
+
+func (g *Group) incr(ctx context.Context, key string, delta int64) (ByteView, error) {
+	if err := g.checkWritable(key); err != nil {
+		return ByteView{}, err
+	}
+
+	peer, remote, err := g.owner(ctx, key)
+	if err != nil {
+		return ByteView{}, err
+	}
+	if remote {
+		n, version, err := peer.Incr(ctx, g.name, key, delta)
+		if err != nil {
+			return ByteView{}, err
+		}
+		g.afterRemoteWrite(key, true, strconv.AppendInt(nil, n, 10), version)
+		return ByteView{b: strconv.AppendInt(nil, n, 10), version: version}, nil
+	}
+
+	unlock := g.keyLocks.lock(key)
+	defer unlock()
+
+	var current int64
+	if view, ok := g.mainCache.peek(key); ok {
+		n, err := strconv.ParseInt(view.String(), 10, 64)
+		if err != nil {
+			return ByteView{}, fmt.Errorf("%w: key %s", ErrNotInteger, key)
+		}
+		current = n
+	}
+	if (delta > 0 && current > math.MaxInt64-delta) || (delta < 0 && current < math.MinInt64-delta) {
+		return ByteView{}, ErrIncrOverflow
+	}
+
+	view, err := g.newView(strconv.AppendInt(nil, current+delta, 10), g.clock.now())
+	if err != nil {
+		return ByteView{}, err
+	}
+	g.addLocal(key, view)
+	g.notifySet(key, view)
+	return view, nil
+}
+
+func (g *Group) setIfAbsent(ctx context.Context, key string, value []byte) (bool, uint64, error) {
+	if err := g.checkWritable(key); err != nil {
+		return false, 0, err
+	}
+	if len(value) == 0 {
+		return false, 0, ErrValueRequired
+	}
+
+	peer, remote, err := g.owner(ctx, key)
+	if err != nil {
+		return false, 0, err
+	}
+	if remote {
+		set, version, err := peer.SetIfAbsent(ctx, g.name, key, value)
+		if err != nil {
+			return false, 0, err
+		}
+		g.afterRemoteWrite(key, set, value, version)
+		return set, version, nil
+	}
+
+	unlock := g.keyLocks.lock(key)
+	defer unlock()
+
+	if view, ok := g.mainCache.peek(key); ok {
+		return false, view.version, nil
+	}
+
+	view, err := g.newView(value, g.clock.now())
+	if err != nil {
+		return false, 0, err
+	}
+	g.addLocal(key, view)
+	g.notifySet(key, view)
+	return true, view.version, nil
+}
+
+func (g *Group) compareAndSwap(ctx context.Context, key string, value []byte, version uint64) (bool, uint64, error) {
+	if err := g.checkWritable(key); err != nil {
+		return false, 0, err
+	}
+	if len(value) == 0 {
+		return false, 0, ErrValueRequired
+	}
+
+	peer, remote, err := g.owner(ctx, key)
+	if err != nil {
+		return false, 0, err
+	}
+	if remote {
+		swapped, current, err := peer.CompareAndSwap(ctx, g.name, key, value, version)
+		if err != nil {
+			return false, 0, err
+		}
+		g.afterRemoteWrite(key, swapped, value, current)
+		return swapped, current, nil
+	}
+
+	unlock := g.keyLocks.lock(key)
+	defer unlock()
+
+	var current uint64
+	if view, ok := g.mainCache.peek(key); ok {
+		current = view.version
+	}
+	if current != version {
+		return false, current, nil
+	}
+
+	view, err := g.newView(value, g.clock.now())
+	if err != nil {
+		return false, 0, err
+	}
+	g.addLocal(key, view)
+	g.notifySet(key, view)
+	return true, view.version, nil
+}
+
+func (g *Group) checkWritable(key string) error {
+	if atomic.LoadInt32(&g.closed) == 1 {
+		return ErrGroupClosed
+	}
+	if key == "" {
+		return ErrKeyRequired
+	}
+	return nil
+}
+
+// owner 返回键的所有者节点；本节点就是所有者或请求来自其他节点时 remote 为 false，在本地执行
+func (g *Group) owner(ctx context.Context, key string) (VersionedPeer, bool, error) {
+	if g.peers == nil || ctx.Value("from_peer") != nil {
+		return nil, false, nil
+	}
+
+	peer, ok, isSelf := g.peers.PickPeer(key)
+	if !ok || isSelf {
+		return nil, false, nil
+	}
+
+	vp, ok := peer.(VersionedPeer)
+	if !ok {
+		return nil, false, fmt.Errorf("peer %T does not support atomic operations", peer)
+	}
+	return vp, true, nil
+}
+
+// afterRemoteWrite 所有者节点执行条件写入后同步本地副本：写入成功时保存新值，失败说明本地副本可能已过时，直接删除（新值无法保存时同样删除）
+func (g *Group) afterRemoteWrite(key string, written bool, value []byte, version uint64) {
+	// 版本号领先本地时钟过多时不保存副本
+	if !g.clock.observe(version) {
+		written = false
+	}
+	if written {
+		if view, err := g.newView(value, version); err == nil {
+			g.addIfNewer(key, view, true)
+			return
+		}
+	}
+	unlock := g.keyLocks.lock(key)
+	if g.mainCache.Delete(key) {
+		g.notifyDelete(key, version)
+	}
+	unlock()
+}
+
+// addLocal 按组的过期时间写入本地缓存，并清除键的删除墓碑，调用前必须持有键锁
+func (g *Group) addLocal(key string, view ByteView, tags ...string) {
+	g.tombstones.remove(key)
+	if ttl := g.ttl(); ttl > 0 {
+		g.mainCache.AddWithExpiration(key, view, time.Now().Add(ttl), tags...)
+	} else {
+		g.mainCache.Add(key, view, tags...)
+	}
+}
+
+// atomicErrors 需要跨节点还原的原子操作错误
+var atomicErrors = []error{ErrNotInteger, ErrIncrOverflow}
+
+// toStatusError 将原子操作错误转换为 gRPC 状态，使客户端可以还原为对应的错误值；加载过载转换为 ResourceExhausted
+func toStatusError(err error) error {
+	if errors.Is(err, ErrOverloaded) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	for _, e := range atomicErrors {
+		if errors.Is(err, e) {
+			return status.Error(codes.FailedPrecondition, e.Error())
+		}
+	}
+	return err
+}
+
+// fromStatusError 将 toStatusError 产生的 gRPC 状态还原为错误值，ResourceExhausted（加载过载或被限流）还原为 ErrOverloaded
+func fromStatusError(err error) error {
+	s, ok := status.FromError(err)
+	if ok && s.Code() == codes.ResourceExhausted {
+		return fmt.Errorf("%w: %v", ErrOverloaded, err)
+	}
+	if ok && s.Code() == codes.FailedPrecondition {
+		for _, e := range atomicErrors {
+			if s.Message() == e.Error() {
+				return e
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,389 @@
+package LCache
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "LCache/pb"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ErrInvalidToken 令牌无效或已过期
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// ErrInsecureToken 令牌要通过未加密的连接发送，需要启用 TLS 或显式使用 WithInsecureToken
+var ErrInsecureToken = errors.New("refusing to send token over an insecure connection")
+
+// authorizationHeader 携带令牌的 gRPC 元数据键，值的格式为 "Bearer <token>"
+const authorizationHeader = "authorization"
+
+// peerHeader 标记节点间同步请求的元数据键，只有通过认证并拥有 PermPeer 权限的调用方的标记才被信任
+const peerHeader = "lcache-peer"
+
+// Permission 组权限，可以按位组合
+type Permission uint8
+
+const (
+	// PermRead 读取：Get、Scan
+	PermRead Permission = 1 << iota
+	// PermWrite 写入：Set、Delete、Incr、SetIfAbsent、CompareAndSwap
+	PermWrite
+	// PermAdmin 管理：Invalidate 等批量操作
+	PermAdmin
+	// PermPeer 节点间同步：写入沿用请求携带的版本号
+	PermPeer
+
+	// PermAll 全部权限
+	PermAll = PermRead | PermWrite | PermAdmin | PermPeer
+)
+
+func (p Permission) String() string {
+	if p == 0 {
+		return "none"
+	}
+	var names []string
+	for _, perm := range []struct {
+		p    Permission
+		name string
+	}{{PermRead, "read"}, {PermWrite, "write"}, {PermAdmin, "admin"}, {PermPeer, "peer"}} {
+		if p&perm.p != 0 {
+			names = append(names, perm.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// methodPermissions 每个 RPC 需要的权限，未列出的 LCache 方法需要 PermAdmin
+var methodPermissions = map[string]Permission{
+	pb.LCache_Get_FullMethodName:            PermRead,
+	pb.LCache_Scan_FullMethodName:           PermRead,
+	pb.LCache_Set_FullMethodName:            PermWrite,
+	pb.LCache_Delete_FullMethodName:         PermWrite,
+	pb.LCache_Incr_FullMethodName:           PermWrite,
+	pb.LCache_SetIfAbsent_FullMethodName:    PermWrite,
+	pb.LCache_CompareAndSwap_FullMethodName: PermWrite,
+	pb.LCache_Invalidate_FullMethodName:     PermAdmin,
+	pb.LCache_Watch_FullMethodName:          PermRead,
+}
+
+// Authenticator 校验令牌并返回其代表的身份
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (identity string, err error)
+}
+
+// StaticTokens 静态令牌表：令牌 -> 身份
+type StaticTokens map[string]string
+
+// Authenticate 实现 Authenticator 接口，使用常量时间比较避免时序攻击
+func (t StaticTokens) Authenticate(_ context.Context, token string) (string, error) {
+	for tok, identity := range t {
+		if subtle.ConstantTimeCompare([]byte(tok), []byte(token)) == 1 {
+			return identity, nil
+		}
+	}
+	return "", ErrInvalidToken
+}
+
+// HMACAuthenticator 校验 HMAC-SHA256 签名的令牌，令牌格式为 "身份.过期时间戳.签名"，由 NewHMACToken 生成
+// 服务端只需要保存密钥，不需要维护令牌列表
+type HMACAuthenticator struct {
+	Secret []byte
+}
+
+// NewHMACToken 用 secret 为 identity 签发有效期为 ttl 的令牌，ttl 为 0 表示永不过期
+func NewHMACToken(secret []byte, identity string, ttl time.Duration) string {
+	var expireAt int64
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl).Unix()
+	}
+	payload := base64.RawURLEncoding.EncodeToString([]byte(identity)) + "." + strconv.FormatInt(expireAt, 10)
+	return payload + "." + hmacSign(secret, payload)
+}
+
+// Authenticate 实现 Authenticator 接口
+func (a HMACAuthenticator) Authenticate(_ context.Context, token string) (string, error) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return "", ErrInvalidToken
+	}
+	payload, sig := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(hmacSign(a.Secret, payload))) {
+		return "", ErrInvalidToken
+	}
+
+	encoded, expire, ok := strings.Cut(payload, ".")
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	expireAt, err := strconv.ParseInt(expire, 10, 64)
+	if err != nil || (expireAt > 0 && time.Now().Unix() > expireAt) {
+		return "", ErrInvalidToken
+	}
+	identity, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	return string(identity), nil
+}
+
+func hmacSign(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ACL 按身份和组授予权限，身份或组为 "*" 的规则匹配任意身份或组，同一身份的多条规则取并集
+type ACL struct {
+	mu    sync.RWMutex
+	rules map[string]map[string]Permission // 身份 -> 组 -> 权限
+}
+
+// NewACL 创建一个空的 ACL，未授权的操作全部拒绝
+func NewACL() *ACL {
+	return &ACL{rules: make(map[string]map[string]Permission)}
+}
+
+// Grant 为身份在组上追加权限
+func (a *ACL) Grant(identity, group string, perm Permission) *ACL {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	groups, ok := a.rules[identity]
+	if !ok {
+		groups = make(map[string]Permission)
+		a.rules[identity] = groups
+	}
+	groups[group] |= perm
+	return a
+}
+
+// Revoke 收回身份在组上的权限
+func (a *ACL) Revoke(identity, group string, perm Permission) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if groups, ok := a.rules[identity]; ok {
+		groups[group] &^= perm
+		if groups[group] == 0 {
+			delete(groups, group)
+		}
+	}
+}
+
+// Allowed 判断身份是否拥有组上的全部 perm 权限
+func (a *ACL) Allowed(identity, group string, perm Permission) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var granted Permission
+	for _, id := range []string{identity, "*"} {
+		groups := a.rules[id]
+		granted |= groups[group] | groups["*"]
+	}
+	return granted&perm == perm
+}
+
+// AuditEvent 审计事件
+type AuditEvent struct {
+	Time     time.Time
+	Identity string     // 已认证的身份，认证失败时为空
+	Method   string     // gRPC 方法全名
+	Group    string     // 请求的组
+	Peer     string     // 调用方地址
+	Required Permission // 方法需要的权限
+	Reason   string     // 拒绝原因
+}
+
+// AuditLogger 记录被拒绝的请求
+type AuditLogger interface {
+	Audit(event AuditEvent)
+}
+
+// AuditLoggerFunc 函数类型实现 AuditLogger 接口
+type AuditLoggerFunc func(event AuditEvent)
+
+// Audit 实现 AuditLogger 接口
+func (f AuditLoggerFunc) Audit(event AuditEvent) {
+	f(event)
+}
+
+// logAudit 默认的审计日志，输出到 logrus
+func logAudit(e AuditEvent) {
+	logrus.WithFields(logrus.Fields{
+		"identity": e.Identity,
+		"method":   e.Method,
+		"group":    e.Group,
+		"peer":     e.Peer,
+		"required": e.Required.String(),
+	}).Warnf("[LCache] audit: denied request: %s", e.Reason)
+}
+
+// identityKey 身份在 context 中的键
+type identityKey struct{}
+
+// IdentityFromContext 返回认证拦截器写入 context 的调用方身份
+func IdentityFromContext(ctx context.Context) (string, bool) {
+	identity, ok := ctx.Value(identityKey{}).(string)
+	return identity, ok
+}
+
+// authInterceptor 认证并按 ACL 鉴权的一元拦截器，只作用于 LCache 服务，健康检查等其他服务不受影响
+func authInterceptor(auth Authenticator, acl *ACL, audit AuditLogger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !strings.HasPrefix(info.FullMethod, "/pb.LCache/") {
+			return handler(ctx, req)
+		}
+
+		var group string
+		if r, ok := req.(interface{ GetGroup() string }); ok {
+			group = requestGroup(ctx, r.GetGroup())
+		}
+		ctx, err := authorize(ctx, auth, acl, audit, info.FullMethod, group)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authStreamInterceptor 流式 RPC 的认证拦截器，组名在请求消息中，因此在收到请求后再鉴权
+func authStreamInterceptor(auth Authenticator, acl *ACL, audit AuditLogger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !strings.HasPrefix(info.FullMethod, "/pb.LCache/") {
+			return handler(srv, ss)
+		}
+
+		stream := &recvHookStream{ServerStream: ss, ctx: ss.Context()}
+		stream.onRecv = func(m interface{}) error {
+			var group string
+			if r, ok := m.(interface{ GetGroup() string }); ok {
+				group = requestGroup(stream.ctx, r.GetGroup())
+			}
+			ctx, err := authorize(stream.ctx, auth, acl, audit, info.FullMethod, group)
+			stream.ctx = ctx
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// recvHookStream 在收到每条请求消息后调用 onRecv 的服务端流，onRecv 可以替换流的 context，ctx 为 nil 时沿用原来的
+type recvHookStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	onRecv func(m interface{}) error
+}
+
+func (s *recvHookStream) Context() context.Context {
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return s.ServerStream.Context()
+}
+
+func (s *recvHookStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.onRecv(m)
+}
+
+// authorize 认证调用方并检查其在组上是否拥有方法需要的权限，通过时返回带有身份的 context
+func authorize(ctx context.Context, auth Authenticator, acl *ACL, audit AuditLogger, method, group string) (context.Context, error) {
+	required, ok := methodPermissions[method]
+	if !ok {
+		required = PermAdmin
+	}
+
+	event := AuditEvent{Time: time.Now(), Method: method, Group: group, Required: required}
+	if p, ok := peer.FromContext(ctx); ok {
+		event.Peer = p.Addr.String()
+	}
+
+	identity, err := auth.Authenticate(ctx, bearerToken(ctx))
+	if err != nil {
+		event.Reason = err.Error()
+		audit.Audit(event)
+		return ctx, status.Error(codes.Unauthenticated, err.Error())
+	}
+	event.Identity = identity
+
+	if acl != nil && !acl.Allowed(identity, group, required) {
+		event.Reason = fmt.Sprintf("missing %s permission", required)
+		audit.Audit(event)
+		return ctx, status.Errorf(codes.PermissionDenied, "%s has no %s permission on group %s", identity, required, group)
+	}
+	return context.WithValue(ctx, identityKey{}, identity), nil
+}
+
+// markedAsPeer 判断请求是否带有节点间同步的标记，标记本身不可信，见 Server.peerVersion
+func markedAsPeer(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	v := md.Get(peerHeader)
+	return len(v) > 0 && v[0] == "true"
+}
+
+// trustedPeer 判断请求是否来自可信的节点：带有节点间同步的标记，启用认证时调用方的身份还必须拥有组的 PermPeer 权限
+func trustedPeer(ctx context.Context, authEnabled bool, acl *ACL, group string) bool {
+	if !markedAsPeer(ctx) {
+		return false
+	}
+	if authEnabled {
+		identity, ok := IdentityFromContext(ctx)
+		if !ok || (acl != nil && !acl.Allowed(identity, group, PermPeer)) {
+			return false
+		}
+	}
+	return true
+}
+
+// peerClientInterceptor 客户端拦截器：把请求标记为节点间同步
+func peerClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(metadata.AppendToOutgoingContext(ctx, peerHeader, "true"), method, req, reply, cc, opts...)
+}
+
+// bearerToken 从请求元数据中取出令牌
+func bearerToken(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, v := range md.Get(authorizationHeader) {
+		if token, ok := strings.CutPrefix(v, "Bearer "); ok {
+			return token
+		}
+	}
+	return ""
+}
+
+// tokenCredentials 在每个请求上附加令牌的 gRPC 凭据
+type tokenCredentials struct {
+	token    string
+	insecure bool // 是否允许在未加密的连接上发送令牌
+}
+
+func (t tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{authorizationHeader: "Bearer " + t.token}, nil
+}
+
+// RequireTransportSecurity 默认只在 TLS 连接上发送令牌，以免令牌泄露，WithInsecureToken 时允许明文连接
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return !t.insecure
+}
@@ -0,0 +1,120 @@
+package LCache
+
+import (
+	"context"
+	"testing"
+
+	pb "LCache/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestACLAllowed(t *testing.T) {
+	acl := NewACL().
+		Grant("alice", "orders", PermRead|PermWrite).
+		Grant("alice", "*", PermRead).
+		Grant("*", "public", PermRead).
+		Grant("admin", "*", PermAll).
+		Grant("peer", "orders", PermPeer)
+
+	tests := []struct {
+		identity string
+		group    string
+		perm     Permission
+		want     bool
+	}{
+		{"alice", "orders", PermRead, true},
+		{"alice", "orders", PermRead | PermWrite, true},
+		{"alice", "orders", PermAdmin, false},
+		{"alice", "users", PermRead, true},              // 组通配
+		{"alice", "users", PermWrite, false},            // 组通配只授予了读
+		{"bob", "public", PermRead, true},               // 身份通配
+		{"bob", "public", PermWrite, false},             // 身份通配只授予了读
+		{"bob", "orders", PermRead, false},              // 没有规则
+		{"alice", "public", PermRead, true},             // 多条规则取并集
+		{"admin", "anything", PermAll, true},            // 组通配授予全部权限
+		{"peer", "orders", PermPeer | PermWrite, false}, // 需要同时拥有全部权限
+	}
+	for _, tt := range tests {
+		if got := acl.Allowed(tt.identity, tt.group, tt.perm); got != tt.want {
+			t.Errorf("Allowed(%q, %q, %s) = %v, want %v", tt.identity, tt.group, tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestACLRevoke(t *testing.T) {
+	acl := NewACL().Grant("alice", "orders", PermRead|PermWrite)
+	acl.Revoke("alice", "orders", PermWrite)
+	if acl.Allowed("alice", "orders", PermWrite) {
+		t.Error("revoked permission is still allowed")
+	}
+	if !acl.Allowed("alice", "orders", PermRead) {
+		t.Error("revoke removed an unrelated permission")
+	}
+	acl.Revoke("alice", "orders", PermRead)
+	if _, ok := acl.rules["alice"]["orders"]; ok {
+		t.Error("empty rule was not removed")
+	}
+}
+
+func TestAuthorizeMethodPermissions(t *testing.T) {
+	tests := []struct {
+		method string
+		want   Permission
+	}{
+		{pb.LCache_Get_FullMethodName, PermRead},
+		{pb.LCache_Scan_FullMethodName, PermRead},
+		{pb.LCache_Watch_FullMethodName, PermRead},
+		{pb.LCache_Set_FullMethodName, PermWrite},
+		{pb.LCache_Delete_FullMethodName, PermWrite},
+		{pb.LCache_Incr_FullMethodName, PermWrite},
+		{pb.LCache_SetIfAbsent_FullMethodName, PermWrite},
+		{pb.LCache_CompareAndSwap_FullMethodName, PermWrite},
+		{pb.LCache_Invalidate_FullMethodName, PermAdmin},
+		{"/pb.LCache/Unknown", PermAdmin}, // 未列出的方法需要管理权限
+	}
+
+	auth := StaticTokens{"token": "alice"}
+	audit := AuditLoggerFunc(func(AuditEvent) {})
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(authorizationHeader, "Bearer token"))
+
+	for _, tt := range tests {
+		for _, perm := range []Permission{PermRead, PermWrite, PermAdmin, PermPeer} {
+			acl := NewACL().Grant("alice", "orders", perm)
+			got, err := authorize(ctx, auth, acl, audit, tt.method, "orders")
+			if perm == tt.want {
+				if err != nil {
+					t.Errorf("%s with %s: %v", tt.method, perm, err)
+				} else if identity, _ := IdentityFromContext(got); identity != "alice" {
+					t.Errorf("%s with %s: identity = %q, want alice", tt.method, perm, identity)
+				}
+				continue
+			}
+			if status.Code(err) != codes.PermissionDenied {
+				t.Errorf("%s with %s: error = %v, want PermissionDenied", tt.method, perm, err)
+			}
+		}
+	}
+}
+
+func TestAuthorizeRejectsInvalidToken(t *testing.T) {
+	var denied []AuditEvent
+	audit := AuditLoggerFunc(func(e AuditEvent) { denied = append(denied, e) })
+	acl := NewACL().Grant("*", "*", PermAll)
+
+	for _, md := range []metadata.MD{
+		metadata.Pairs(),
+		metadata.Pairs(authorizationHeader, "Bearer wrong"),
+		metadata.Pairs(authorizationHeader, "token"), // 缺少 Bearer 前缀
+	} {
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		_, err := authorize(ctx, StaticTokens{"token": "alice"}, acl, audit, pb.LCache_Get_FullMethodName, "orders")
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("metadata %v: error = %v, want Unauthenticated", md, err)
+		}
+	}
+	if len(denied) != 3 {
+		t.Errorf("audited %d denied requests, want 3", len(denied))
+	}
+}
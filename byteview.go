@@ -1,20 +1,144 @@
 package LCache
 
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
 // ByteView 只读的字节视图，用于缓存数据
+// 组配置了压缩算法或加密时 b 保存压缩、加密后的字节，读取数据时才解密和解压
 type ByteView struct {
-	b []byte
+	b        []byte
+	codec    Codec           // 压缩算法，nil 表示未压缩
+	enc      *valueEncryptor // 加密器，nil 表示未加密
+	version  uint64          // 条目版本号，每次写入都会变化，用作 CompareAndSwap 的令牌；0 表示未知
+	plain    []byte          // 已解密、解压的数据，由 decodeView 设置，只存在于返回给调用方的视图中
+	expireAt int64           // 写入本地缓存时的过期时间（纳秒），0 表示永不过期，只用于区分过期和淘汰，不会序列化
 }
 
+// storedEncrypted 保存形式中表示值已加密的标志位
+const storedEncrypted = 1
+
+// Len 返回视图在缓存中占用的字节数，压缩时为压缩后的长度
 func (b ByteView) Len() int {
 	return len(b.b)
 }
 
 func (b ByteView) ByteSLice() []byte {
-	return cloneBytes(b.b)
+	return b.Bytes()
+}
+
+// Bytes 返回数据的拷贝，实现 store.BytesValue 接口，使 ByteView 可以保存在序列化型存储中
+func (b ByteView) Bytes() []byte {
+	if b.codec == nil && b.enc == nil {
+		return cloneBytes(b.b)
+	}
+	if b.plain != nil {
+		return cloneBytes(b.plain)
+	}
+	return b.data()
+}
+
+// Version 返回条目的版本号，可作为 Group.CompareAndSwap 的令牌
+func (b ByteView) Version() uint64 {
+	return b.version
+}
+
+// Encode 实现 store.EncodedValue 接口，序列化型存储中保存 版本号(8) + 保存形式（见 appendStored）
+func (b ByteView) Encode() []byte {
+	buf := make([]byte, 8, 8+3+len(b.b))
+	binary.LittleEndian.PutUint64(buf, b.version)
+	return b.appendStored(buf)
+}
+
+// appendStored 追加值的保存形式：标志位(1) | 算法名长度(1) | 算法名 | 压缩、加密后的数据
+// 保存形式不包含明文，用于序列化型存储和快照
+func (b ByteView) appendStored(buf []byte) []byte {
+	var flags byte
+	if b.enc != nil {
+		flags |= storedEncrypted
+	}
+	name := b.codecName()
+	buf = append(buf, flags, byte(len(name)))
+	buf = append(buf, name...)
+	return append(buf, b.b...)
+}
+
+// decodeStored 将 appendStored 生成的保存形式还原为视图，加密的值使用 enc 解密，enc 为 nil 时返回错误
+func decodeStored(stored []byte, enc *valueEncryptor, version uint64) (ByteView, error) {
+	if len(stored) < 2 || len(stored) < 2+int(stored[1]) {
+		return ByteView{}, errors.New("malformed stored value")
+	}
+	n := int(stored[1])
+	return encodedView(stored[2+n:], string(stored[2:2+n]), stored[0]&storedEncrypted != 0, enc, version)
+}
+
+// encodedView 用已经压缩、加密的字节创建视图，例如其他节点发来的值
+func encodedView(value []byte, codecName string, encrypted bool, enc *valueEncryptor, version uint64) (ByteView, error) {
+	codec, err := lookupCodec(codecName)
+	if err != nil {
+		return ByteView{}, err
+	}
+	view := ByteView{b: cloneBytes(value), codec: codec, version: version}
+	if encrypted {
+		if enc == nil {
+			return ByteView{}, ErrEncryptionNotConfigured
+		}
+		view.enc = enc
+	}
+	return view, nil
 }
 
 func (b ByteView) String() string {
-	return string(b.b)
+	return string(b.data())
+}
+
+// data 返回解密、解压后的数据，未压缩也未加密时直接返回底层切片，调用方不能修改
+// 解密或解压失败说明数据损坏或密钥不可用，此时记录日志并返回空；Group.Get 返回的视图已经校验过，不会失败
+func (b ByteView) data() []byte {
+	data, err := b.decode()
+	if err != nil {
+		logrus.Errorf("[LCache] %v", err)
+		return nil
+	}
+	return data
+}
+
+// decode 解密、解压数据，未压缩也未加密时直接返回底层切片，调用方不能修改
+func (b ByteView) decode() ([]byte, error) {
+	if b.plain != nil {
+		return b.plain, nil
+	}
+	data := b.b
+	if b.enc != nil {
+		var err error
+		if data, err = b.enc.open(data); err != nil {
+			return nil, fmt.Errorf("failed to decrypt value: %w", err)
+		}
+	}
+	if b.codec != nil {
+		var err error
+		if data, err = b.codec.Decode(data); err != nil {
+			return nil, fmt.Errorf("failed to decompress value with %s: %w", b.codec.Name(), err)
+		}
+	}
+	return data, nil
+}
+
+// decodeView 解码压缩或加密的视图，并把明文保存在返回的视图中，之后读取数据不再重复解码
+func decodeView(view ByteView) (ByteView, error) {
+	if view.codec == nil && view.enc == nil {
+		return view, nil
+	}
+	data, err := view.decode()
+	if err != nil {
+		return ByteView{}, err
+	}
+	view.plain = data
+	return view, nil
 }
 
 func cloneBytes(b []byte) []byte {
@@ -3,6 +3,11 @@ package LCache
 import (
 	"LCache/store"
 	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -13,12 +18,19 @@ import (
 // Cache 是对底层（LRU / LRU2）缓存存储的封装
 type Cache struct {
 	mu          sync.RWMutex
-	store       store.Store  // 底层存储实现
-	opts        CacheOptions // 缓存配置选项
-	hits        int64        // 缓存命中次数
-	misses      int64        // 缓存未命中次数
-	initialized int32        // 原子变量，标记缓存是否已初始化
-	closed      int32        // 原子变量，标记缓存是否已关闭
+	store       store.Store                                            // 底层存储实现
+	prev        store.Store                                            // 迁移期间的旧存储，尚未复制的条目从这里读取
+	generation  int32                                                  // 原子变量，当前存储的代数，旧存储的回调会被忽略
+	opts        CacheOptions                                           // 缓存配置选项
+	tags        *store.TagIndex                                        // 标签索引，条目离开缓存时同步清理
+	encryptor   *valueEncryptor                                        // 组的值加密器，序列化型存储中读出的加密值需要关联它才能解密
+	onRemoved   func(key string, value ByteView, reason removalReason) // 条目离开缓存时的通知，单个键的显式删除除外
+	deleting    sync.Map                                               // 正在显式删除的键 -> removalReason
+	clearing    int32                                                  // 原子变量，标记正在清空缓存
+	hits        int64                                                  // 缓存命中次数
+	misses      int64                                                  // 缓存未命中次数
+	initialized int32                                                  // 原子变量，标记缓存是否已初始化
+	closed      int32                                                  // 原子变量，标记缓存是否已关闭
 }
 
 // CacheOptions 缓存配置选项
@@ -30,6 +42,9 @@ type CacheOptions struct {
 	Level2Cap    uint16                              // 二级缓存桶的容量 (用于 LRU2)
 	CleanupTime  time.Duration                       // 清理间隔
 	OnEvicted    func(key string, value store.Value) // 驱逐回调
+	MemoryTier   store.CacheType                     // 内存层缓存类型 (用于 Tiered)
+	DiskDir      string                              // 磁盘层目录 (用于 Tiered)
+	DiskMaxBytes int64                               // 磁盘层字节预算 (用于 Tiered)
 }
 
 // DefaultCacheOptions 返回默认的缓存配置
@@ -45,19 +60,31 @@ func DefaultCacheOptions() CacheOptions {
 	}
 }
 
+// validate 检查缓存类型（以及两级存储的内存层类型）是否已注册
+func (opts CacheOptions) validate() error {
+	if !slices.Contains(store.CacheTypes(), opts.CacheType) {
+		return fmt.Errorf("%w: %q", store.ErrUnknownCacheType, opts.CacheType)
+	}
+	if opts.CacheType == store.Tiered && opts.MemoryTier != "" && !slices.Contains(store.CacheTypes(), opts.MemoryTier) {
+		return fmt.Errorf("%w: memory tier %q", store.ErrUnknownCacheType, opts.MemoryTier)
+	}
+	return nil
+}
+
 // NewCache 创建一个新的缓存实例
 func NewCache(opts CacheOptions) *Cache {
 	return &Cache{
 		opts: opts,
+		tags: store.NewTagIndex(),
 	}
 }
 
 // 确保 懒初始化 在并发环境中是安全且高性能的，只有在首次访问缓存时才会创建底层 store.Store，并且只会创建一次
-// ensureInitialized 确保缓存已初始化
-func (c *Cache) ensureInitialized() {
+// ensureInitialized 确保缓存已初始化，缓存类型未注册时返回错误
+func (c *Cache) ensureInitialized() error {
 	// 快速检查缓存是否已初始化，避免不必要的锁争用
 	if atomic.LoadInt32(&c.initialized) == 1 {
-		return
+		return nil
 	}
 
 	// 双重检查锁定模式
@@ -65,38 +92,166 @@ func (c *Cache) ensureInitialized() {
 	defer c.mu.Unlock()
 
 	if c.initialized == 0 {
-		// 创建存储选项
-		storeOpts := store.Options{
-			MaxBytes:        c.opts.MaxBytes,
-			BucketCount:     c.opts.BucketCount,
-			CapPerBucket:    c.opts.CapPerBucket,
-			Level2Cap:       c.opts.Level2Cap,
-			CleanupInterval: c.opts.CleanupTime,
-			OnEvicted:       c.opts.OnEvicted,
-		}
-
 		// 创建存储实例
-		c.store = store.NewStore(c.opts.CacheType, storeOpts)
+		s, err := c.newStore(c.opts, c.generation+1)
+		if err != nil {
+			return err
+		}
+		c.store = s
+		atomic.AddInt32(&c.generation, 1)
 
 		// 标记为已初始化
 		atomic.StoreInt32(&c.initialized, 1)
 
 		logrus.Infof("Cache initialized with type %s, max bytes: %d", c.opts.CacheType, c.opts.MaxBytes)
 	}
+
+	return nil
+}
+
+// newStore 按 opts 创建存储实例，gen 为它成为当前存储后的代数
+func (c *Cache) newStore(opts CacheOptions, gen int32) (store.Store, error) {
+	storeOpts := store.Options{
+		MaxBytes:        opts.MaxBytes,
+		BucketCount:     opts.BucketCount,
+		CapPerBucket:    opts.CapPerBucket,
+		Level2Cap:       opts.Level2Cap,
+		CleanupInterval: opts.CleanupTime,
+		// 被替换的旧存储中的条目已复制到新存储或被新值覆盖，它的回调不再代表条目离开缓存
+		OnEvicted: func(key string, value store.Value) {
+			if atomic.LoadInt32(&c.generation) == gen {
+				c.onEvicted(key, value)
+			}
+		},
+		DecodeValue:  c.decodeByteView,
+		MemoryTier:   opts.MemoryTier,
+		DiskDir:      opts.DiskDir,
+		DiskMaxBytes: opts.DiskMaxBytes,
+	}
+	return store.NewStore(opts.CacheType, storeOpts)
+}
+
+// decodeByteView 将序列化型存储中读出的字节（ByteView.Encode 的结果）还原为 ByteView
+func (c *Cache) decodeByteView(b []byte) store.Value {
+	if len(b) < 8 {
+		return ByteView{b: b}
+	}
+	view, err := decodeStored(b[8:], c.encryptor, binary.LittleEndian.Uint64(b))
+	if err != nil {
+		logrus.Errorf("[LCache] failed to decode cached value: %v", err)
+		return ByteView{}
+	}
+	return view
+}
+
+// onEvicted 条目被淘汰、过期或删除时清理其标签，再调用配置的驱逐回调
+func (c *Cache) onEvicted(key string, value store.Value) {
+	c.tags.Remove(key)
+	if c.opts.OnEvicted != nil {
+		c.opts.OnEvicted(key, value)
+	}
+	if c.onRemoved != nil {
+		if bv, ok := value.(ByteView); ok {
+			if reason := c.removalReason(key, bv); reason != removalDeleted {
+				c.onRemoved(key, bv, reason)
+			}
+		}
+	}
+}
+
+// removalReason 条目离开缓存的原因
+type removalReason int
+
+const (
+	removalDeleted     removalReason = iota // 单个键的显式删除，由调用方自行通知
+	removalExpired                          // 过期
+	removalCapacity                         // 因容量被淘汰
+	removalInvalidated                      // 按前缀或标签批量失效
+	removalCleared                          // 缓存被清空
+)
+
+// expireClockSkew 判断条目是否过期时容许的时钟误差，部分存储（如 lru2）使用每秒校准一次的粗粒度时钟
+const expireClockSkew = 250 * time.Millisecond
+
+// removalReason 判断条目离开缓存的原因
+// 存储的回调不区分过期和淘汰，按写入时记录的过期时间判断；序列化型存储不保存过期时间，过期的条目会被视为淘汰
+func (c *Cache) removalReason(key string, value ByteView) removalReason {
+	if v, ok := c.deleting.Load(key); ok {
+		return v.(removalReason)
+	}
+	if atomic.LoadInt32(&c.clearing) == 1 {
+		return removalCleared
+	}
+	if value.expireAt > 0 && time.Now().Add(expireClockSkew).UnixNano() >= value.expireAt {
+		return removalExpired
+	}
+	return removalCapacity
+}
+
+// deleteFromStore 从存储中删除键，reason 为删除触发的回调中通知 onRemoved 的原因，调用前必须持有读锁
+func (c *Cache) deleteFromStore(key string, reason removalReason) bool {
+	c.tags.Remove(key)
+	deleted := false
+	if c.prev != nil {
+		deleted = c.prev.Delete(key)
+	}
+	if c.onRemoved == nil {
+		return c.store.Delete(key) || deleted
+	}
+	c.deleting.Store(key, reason)
+	defer c.deleting.Delete(key)
+	return c.store.Delete(key) || deleted
+}
+
+// set 写入当前存储，迁移期间同时从旧存储中删除该键，使旧值不会再被复制过来
+func (c *Cache) set(key string, value ByteView, expiration time.Duration) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.store == nil {
+		return nil
+	}
+	var err error
+	if expiration > 0 {
+		err = c.store.SetWithExpiration(key, value, expiration)
+	} else {
+		err = c.store.Set(key, value)
+	}
+	if err == nil && c.prev != nil {
+		c.prev.Delete(key)
+	}
+	return err
+}
+
+// lookup 从当前存储读取，迁移期间未命中时再读取旧存储，调用前必须持有读锁
+func (c *Cache) lookup(key string) (store.Value, bool) {
+	if val, found := c.store.Get(key); found {
+		return val, true
+	}
+	if c.prev != nil {
+		return c.prev.Get(key)
+	}
+	return nil, false
 }
 
-// Add 向缓存中添加一个 key-value 对
-func (c *Cache) Add(key string, value ByteView) {
+// Add 向缓存中添加一个 key-value 对，tags 会替换该键原有的标签
+func (c *Cache) Add(key string, value ByteView, tags ...string) {
 	if atomic.LoadInt32(&c.closed) == 1 {
 		logrus.Warnf("Attempted to add to a closed cache: %s", key)
 		return
 	}
 
-	c.ensureInitialized()
+	if err := c.ensureInitialized(); err != nil {
+		logrus.Errorf("Failed to initialize cache: %v", err)
+		return
+	}
 
-	if err := c.store.Set(key, value); err != nil {
+	if err := c.set(key, value, 0); err != nil {
 		logrus.Warnf("Failed to add key %s to cache: %v", key, err)
+		return
 	}
+	// 先写存储再更新索引：并发淘汰最多留下一个指向已不存在的键的索引项，不会漏掉仍在缓存中的键
+	c.tags.Set(key, tags)
 }
 
 // Get 从缓存中获取值
@@ -115,7 +270,7 @@ func (c *Cache) Get(ctx context.Context, key string) (value ByteView, ok bool) {
 	defer c.mu.RUnlock()
 
 	// 从底层存储获取
-	val, found := c.store.Get(key)
+	val, found := c.lookup(key)
 	if !found {
 		atomic.AddInt64(&c.misses, 1)
 		return ByteView{}, false
@@ -136,15 +291,35 @@ func (c *Cache) Get(ctx context.Context, key string) (value ByteView, ok bool) {
 	return ByteView{}, false
 }
 
+// peek 读取缓存项但不计入命中统计，用于原子操作读取当前值
+func (c *Cache) peek(key string) (ByteView, bool) {
+	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
+		return ByteView{}, false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	val, found := c.lookup(key)
+	if !found {
+		return ByteView{}, false
+	}
+	bv, ok := val.(ByteView)
+	return bv, ok
+}
+
 // AddWithExpiration 向缓存中添加一个带过期时间的 key-value 对
 // 适用于 短期热点数据、时间敏感数据 的缓存
-func (c *Cache) AddWithExpiration(key string, value ByteView, expirationTime time.Time) {
+func (c *Cache) AddWithExpiration(key string, value ByteView, expirationTime time.Time, tags ...string) {
 	if atomic.LoadInt32(&c.closed) == 1 {
 		logrus.Warnf("Attempted to add to a closed cache: %s", key)
 		return
 	}
 
-	c.ensureInitialized()
+	if err := c.ensureInitialized(); err != nil {
+		logrus.Errorf("Failed to initialize cache: %v", err)
+		return
+	}
 
 	// 计算过期时间
 	expiration := time.Until(expirationTime)
@@ -154,9 +329,12 @@ func (c *Cache) AddWithExpiration(key string, value ByteView, expirationTime tim
 	}
 
 	// 设置到底层存储
-	if err := c.store.SetWithExpiration(key, value, expiration); err != nil {
+	value.expireAt = expirationTime.UnixNano()
+	if err := c.set(key, value, expiration); err != nil {
 		logrus.Warnf("Failed to add key %s to cache with expiration: %v", key, err)
+		return
 	}
+	c.tags.Set(key, tags)
 }
 
 // Delete 从缓存中删除一个 key
@@ -168,7 +346,47 @@ func (c *Cache) Delete(key string) bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return c.store.Delete(key)
+	return c.deleteFromStore(key, removalDeleted)
+}
+
+// DeletePrefix 删除所有以 prefix 开头的键，返回删除的数量
+func (c *Cache) DeletePrefix(prefix string) int {
+	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
+		return 0
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys, _, err := c.scanLocked("", store.EscapePattern(prefix)+"*", math.MaxInt)
+	if err != nil {
+		logrus.Warnf("Failed to scan keys with prefix %s: %v", prefix, err)
+		return 0
+	}
+	return c.deleteKeys(keys)
+}
+
+// InvalidateTag 删除所有带有 tag 标签的键，返回删除的数量
+func (c *Cache) InvalidateTag(tag string) int {
+	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
+		return 0
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.deleteKeys(c.tags.Keys(tag))
+}
+
+// deleteKeys 逐个删除键，调用此方法前必须持有读锁
+func (c *Cache) deleteKeys(keys []string) int {
+	deleted := 0
+	for _, key := range keys {
+		if c.deleteFromStore(key, removalInvalidated) {
+			deleted++
+		}
+	}
+	return deleted
 }
 
 // Clear 清空缓存
@@ -180,14 +398,20 @@ func (c *Cache) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	atomic.StoreInt32(&c.clearing, 1)
+	if c.prev != nil {
+		c.prev.Clear()
+	}
 	c.store.Clear()
+	atomic.StoreInt32(&c.clearing, 0)
+	c.tags.Reset()
 
 	// 重置统计信息
 	atomic.StoreInt64(&c.hits, 0)
 	atomic.StoreInt64(&c.misses, 0)
 }
 
-// Len 返回缓存的当前存储项数量
+// Len 返回缓存的当前存储项数量，迁移期间包括旧存储中尚未复制的条目
 func (c *Cache) Len() int {
 	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
 		return 0
@@ -196,9 +420,312 @@ func (c *Cache) Len() int {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
+	if c.prev != nil {
+		return c.store.Len() + c.prev.Len()
+	}
 	return c.store.Len()
 }
 
+// Range 遍历缓存中所有未过期的条目，fn 返回 false 时停止；ttl 为剩余存活时间，0 表示永不过期
+func (c *Cache) Range(fn func(key string, value ByteView, ttl time.Duration) bool) {
+	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
+		return
+	}
+
+	c.mu.RLock()
+	s, prev := c.store, c.prev
+	c.mu.RUnlock()
+
+	// 迁移期间先遍历新存储，再遍历旧存储中尚未被覆盖的条目
+	var seen map[string]struct{}
+	if prev != nil {
+		seen = make(map[string]struct{})
+	}
+	stopped := false
+	s.Range(func(key string, value store.Value, ttl time.Duration) bool {
+		bv, ok := value.(ByteView)
+		if !ok {
+			return true
+		}
+		if seen != nil {
+			seen[key] = struct{}{}
+		}
+		stopped = !fn(key, bv, ttl)
+		return !stopped
+	})
+	if prev == nil || stopped {
+		return
+	}
+	prev.Range(func(key string, value store.Value, ttl time.Duration) bool {
+		bv, ok := value.(ByteView)
+		if _, dup := seen[key]; !ok || dup {
+			return true
+		}
+		return fn(key, bv, ttl)
+	})
+}
+
+// Scan 按键的字典序分页返回匹配 glob 模式 match 的键，cursor 为空表示从头开始，返回的 next 为空表示遍历结束
+func (c *Cache) Scan(cursor, match string, count int) ([]string, string, error) {
+	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
+		return nil, "", nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.scanLocked(cursor, match, count)
+}
+
+// scanLocked 分页返回键，迁移期间合并新旧存储的结果，调用前必须持有读锁
+func (c *Cache) scanLocked(cursor, match string, count int) ([]string, string, error) {
+	keys, next, err := c.store.Scan(cursor, match, count)
+	if err != nil || c.prev == nil {
+		return keys, next, err
+	}
+	prevKeys, prevNext, err := c.prev.Scan(cursor, match, count)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 两个存储各自返回 cursor 之后最小的 count 个键，合并后的前 count 个键一定都在其中
+	if count <= 0 {
+		count = store.DefaultScanCount
+	}
+	merged := append(keys, prevKeys...)
+	sort.Strings(merged)
+	merged = slices.Compact(merged)
+	if len(merged) > count || next != "" || prevNext != "" {
+		merged = merged[:min(count, len(merged))]
+		return merged, merged[len(merged)-1], nil
+	}
+	return merged, "", nil
+}
+
+// usedBytes 返回存储占用的字节数，迁移期间包括旧存储，存储不支持统计时为 0
+func (c *Cache) usedBytes() int64 {
+	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
+		return 0
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var used int64
+	for _, s := range []store.Store{c.store, c.prev} {
+		if r, ok := s.(store.UsageReporter); ok {
+			used += r.UsedBytes()
+		}
+	}
+	return used
+}
+
+// evict 按淘汰策略淘汰条目直到至少释放 bytes 字节，返回释放的字节数，存储不支持按需淘汰时为 0
+func (c *Cache) evict(bytes int64) int64 {
+	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
+		return 0
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if e, ok := c.store.(store.Evictor); ok {
+		return e.Evict(bytes)
+	}
+	return 0
+}
+
+// applyBudget 把存储的容量限制为预算和 MaxBytes 中较小的一个，budget <= 0 时恢复为 MaxBytes，
+// 存储不支持调整容量时返回 false
+func (c *Cache) applyBudget(budget int64) bool {
+	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
+		return false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	r, ok := c.store.(store.Resizable)
+	if !ok {
+		return false
+	}
+	limit := c.opts.MaxBytes
+	if budget > 0 && (limit <= 0 || budget < limit) {
+		limit = budget
+	}
+	r.SetMaxBytes(limit)
+	return true
+}
+
+// options 返回当前的缓存配置
+func (c *Cache) options() CacheOptions {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.opts
+}
+
+// reconfigure 在运行时修改缓存配置（驱逐回调保持不变）：存储支持时原地调整容量和清理间隔，
+// 否则（包括更换缓存类型）迁移到按新配置创建的存储，见 migrate
+func (c *Cache) reconfigure(opts CacheOptions, lockKey func(key string) func()) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	opts.OnEvicted = c.opts.OnEvicted
+	if c.initialized == 0 {
+		// 尚未创建存储，首次写入时按新配置创建
+		c.opts = opts
+		c.mu.Unlock()
+		return nil
+	}
+	inPlace, err := c.reconfigureInPlace(opts)
+	if err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	if inPlace {
+		c.opts = opts
+		c.mu.Unlock()
+		return nil
+	}
+	c.mu.Unlock()
+
+	return c.migrate(opts, lockKey)
+}
+
+// reconfigureInPlace 尝试在当前存储上应用新配置，存储不支持其中任一项修改时不做任何修改并返回 false，调用前必须持有写锁
+// 修改按条目数限制容量的存储（lru2）的 MaxBytes 时返回 ErrMaxBytesUnsupported：原地修改和迁移到同类型的存储都不会生效
+func (c *Cache) reconfigureInPlace(opts CacheOptions) (bool, error) {
+	old := c.opts
+	if opts.CacheType != old.CacheType || opts.BucketCount != old.BucketCount || opts.MemoryTier != old.MemoryTier ||
+		opts.DiskDir != old.DiskDir || opts.DiskMaxBytes != old.DiskMaxBytes {
+		return false, nil
+	}
+
+	resizer, resizable := c.store.(store.Resizable)
+	capResizer, bucketed := c.store.(store.CapacityResizable)
+	scheduler, schedulable := c.store.(store.CleanupScheduler)
+
+	// lru2 按每个桶的条目数限制容量，不使用 MaxBytes；tiered 把所有容量配置交给内存层，只能重建
+	maxBytesChanged := opts.MaxBytes != old.MaxBytes
+	capChanged := opts.CapPerBucket != old.CapPerBucket || opts.Level2Cap != old.Level2Cap
+	if maxBytesChanged && !resizable && bucketed {
+		return false, fmt.Errorf("%w: %s, use UpdateBucketCapacity instead", ErrMaxBytesUnsupported, opts.CacheType)
+	}
+	if maxBytesChanged && !resizable {
+		return false, nil
+	}
+	if capChanged && !bucketed && opts.CacheType == store.Tiered {
+		return false, nil
+	}
+	if opts.CleanupTime != old.CleanupTime && !schedulable {
+		return false, nil
+	}
+
+	if maxBytesChanged && resizable {
+		resizer.SetMaxBytes(opts.MaxBytes)
+	}
+	if capChanged && bucketed {
+		var capPerBucket, level2Cap uint16
+		if opts.CapPerBucket != old.CapPerBucket {
+			capPerBucket = opts.CapPerBucket
+		}
+		if opts.Level2Cap != old.Level2Cap {
+			level2Cap = opts.Level2Cap
+		}
+		capResizer.SetCapacity(capPerBucket, level2Cap)
+	}
+	if opts.CleanupTime != old.CleanupTime && schedulable {
+		scheduler.SetCleanupInterval(opts.CleanupTime)
+	}
+	return true, nil
+}
+
+// migrate 按 opts 创建新存储并立即切换，之后的写入进入新存储，读取在新存储未命中时回退到旧存储；
+// 旧存储中的条目按剩余存活时间逐个复制到新存储（已被新写入覆盖或删除的键跳过），全部复制完后关闭旧存储。
+// 复制单个条目时持有 lockKey 返回的键锁，与该键的写入互斥，避免旧值覆盖并发写入的新值
+func (c *Cache) migrate(opts CacheOptions, lockKey func(key string) func()) error {
+	c.mu.RLock()
+	gen := atomic.LoadInt32(&c.generation) + 1
+	c.mu.RUnlock()
+
+	s, err := c.newStore(opts, gen)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.store == nil {
+		c.mu.Unlock()
+		s.Close()
+		return nil
+	}
+	prev := c.store
+	c.store, c.prev, c.opts = s, prev, opts
+	atomic.StoreInt32(&c.generation, gen)
+	c.mu.Unlock()
+
+	logrus.Infof("[LCache] migrating cache to type %s, max bytes: %d", opts.CacheType, opts.MaxBytes)
+	start := time.Now()
+	copied := 0
+	prev.Range(func(key string, _ store.Value, ttl time.Duration) bool {
+		var expireAt time.Time
+		if ttl > 0 {
+			expireAt = time.Now().Add(ttl)
+		}
+		unlock := lockKey(key)
+		ok, done := c.copyEntry(prev, key, expireAt)
+		unlock()
+		if ok {
+			copied++
+		}
+		return !done
+	})
+
+	c.mu.Lock()
+	if c.prev == prev {
+		c.prev = nil
+		prev.Close()
+	}
+	c.mu.Unlock()
+
+	logrus.Infof("[LCache] migrated %d entries to cache type %s in %v", copied, opts.CacheType, time.Since(start))
+	return nil
+}
+
+// copyEntry 把旧存储中键的当前值复制到新存储并从旧存储删除，返回是否复制以及迁移是否已被清空或关闭缓存打断
+func (c *Cache) copyEntry(prev store.Store, key string, expireAt time.Time) (copied, done bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.prev != prev {
+		return false, true
+	}
+	value, ok := prev.Get(key)
+	if !ok {
+		return false, false
+	}
+	prev.Delete(key)
+
+	var err error
+	switch {
+	case expireAt.IsZero():
+		err = c.store.Set(key, value)
+	case time.Until(expireAt) > 0:
+		err = c.store.SetWithExpiration(key, value, time.Until(expireAt))
+	default:
+		c.tags.Remove(key)
+		return false, false
+	}
+	if err != nil {
+		c.tags.Remove(key)
+		logrus.Warnf("Failed to migrate key %s: %v", key, err)
+		return false, false
+	}
+	return true, false
+}
+
 // Close 关闭缓存，释放资源
 func (c *Cache) Close() {
 	// 如果已经关闭，直接返回
@@ -210,6 +737,10 @@ func (c *Cache) Close() {
 	defer c.mu.Unlock()
 
 	// 关闭底层存储
+	if c.prev != nil {
+		c.prev.Close()
+		c.prev = nil
+	}
 	if c.store != nil {
 		if closer, ok := c.store.(interface{ Close() }); ok {
 			closer.Close()
@@ -232,8 +763,13 @@ func (c *Cache) Stats() map[string]interface{} {
 		"misses":      atomic.LoadInt64(&c.misses),
 	}
 
+	c.mu.RLock()
+	stats["migrating"] = c.prev != nil
+	c.mu.RUnlock()
+
 	if atomic.LoadInt32(&c.initialized) == 1 {
 		stats["size"] = c.Len()
+		stats["tagged_keys"] = c.tags.Len()
 
 		// 计算命中率
 		totalRequests := stats["hits"].(int64) + stats["misses"].(int64)
@@ -3,26 +3,123 @@ package LCache
 import (
 	"context"
 	"fmt"
+	"io"
 	"time"
 
 	pb "LCache/pb"
 	"github.com/sirupsen/logrus"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 type Client struct {
-	addr    string
-	svcName string
-	etcdCli *clientv3.Client
-	conn    *grpc.ClientConn
-	grpcCli pb.LCacheClient
+	addr     string
+	svcName  string
+	etcdCli  *clientv3.Client
+	conn     *grpc.ClientConn
+	grpcCli  pb.LCacheClient
+	registry *Registry // 解密时查找同名组的注册中心
+	tenant   string    // 请求所属的租户，为空表示不属于任何租户
 }
 
-var _ Peer = (*Client)(nil)
+var (
+	_ Peer          = (*Client)(nil)
+	_ Invalidator   = (*Client)(nil)
+	_ VersionedPeer = (*Client)(nil)
+	_ CodecPeer     = (*Client)(nil)
+)
+
+// ClientOption 定义客户端配置选项
+type ClientOption func(*clientOptions)
+
+// clientOptions 客户端配置
+type clientOptions struct {
+	creds           credentials.TransportCredentials // 传输层凭据，默认不加密
+	token           string                           // 每个请求携带的认证令牌
+	insecureToken   bool                             // 是否允许在未加密的连接上发送令牌
+	priority        Priority                         // 请求的默认优先级
+	throttleRetries int                              // 被服务端限流后的最多重试次数
+	registry        *Registry                        // 解密时查找同名组的注册中心
+	tenant          string                           // 每个请求携带的租户
+	peer            bool                             // 是否是节点间同步使用的客户端
+}
+
+// WithTransportCredentials 设置连接使用的传输层凭据，例如 mTLS
+func WithTransportCredentials(creds credentials.TransportCredentials) ClientOption {
+	return func(o *clientOptions) {
+		o.creds = creds
+	}
+}
+
+// WithClientRegistry 设置解密加密值时查找同名组密钥的注册中心，默认 DefaultRegistry
+func WithClientRegistry(r *Registry) ClientOption {
+	return func(o *clientOptions) {
+		o.registry = r
+	}
+}
+
+// WithTenant 设置每个请求所属的租户，服务端访问该租户的组并计入租户的请求配额
+func WithTenant(name string) ClientOption {
+	return func(o *clientOptions) {
+		o.tenant = name
+	}
+}
+
+// asPeer 把客户端的请求标记为节点间同步，由 ClientPicker 为其他节点创建客户端时使用
+func asPeer() ClientOption {
+	return func(o *clientOptions) {
+		o.peer = true
+	}
+}
+
+// WithToken 设置每个请求携带的认证令牌
+func WithToken(token string) ClientOption {
+	return func(o *clientOptions) {
+		o.token = token
+	}
+}
+
+// WithInsecureToken 允许在未启用 TLS 的连接上发送 WithToken 设置的令牌，令牌可能被窃听，只应用于测试或可信网络
+func WithInsecureToken() ClientOption {
+	return func(o *clientOptions) {
+		o.insecureToken = true
+	}
+}
+
+// WithPriority 设置请求的默认优先级，单个请求可以用 ContextWithPriority 覆盖
+func WithPriority(p Priority) ClientOption {
+	return func(o *clientOptions) {
+		o.priority = p
+	}
+}
+
+// WithThrottleRetries 设置被服务端限流后按建议的等待时间重试的次数，0 表示不重试，默认 3 次
+func WithThrottleRetries(n int) ClientOption {
+	return func(o *clientOptions) {
+		o.throttleRetries = n
+	}
+}
+
+func NewClient(addr string, svcName string, etcdCli *clientv3.Client, opts ...ClientOption) (*Client, error) {
+	options := clientOptions{
+		creds:           insecure.NewCredentials(),
+		priority:        PriorityNormal,
+		throttleRetries: defaultThrottleRetries,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.registry == nil {
+		options.registry = DefaultRegistry
+	}
+	if options.token != "" && options.creds.Info().SecurityProtocol == "insecure" && !options.insecureToken {
+		return nil, ErrInsecureToken
+	}
 
-func NewClient(addr string, svcName string, etcdCli *clientv3.Client) (*Client, error) {
 	var err error
 	if etcdCli == nil {
 		etcdCli, err = clientv3.New(clientv3.Config{
@@ -34,12 +131,28 @@ func NewClient(addr string, svcName string, etcdCli *clientv3.Client) (*Client,
 		}
 	}
 
-	conn, err := grpc.Dial(addr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(options.creds),
 		grpc.WithBlock(),
-		grpc.WithTimeout(10*time.Second),
+		grpc.WithTimeout(10 * time.Second),
 		grpc.WithDefaultCallOptions(grpc.WaitForReady(true)),
-	)
+		grpc.WithChainUnaryInterceptor(throttleInterceptor(options.priority, options.throttleRetries)),
+		grpc.WithChainStreamInterceptor(priorityStreamInterceptor(options.priority)),
+	}
+	if options.token != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(tokenCredentials{token: options.token, insecure: options.insecureToken}))
+	}
+	if options.peer {
+		dialOpts = append(dialOpts, grpc.WithChainUnaryInterceptor(peerClientInterceptor))
+	}
+	if options.tenant != "" {
+		dialOpts = append(dialOpts,
+			grpc.WithChainUnaryInterceptor(tenantClientInterceptor(options.tenant)),
+			grpc.WithChainStreamInterceptor(tenantStreamClientInterceptor(options.tenant)),
+		)
+	}
+
+	conn, err := grpc.Dial(addr, dialOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial server: %v", err)
 	}
@@ -47,11 +160,13 @@ func NewClient(addr string, svcName string, etcdCli *clientv3.Client) (*Client,
 	grpcClient := pb.NewLCacheClient(conn)
 
 	client := &Client{
-		addr:    addr,
-		svcName: svcName,
-		etcdCli: etcdCli,
-		conn:    conn,
-		grpcCli: grpcClient,
+		addr:     addr,
+		svcName:  svcName,
+		etcdCli:  etcdCli,
+		conn:     conn,
+		grpcCli:  grpcClient,
+		registry: options.registry,
+		tenant:   options.tenant,
 	}
 
 	return client, nil
@@ -62,25 +177,73 @@ func (c *Client) Get(group, key string) ([]byte, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	value, _, err := c.GetVersioned(ctx, group, key)
+	return value, err
+}
+
+// GetVersioned 从缓存中获取值及其版本号
+func (c *Client) GetVersioned(ctx context.Context, group, key string) ([]byte, uint64, error) {
+	wv, err := c.GetEncoded(ctx, group, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	value, err := c.decodeWireValue(group, wv)
+	if err != nil {
+		return nil, 0, err
+	}
+	return value, wv.Version, nil
+}
+
+// decodeWireValue 解码其他节点返回的保存形式，加密的值只能用客户端注册中心中同名组的密钥解密
+// 设置了租户时同名组为该租户的组
+func (c *Client) decodeWireValue(group string, wv WireValue) ([]byte, error) {
+	if wv.Codec == "" && !wv.Encrypted {
+		return wv.Value, nil
+	}
+	if c.tenant != "" {
+		group = TenantGroupName(c.tenant, group)
+	}
+
+	var enc *valueEncryptor
+	if g := c.registry.Get(group); g != nil {
+		enc = g.encryptor
+	}
+	view, err := encodedView(wv.Value, wv.Codec, wv.Encrypted, enc, wv.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode value from lcache: %v", err)
+	}
+	data, err := view.decode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode value from lcache: %v", err)
+	}
+	return data, nil
+}
+
+// GetEncoded 从缓存中获取值的保存形式，压缩、加密的值不解码
+func (c *Client) GetEncoded(ctx context.Context, group, key string) (WireValue, error) {
 	resp, err := c.grpcCli.Get(ctx, &pb.Request{
 		Group: group,
 		Key:   key,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get value from lcache: %v", err)
+		return WireValue{}, fmt.Errorf("failed to get value from lcache: %v", err)
 	}
 
-	return resp.GetValue(), nil
+	return WireValue{
+		Value:     resp.GetValue(),
+		Version:   resp.GetVersion(),
+		Codec:     resp.GetCodec(),
+		Encrypted: resp.GetEncrypted(),
+	}, nil
 }
 
 // Delete 从缓存中删除指定 key
-func (c *Client) Delete(group, key string) (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
+func (c *Client) Delete(ctx context.Context, group, key string, opts ...WriteOption) (bool, error) {
+	wo := applyWriteOptions(opts)
 	resp, err := c.grpcCli.Delete(ctx, &pb.Request{
-		Group: group,
-		Key:   key,
+		Group:   group,
+		Key:     key,
+		Version: wo.version,
 	})
 	if err != nil {
 		return false, fmt.Errorf("failed to delete value from lcache: %v", err)
@@ -90,11 +253,16 @@ func (c *Client) Delete(group, key string) (bool, error) {
 }
 
 // Set 向缓存中写入值
-func (c *Client) Set(ctx context.Context, group, key string, value []byte) error {
+func (c *Client) Set(ctx context.Context, group, key string, value []byte, opts ...WriteOption) error {
+	wo := applyWriteOptions(opts)
 	resp, err := c.grpcCli.Set(ctx, &pb.Request{
-		Group: group,
-		Key:   key,
-		Value: value,
+		Group:     group,
+		Key:       key,
+		Value:     value,
+		Tags:      wo.tags,
+		Version:   wo.version,
+		Codec:     wo.codec,
+		Encrypted: wo.encrypted,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to set value to lcache: %v", err)
@@ -104,6 +272,169 @@ func (c *Client) Set(ctx context.Context, group, key string, value []byte) error
 	return nil
 }
 
+// Scan 分页列出远程节点缓存中匹配 match 的键，返回下一页的游标，游标为空表示遍历结束
+func (c *Client) Scan(group, cursor, match string, count int) ([]string, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	resp, err := c.grpcCli.Scan(ctx, &pb.ScanRequest{
+		Group:  group,
+		Cursor: cursor,
+		Match:  match,
+		Count:  int32(count),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to scan keys from lcache: %v", err)
+	}
+
+	return resp.GetKeys(), resp.GetCursor(), nil
+}
+
+// Incr 在远程节点上对整数值加上 delta，返回新值和版本号
+func (c *Client) Incr(ctx context.Context, group, key string, delta int64) (int64, uint64, error) {
+	resp, err := c.grpcCli.Incr(ctx, &pb.IncrRequest{
+		Group: group,
+		Key:   key,
+		Delta: delta,
+	})
+	if err != nil {
+		if e := fromStatusError(err); e != err {
+			return 0, 0, e
+		}
+		return 0, 0, fmt.Errorf("failed to incr value in lcache: %v", err)
+	}
+
+	return resp.GetValue(), resp.GetVersion(), nil
+}
+
+// SetIfAbsent 仅当远程节点上键不存在时写入，返回是否写入成功以及新的或当前的版本号
+func (c *Client) SetIfAbsent(ctx context.Context, group, key string, value []byte) (bool, uint64, error) {
+	resp, err := c.grpcCli.SetIfAbsent(ctx, &pb.Request{
+		Group: group,
+		Key:   key,
+		Value: value,
+	})
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to set value if absent in lcache: %v", err)
+	}
+
+	return resp.GetSwapped(), resp.GetVersion(), nil
+}
+
+// CompareAndSwap 仅当远程节点上键的版本号等于 version 时写入，返回是否写入成功以及新的或当前的版本号
+func (c *Client) CompareAndSwap(ctx context.Context, group, key string, value []byte, version uint64) (bool, uint64, error) {
+	resp, err := c.grpcCli.CompareAndSwap(ctx, &pb.CASRequest{
+		Group:   group,
+		Key:     key,
+		Value:   value,
+		Version: version,
+	})
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to compare and swap value in lcache: %v", err)
+	}
+
+	return resp.GetSwapped(), resp.GetVersion(), nil
+}
+
+// DeletePrefix 删除远程节点上所有以 prefix 开头的键
+func (c *Client) DeletePrefix(ctx context.Context, group, prefix string) (int, error) {
+	resp, err := c.grpcCli.Invalidate(ctx, &pb.InvalidateRequest{
+		Group:  group,
+		Prefix: prefix,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete prefix from lcache: %v", err)
+	}
+
+	return int(resp.GetDeleted()), nil
+}
+
+// InvalidateTag 删除远程节点上所有带有 tag 标签的键
+func (c *Client) InvalidateTag(ctx context.Context, group, tag string) (int, error) {
+	resp, err := c.grpcCli.Invalidate(ctx, &pb.InvalidateRequest{
+		Group: group,
+		Tag:   tag,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to invalidate tag from lcache: %v", err)
+	}
+
+	return int(resp.GetDeleted()), nil
+}
+
+// Watch 观察远程节点缓存中键的变化，只包含该节点本地缓存的变化
+// 事件在 Watcher 的缓冲区满时阻塞接收（由 gRPC 流控反压到服务端），服务端缓冲区也满时观察以 ErrSlowWatcher 结束
+func (c *Client) Watch(ctx context.Context, group string, opts ...WatchOption) (*Watcher, error) {
+	var options watchOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	keys := make([]string, 0, len(options.keys))
+	for key := range options.keys {
+		keys = append(keys, key)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := c.grpcCli.Watch(ctx, &pb.WatchRequest{
+		Group:     group,
+		Keys:      keys,
+		Prefix:    options.prefix,
+		WithValue: options.values,
+	})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to watch lcache: %v", err)
+	}
+
+	w := newWatcher(options)
+	w.cancel = cancel
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				w.close(watchStreamError(ctx, err))
+				return
+			}
+
+			ev := WatchEvent{Type: WatchEventType(msg.GetType()), Key: msg.GetKey(), Version: msg.GetVersion()}
+			if options.values && msg.GetType() != pb.WatchEvent_DELETE {
+				ev.Value, err = c.decodeWireValue(group, WireValue{
+					Value:     msg.GetValue(),
+					Version:   msg.GetVersion(),
+					Codec:     msg.GetCodec(),
+					Encrypted: msg.GetEncrypted(),
+				})
+				if err != nil {
+					w.close(err)
+					return
+				}
+			}
+			if !w.deliver(ctx, ev) {
+				return
+			}
+		}
+	}()
+	return w, nil
+}
+
+// watchStreamError 将 Watch 流结束的错误还原为 Watcher.Err 的返回值
+func watchStreamError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	s, ok := status.FromError(err)
+	switch {
+	case err == io.EOF:
+		return nil
+	case ok && s.Code() == codes.Aborted && s.Message() == ErrSlowWatcher.Error():
+		return ErrSlowWatcher
+	case ok && s.Code() == codes.Unavailable && s.Message() == ErrGroupClosed.Error():
+		return ErrGroupClosed
+	default:
+		return fmt.Errorf("failed to watch lcache: %v", err)
+	}
+}
+
 func (c *Client) Close() error {
 	if c.conn != nil {
 		return c.conn.Close()
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"LCache/store"
+	"LCache/store/trace"
+)
+
+// 回放访问日志，比较各淘汰策略的命中率
+// 用法：go run ./cmd/tracebench -trace access.log -max-bytes 67108864 -policies lru,tinylfu,arc,s3fifo
+func main() {
+	tracePath := flag.String("trace", "", "访问日志路径，每行 \"key\" 或 \"key size\"")
+	maxBytes := flag.Int64("max-bytes", 64<<20, "缓存容量（字节）")
+	policies := flag.String("policies", "lru,lru2,tinylfu,arc,s3fifo", "参与比较的缓存类型，逗号分隔")
+	bucketCount := flag.Uint("buckets", 16, "lru2 的桶数量")
+	capPerBucket := flag.Uint("cap-per-bucket", 512, "lru2 每个桶的一级缓存容量")
+	level2Cap := flag.Uint("level2-cap", 256, "lru2 每个桶的二级缓存容量")
+	flag.Parse()
+
+	if *tracePath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*tracePath)
+	if err != nil {
+		log.Fatal("打开访问日志失败:", err)
+	}
+	accesses, err := trace.ReadAccesses(f)
+	f.Close()
+	if err != nil {
+		log.Fatal("解析访问日志失败:", err)
+	}
+
+	opts := store.NewOptions()
+	opts.MaxBytes = *maxBytes
+	opts.BucketCount = uint16(*bucketCount)
+	opts.CapPerBucket = uint16(*capPerBucket)
+	opts.Level2Cap = uint16(*level2Cap)
+
+	var cacheTypes []store.CacheType
+	for _, name := range strings.Split(*policies, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			cacheTypes = append(cacheTypes, store.CacheType(name))
+		}
+	}
+
+	results, err := trace.Compare(cacheTypes, opts, accesses)
+	if err != nil {
+		log.Fatal("回放失败:", err)
+	}
+
+	fmt.Printf("回放 %d 次访问，容量 %d 字节\n\n", len(accesses), *maxBytes)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "策略\t请求数\t命中数\t命中率\t淘汰数\t耗时")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.2f%%\t%d\t%v\n",
+			r.CacheType, r.Requests, r.Hits, r.HitRatio()*100, r.Evictions, r.Duration)
+	}
+	w.Flush()
+}
@@ -0,0 +1,231 @@
+package LCache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrUnknownCodec 压缩算法未注册错误
+var ErrUnknownCodec = errors.New("unknown codec")
+
+// ErrValueTooLarge 解压后的值超过 MaxDecodedValueSize
+var ErrValueTooLarge = errors.New("decoded value is too large")
+
+// defaultCompressThreshold 默认的压缩阈值，小于该长度的值压缩收益很小，直接保存原始字节
+const defaultCompressThreshold = 256
+
+// MaxDecodedValueSize 内置压缩算法解压后的值的最大字节数，压缩的值可能来自其他节点或客户端，
+// 限制解压后的大小以防止解压炸弹；需要在首次解压之前设置（zstd 解码器创建后不再读取）
+var MaxDecodedValueSize int64 = 64 << 20
+
+// Codec 值的压缩算法
+// 值在加载或 Set 时压缩一次，以压缩后的形式保存在缓存中并在节点间传输，读取时才解压
+type Codec interface {
+	// Name 算法名称，随压缩后的值一起保存和传输，必须全局唯一
+	Name() string
+	Encode(src []byte) ([]byte, error)
+	Decode(src []byte) ([]byte, error)
+}
+
+// WireValue 以保存形式在节点间传输的值：可能已压缩或加密，由接收方在读取时解码
+type WireValue struct {
+	Value     []byte
+	Version   uint64
+	Codec     string // 压缩算法名称，为空表示未压缩
+	Encrypted bool   // 是否已加密
+}
+
+// CodecPeer 支持以保存形式传输值的节点，值在节点间保持压缩和加密
+type CodecPeer interface {
+	GetEncoded(ctx context.Context, group string, key string) (WireValue, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = make(map[string]Codec)
+)
+
+// 内置的压缩算法
+var (
+	SnappyCodec Codec = snappyCodec{}
+	ZstdCodec   Codec = &zstdCodec{}
+	GzipCodec   Codec = gzipCodec{}
+)
+
+func init() {
+	RegisterCodec(SnappyCodec)
+	RegisterCodec(ZstdCodec)
+	RegisterCodec(GzipCodec)
+}
+
+// RegisterCodec 注册压缩算法，接收方按名称查找算法解压其他节点发来的值，因此所有节点都要注册相同的算法
+func RegisterCodec(c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[c.Name()] = c
+}
+
+// lookupCodec 按名称查找压缩算法，名称为空表示未压缩，返回 nil
+func lookupCodec(name string) (Codec, error) {
+	if name == "" {
+		return nil, nil
+	}
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCodec, name)
+	}
+	return c, nil
+}
+
+// WithCodec 设置组的压缩算法，长度不小于 threshold 的值会被压缩，threshold 小于等于 0 时使用默认阈值
+func WithCodec(c Codec, threshold int) GroupOption {
+	return func(g *Group) {
+		if threshold <= 0 {
+			threshold = defaultCompressThreshold
+		}
+		g.codec = c
+		g.compressThreshold = threshold
+	}
+}
+
+// newView 创建版本号为 version 的缓存视图，按组的配置压缩并加密 value
+// 压缩失败或压缩后没有变小时保存原始字节；加密失败时返回错误，避免明文进入缓存
+func (g *Group) newView(value []byte, version uint64) (ByteView, error) {
+	view := ByteView{b: cloneBytes(value), version: version}
+	if g.codec != nil && len(value) >= g.compressThreshold {
+		encoded, err := g.codec.Encode(value)
+		if err != nil {
+			logrus.Warnf("[LCache] failed to compress value with %s in group [%s]: %v", g.codec.Name(), g.name, err)
+		} else if len(encoded) < len(value) {
+			view.b, view.codec = encoded, g.codec
+		}
+	}
+
+	if g.encryptor != nil {
+		sealed, err := g.encryptor.seal(view.b)
+		if err != nil {
+			return ByteView{}, fmt.Errorf("failed to encrypt value: %w", err)
+		}
+		view.b, view.enc = sealed, g.encryptor
+	}
+	return view, nil
+}
+
+// receivedView 用其他节点发来的保存形式创建视图；组启用了加密但值未加密时（例如对端还没有配置加密），
+// 解码后按本组的配置重新压缩、加密，缓存中不会保存明文
+func (g *Group) receivedView(value []byte, codecName string, encrypted bool, version uint64) (ByteView, error) {
+	view, err := encodedView(value, codecName, encrypted, g.encryptor, version)
+	if err != nil || g.encryptor == nil || view.enc != nil {
+		return view, err
+	}
+	plain, err := view.decode()
+	if err != nil {
+		return ByteView{}, err
+	}
+	return g.newView(plain, version)
+}
+
+// codecName 返回视图使用的压缩算法名称，未压缩时为空
+func (b ByteView) codecName() string {
+	if b.codec == nil {
+		return ""
+	}
+	return b.codec.Name()
+}
+
+type snappyCodec struct{}
+
+func (snappyCodec) Name() string { return "snappy" }
+
+func (snappyCodec) Encode(src []byte) ([]byte, error) {
+	return snappy.Encode(nil, src), nil
+}
+
+func (snappyCodec) Decode(src []byte) ([]byte, error) {
+	n, err := snappy.DecodedLen(src)
+	if err != nil {
+		return nil, err
+	}
+	if int64(n) > MaxDecodedValueSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrValueTooLarge, n)
+	}
+	return snappy.Decode(nil, src)
+}
+
+// zstdCodec 共享一个编码器和解码器，EncodeAll/DecodeAll 可以并发调用
+type zstdCodec struct {
+	once    sync.Once
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+	err     error
+}
+
+func (c *zstdCodec) init() error {
+	c.once.Do(func() {
+		if c.encoder, c.err = zstd.NewWriter(nil); c.err != nil {
+			return
+		}
+		c.decoder, c.err = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(uint64(MaxDecodedValueSize)))
+	})
+	return c.err
+}
+
+func (c *zstdCodec) Name() string { return "zstd" }
+
+func (c *zstdCodec) Encode(src []byte) ([]byte, error) {
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	return c.encoder.EncodeAll(src, nil), nil
+}
+
+func (c *zstdCodec) Decode(src []byte) ([]byte, error) {
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	return c.decoder.DecodeAll(src, nil)
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string { return "gzip" }
+
+func (gzipCodec) Encode(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCodec) Decode(src []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, MaxDecodedValueSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > MaxDecodedValueSize {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrValueTooLarge, MaxDecodedValueSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,143 @@
+package LCache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WriteConsistency 写入同步到其他节点的方式
+type WriteConsistency int
+
+const (
+	// WriteAsync 异步同步到键的所有者节点，写入立即返回，同步失败只记录日志（默认）
+	WriteAsync WriteConsistency = iota + 1
+	// WriteSyncOwner 等待键的所有者节点确认后返回，所有者节点的错误返回给调用方
+	WriteSyncOwner
+	// WriteSyncAll 等待集群中所有节点确认后返回，任一节点的错误都返回给调用方
+	WriteSyncAll
+)
+
+// asyncSyncTimeout 异步同步单个节点的超时时间
+const asyncSyncTimeout = 3 * time.Second
+
+func (c WriteConsistency) String() string {
+	switch c {
+	case WriteAsync:
+		return "async"
+	case WriteSyncOwner:
+		return "sync-owner"
+	case WriteSyncAll:
+		return "sync-all"
+	default:
+		return fmt.Sprintf("WriteConsistency(%d)", int(c))
+	}
+}
+
+// parseWriteConsistency 解析 String 返回的名称，空字符串表示默认的异步同步
+func parseWriteConsistency(s string) (WriteConsistency, error) {
+	switch s {
+	case "":
+		return 0, nil
+	case "async":
+		return WriteAsync, nil
+	case "sync-owner":
+		return WriteSyncOwner, nil
+	case "sync-all":
+		return WriteSyncAll, nil
+	default:
+		return 0, fmt.Errorf("unknown write consistency %q", s)
+	}
+}
+
+// WithDefaultWriteConsistency 设置组内 Set 和 Delete 默认的同步方式
+func WithDefaultWriteConsistency(c WriteConsistency) GroupOption {
+	return func(g *Group) {
+		g.consistency = int32(c)
+	}
+}
+
+// WithWriteConsistency 设置单次写入的同步方式，覆盖组的默认设置
+func WithWriteConsistency(c WriteConsistency) WriteOption {
+	return func(o *writeOptions) {
+		o.consistency = c
+	}
+}
+
+// replicate 按同步方式把本地已完成的写入同步到其他节点
+// 同步方式下 ctx 的取消和超时会传递给对端请求；异步方式下使用独立的超时，不受调用方 ctx 影响
+func (g *Group) replicate(ctx context.Context, op string, key string, value []byte, consistency WriteConsistency, opts []WriteOption) error {
+	if consistency == 0 {
+		consistency = WriteConsistency(atomic.LoadInt32(&g.consistency))
+	}
+
+	switch consistency {
+	case WriteSyncOwner:
+		return g.syncToPeers(ctx, op, key, value, opts...)
+	case WriteSyncAll:
+		return g.syncToAll(ctx, op, key, value, opts...)
+	default:
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), asyncSyncTimeout)
+			defer cancel()
+			if err := g.syncToPeers(ctx, op, key, value, opts...); err != nil {
+				logrus.Errorf("[LCache] %v", err)
+			}
+		}()
+		return nil
+	}
+}
+
+// syncToAll 并发同步到所有远程节点并等待全部完成，返回所有失败
+func (g *Group) syncToAll(ctx context.Context, op string, key string, value []byte, opts ...WriteOption) error {
+	lister, ok := g.peers.(PeerLister)
+	if !ok {
+		return fmt.Errorf("peer picker %T cannot list peers for %s", g.peers, WriteSyncAll)
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []error
+	)
+	for _, peer := range lister.Peers() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := g.syncToPeer(ctx, peer, op, key, value, opts...); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// syncToPeer 把写入同步到指定节点
+func (g *Group) syncToPeer(ctx context.Context, peer Peer, op string, key string, value []byte, opts ...WriteOption) error {
+	// 对端把同步的写入作为副本处理，不写后端也不再次同步；通过 gRPC 同步时由对端的 Server 根据节点标记判断（见 Server.fromPeer）
+	// 复制是后台流量，按 PriorityBulk 限流，不挤占交互请求的令牌
+	syncCtx := bulkContext(ctx)
+	opts = append([]WriteOption{asReplica()}, opts...)
+
+	var err error
+	switch op {
+	case "set":
+		err = peer.Set(syncCtx, g.name, key, value, opts...)
+	case "delete":
+		_, err = peer.Delete(syncCtx, g.name, key, opts...)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to sync %s of key %s to peer: %w", op, key, err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package LCache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// testCluster 两个互为对端的节点，节点间通过 gRPC 同步
+type testCluster struct {
+	groups [2]*Group
+	peers  [2]*countingPeer // peers[i] 是节点 i 用来同步到另一个节点的客户端
+}
+
+func newTestCluster(t *testing.T, opts ...GroupOption) *testCluster {
+	t.Helper()
+	var c testCluster
+	var pickers [2]*staticPicker
+	var addrs [2]string
+	for i := range c.groups {
+		reg := NewRegistry()
+		pickers[i] = &staticPicker{}
+		c.groups[i] = newTestGroupIn(t, reg, nil, append([]GroupOption{WithPeers(pickers[i])}, opts...)...)
+		addrs[i] = startTestServer(t, reg)
+	}
+	for i := range c.groups {
+		c.peers[i] = &countingPeer{Peer: newTestClient(t, addrs[1-i], asPeer())}
+		pickers[i].set(c.peers[i])
+	}
+	return &c
+}
+
+func TestSyncAllDeleteDoesNotBounce(t *testing.T) {
+	c := newTestCluster(t, WithDefaultWriteConsistency(WriteSyncAll))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.groups[0].Set(ctx, "k", []byte("v")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, ok := cachedValue(t, c.groups[1], "k"); !ok {
+		t.Fatal("set was not replicated")
+	}
+
+	if err := c.groups[0].Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := cachedValue(t, c.groups[1], "k"); ok {
+		t.Fatal("delete was not replicated")
+	}
+	if n := c.peers[0].deletes.Load(); n != 1 {
+		t.Errorf("node 0 sent %d deletes, want 1", n)
+	}
+	if n := c.peers[1].deletes.Load(); n != 0 {
+		t.Errorf("replica sent %d deletes back, want 0", n)
+	}
+}
+
+func TestSyncDeleteHonoursContext(t *testing.T) {
+	c := newTestCluster(t, WithDefaultWriteConsistency(WriteSyncAll))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.groups[0].Delete(ctx, "k"); err == nil {
+		t.Fatal("Delete with a canceled context succeeded")
+	}
+}
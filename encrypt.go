@@ -0,0 +1,214 @@
+package LCache
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrEncryptionNotConfigured 收到加密的值，但组没有配置加密
+var ErrEncryptionNotConfigured = errors.New("value is encrypted but group has no encryption configured")
+
+// ErrUnknownKeyID 密钥 ID 不存在
+var ErrUnknownKeyID = errors.New("unknown encryption key id")
+
+// KeyProvider 提供加密值使用的 AES 密钥（16、24 或 32 字节）
+// 新写入的值使用 CurrentKey 加密，并把密钥 ID 保存在密文中；解密时按 ID 通过 Key 查找，
+// 因此轮换密钥时只需要让新密钥成为当前密钥，并在旧值全部过期前保留旧密钥
+type KeyProvider interface {
+	CurrentKey() (id string, key []byte, err error)
+	Key(id string) ([]byte, error)
+}
+
+// WithEncryption 使用 AES-GCM 加密组内的值：值（压缩后）在写入缓存前加密，在缓存、快照和节点间传输中都保持加密，
+// Get 返回的 ByteView 中是解密后的数据，访问控制由 WithAuth 的 ACL 负责。所有节点需要能从 KeyProvider 取得相同的密钥
+func WithEncryption(keys KeyProvider) GroupOption {
+	return func(g *Group) {
+		g.encryptor = &valueEncryptor{keys: keys}
+	}
+}
+
+// valueEncryptor 加密和解密缓存值，密文格式：len(keyID)(1) | keyID | nonce(12) | 密文和认证标签
+type valueEncryptor struct {
+	keys  KeyProvider
+	aeads sync.Map // keyID -> cipher.AEAD
+}
+
+func (e *valueEncryptor) aead(id string, key []byte) (cipher.AEAD, error) {
+	if a, ok := e.aeads.Load(id); ok {
+		return a.(cipher.AEAD), nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key %s: %v", id, err)
+	}
+	a, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	e.aeads.Store(id, a)
+	return a, nil
+}
+
+// seal 用当前密钥加密 plain
+func (e *valueEncryptor) seal(plain []byte) ([]byte, error) {
+	id, key, err := e.keys.CurrentKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current key: %w", err)
+	}
+	if id == "" || len(id) > 255 {
+		return nil, fmt.Errorf("invalid key id %q", id)
+	}
+	a, err := e.aead(id, key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 1+len(id)+a.NonceSize(), 1+len(id)+a.NonceSize()+len(plain)+a.Overhead())
+	out[0] = byte(len(id))
+	copy(out[1:], id)
+	nonce := out[1+len(id):]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return a.Seal(out, nonce, plain, nil), nil
+}
+
+// open 按密文中的密钥 ID 解密
+func (e *valueEncryptor) open(sealed []byte) ([]byte, error) {
+	if len(sealed) < 1 || len(sealed) < 1+int(sealed[0]) {
+		return nil, errors.New("malformed encrypted value")
+	}
+	id := string(sealed[1 : 1+sealed[0]])
+	key, err := e.keys.Key(id)
+	if err != nil {
+		return nil, err
+	}
+	a, err := e.aead(id, key)
+	if err != nil {
+		return nil, err
+	}
+
+	rest := sealed[1+len(id):]
+	if len(rest) < a.NonceSize() {
+		return nil, errors.New("malformed encrypted value")
+	}
+	return a.Open(nil, rest[:a.NonceSize()], rest[a.NonceSize():], nil)
+}
+
+// keyReloadInterval 遇到未知的密钥 ID 时重新读取密钥文件的最小间隔，避免损坏或伪造的密钥 ID 让每次解密都读取文件
+const keyReloadInterval = time.Second
+
+// FileKeyProvider 从文件读取密钥，每行一个密钥，格式为 "<id> <base64 编码的密钥>"，# 开头的行为注释
+// 最后一行的密钥为当前密钥。轮换时在文件末尾追加新密钥即可，遇到未知的密钥 ID 时会自动重新读取文件（最多每 keyReloadInterval 一次）
+type FileKeyProvider struct {
+	path string
+
+	mu      sync.RWMutex
+	keys    map[string][]byte
+	current string
+
+	reloadMu   sync.Mutex // 串行化遇到未知密钥 ID 时的重新读取
+	lastReload time.Time  // 上一次因未知密钥 ID 重新读取的时间，由 reloadMu 保护
+}
+
+// NewFileKeyProvider 读取密钥文件创建 FileKeyProvider
+func NewFileKeyProvider(path string) (*FileKeyProvider, error) {
+	p := &FileKeyProvider{path: path}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload 重新读取密钥文件
+func (p *FileKeyProvider) Reload() error {
+	f, err := os.Open(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to open key file: %v", err)
+	}
+	defer f.Close()
+
+	keys := make(map[string][]byte)
+	var current string
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		id, encoded, ok := strings.Cut(text, " ")
+		if !ok {
+			return fmt.Errorf("key file %s line %d: expected \"<id> <base64 key>\"", p.path, line)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return fmt.Errorf("key file %s line %d: %v", p.path, line, err)
+		}
+		if n := len(key); n != 16 && n != 24 && n != 32 {
+			return fmt.Errorf("key file %s line %d: key must be 16, 24 or 32 bytes, got %d", p.path, line, n)
+		}
+		keys[id] = key
+		current = id
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read key file: %v", err)
+	}
+	if current == "" {
+		return fmt.Errorf("no keys found in key file %s", p.path)
+	}
+
+	p.mu.Lock()
+	p.keys, p.current = keys, current
+	p.mu.Unlock()
+	return nil
+}
+
+// CurrentKey 实现 KeyProvider 接口
+func (p *FileKeyProvider) CurrentKey() (string, []byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.current, p.keys[p.current], nil
+}
+
+// Key 实现 KeyProvider 接口，其他节点已经轮换到新密钥时，本地按需重新读取文件
+// 距离上一次重新读取不足 keyReloadInterval 时直接返回 ErrUnknownKeyID
+func (p *FileKeyProvider) Key(id string) ([]byte, error) {
+	if key, ok := p.lookup(id); ok {
+		return key, nil
+	}
+
+	p.reloadMu.Lock()
+	defer p.reloadMu.Unlock()
+
+	// 等待期间其他调用可能已经重新读取了文件
+	if key, ok := p.lookup(id); ok {
+		return key, nil
+	}
+	if time.Since(p.lastReload) < keyReloadInterval {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKeyID, id)
+	}
+	p.lastReload = time.Now()
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	if key, ok := p.lookup(id); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownKeyID, id)
+}
+
+func (p *FileKeyProvider) lookup(id string) ([]byte, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	key, ok := p.keys[id]
+	return key, ok
+}
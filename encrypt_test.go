@@ -0,0 +1,115 @@
+package LCache
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeKeyFile 写入密钥文件，每个 ID 使用由 ID 派生的 32 字节密钥
+func writeKeyFile(t *testing.T, path string, ids ...string) {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, id := range ids {
+		key := bytes.Repeat([]byte(id[:1]), 32)
+		buf.WriteString(id + " " + base64.StdEncoding.EncodeToString(key) + "\n")
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("write key file: %v", err)
+	}
+}
+
+func TestFileKeyProviderRateLimitsReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	writeKeyFile(t, path, "a1")
+	p, err := NewFileKeyProvider(path)
+	if err != nil {
+		t.Fatalf("NewFileKeyProvider: %v", err)
+	}
+
+	if _, err := p.Key("b2"); !errors.Is(err, ErrUnknownKeyID) {
+		t.Fatalf("Key of unknown id error = %v, want ErrUnknownKeyID", err)
+	}
+
+	// 刚重新读取过文件，新追加的密钥要等到间隔之后才会读到
+	writeKeyFile(t, path, "a1", "b2")
+	if _, err := p.Key("b2"); !errors.Is(err, ErrUnknownKeyID) {
+		t.Fatalf("Key within reload interval error = %v, want ErrUnknownKeyID", err)
+	}
+
+	p.reloadMu.Lock()
+	p.lastReload = time.Now().Add(-keyReloadInterval)
+	p.reloadMu.Unlock()
+	if _, err := p.Key("b2"); err != nil {
+		t.Fatalf("Key after reload interval: %v", err)
+	}
+	if id, _, _ := p.CurrentKey(); id != "b2" {
+		t.Errorf("current key = %q, want b2", id)
+	}
+}
+
+// encryptedTestGroup 创建启用加密和 snappy 压缩的组，并在 gRPC 服务上提供
+func encryptedTestGroup(t *testing.T) (*Group, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keys")
+	writeKeyFile(t, path, "k1")
+	keys, err := NewFileKeyProvider(path)
+	if err != nil {
+		t.Fatalf("NewFileKeyProvider: %v", err)
+	}
+	reg := NewRegistry()
+	g := newTestGroupIn(t, reg, nil, WithEncryption(keys), WithCodec(SnappyCodec, 0))
+	return g, startTestServer(t, reg)
+}
+
+func TestServerSetIgnoresClientEncoding(t *testing.T) {
+	g, addr := encryptedTestGroup(t)
+	client := newTestClient(t, addr)
+	ctx := context.Background()
+
+	// 客户端声称值已经加密，服务端仍按组的配置加密，读到的是客户端发送的原始字节
+	if err := client.Set(ctx, t.Name(), "k", []byte("not ciphertext"), withEncoding("", true)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	view, ok := cachedValue(t, g, "k")
+	if !ok {
+		t.Fatal("value not cached")
+	}
+	if view.enc == nil {
+		t.Fatal("client value was stored without encryption")
+	}
+	if got, err := view.decode(); err != nil || string(got) != "not ciphertext" {
+		t.Fatalf("decode = %q, %v; want %q", got, err, "not ciphertext")
+	}
+}
+
+func TestServerSetReencryptsPlainPeerValue(t *testing.T) {
+	g, addr := encryptedTestGroup(t)
+	peer := newTestClient(t, addr, asPeer())
+	ctx := context.Background()
+
+	// 对端没有配置加密，发来只压缩未加密的值
+	value := bytes.Repeat([]byte("plain"), 100)
+	compressed, _ := SnappyCodec.Encode(value)
+	if err := peer.Set(ctx, t.Name(), "k", compressed, withEncoding(SnappyCodec.Name(), false)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	view, ok := cachedValue(t, g, "k")
+	if !ok {
+		t.Fatal("value not cached")
+	}
+	if view.enc == nil {
+		t.Fatal("plaintext peer value was stored in an encrypted group")
+	}
+	if bytes.Contains(view.b, []byte("plain")) {
+		t.Fatal("stored bytes contain plaintext")
+	}
+	if got, err := view.decode(); err != nil || !bytes.Equal(got, value) {
+		t.Fatalf("decode = %d bytes, %v; want the original value", len(got), err)
+	}
+}
@@ -0,0 +1,410 @@
+package LCache
+
+import (
+	"runtime/metrics"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultGovernorInterval 内存治理器检查内存用量的默认间隔
+const defaultGovernorInterval = time.Second
+
+// heapObjectsMetric 堆上存活对象（及尚未回收的对象）占用的字节数
+const heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+// utilityDecay 效用的指数加权系数，越大越偏向最近一个周期的命中次数
+const utilityDecay = 0.5
+
+// GovernorOption 定义内存治理器的配置选项
+type GovernorOption func(*Governor)
+
+// WithGovernorRegistry 设置治理的组所在的注册中心，默认 DefaultRegistry
+func WithGovernorRegistry(r *Registry) GovernorOption {
+	return func(gv *Governor) {
+		gv.registry = r
+	}
+}
+
+// WithMemoryLimit 设置所有组合计的缓存内存上限（字节），超出时按预算淘汰
+func WithMemoryLimit(bytes int64) GovernorOption {
+	return func(gv *Governor) {
+		gv.memoryLimit = bytes
+	}
+}
+
+// WithHeapLimit 设置进程堆内存的上限（字节，来自 runtime/metrics），超出时从缓存中淘汰超出的部分
+func WithHeapLimit(bytes int64) GovernorOption {
+	return func(gv *Governor) {
+		gv.heapLimit = bytes
+	}
+}
+
+// WithGovernorInterval 设置检查内存用量的间隔，默认 1 秒
+func WithGovernorInterval(d time.Duration) GovernorOption {
+	return func(gv *Governor) {
+		gv.interval = d
+	}
+}
+
+// WithGovernorTenants 设置租户集合，内存压力下先从超出配额的租户中淘汰
+func WithGovernorTenants(ts *Tenants) GovernorOption {
+	return func(gv *Governor) {
+		gv.tenants = ts
+	}
+}
+
+// groupBudget 组的内存预算及计算效用用到的命中次数
+type groupBudget struct {
+	lastHits int64   // 上个周期结束时的本地命中次数
+	utility  float64 // 每个周期命中次数的指数加权平均
+	budget   int64   // 最近一次分配的预算，0 表示尚未分配
+}
+
+// Governor 进程级的内存治理器
+// 每个组的 MaxBytes 只限制单个组，多个组合计仍可能超出容器的内存；治理器周期性地统计注册中心中所有组的用量，
+// 按各组最近的命中次数把内存上限分配为每个组的预算，并通过 store.Resizable 把存储的容量限制在预算以内，
+// 合计用量或堆内存超出上限时再从超出预算的组中淘汰
+type Governor struct {
+	registry    *Registry
+	tenants     *Tenants
+	memoryLimit int64
+	heapLimit   int64
+	interval    time.Duration
+
+	mu      sync.Mutex
+	budgets map[*Group]*groupBudget
+
+	usedBytes    int64 // 最近一次统计的缓存用量
+	heapBytes    int64 // 最近一次读取的堆内存
+	evictedBytes int64 // 累计淘汰的字节数
+	pressure     int64 // 触发淘汰的次数
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewGovernor 创建内存治理器并开始周期性检查
+func NewGovernor(opts ...GovernorOption) *Governor {
+	gv := &Governor{
+		registry: DefaultRegistry,
+		interval: defaultGovernorInterval,
+		budgets:  make(map[*Group]*groupBudget),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(gv)
+	}
+	if gv.registry == nil {
+		gv.registry = DefaultRegistry
+	}
+	if gv.interval <= 0 {
+		gv.interval = defaultGovernorInterval
+	}
+
+	go gv.run()
+	return gv
+}
+
+// Close 停止内存治理器，各组的容量恢复为自己的 MaxBytes
+func (gv *Governor) Close() {
+	gv.closeOnce.Do(func() {
+		close(gv.stop)
+		<-gv.done
+		gv.restoreBudgets()
+	})
+}
+
+// restoreBudgets 取消已分配的预算，各组的容量恢复为自己的 MaxBytes
+func (gv *Governor) restoreBudgets() {
+	gv.mu.Lock()
+	defer gv.mu.Unlock()
+	for g, b := range gv.budgets {
+		if b.budget > 0 {
+			g.mainCache.applyBudget(0)
+			b.budget = 0
+		}
+	}
+}
+
+// Budget 返回指定组最近一次分配的内存预算，未分配时返回 0
+func (gv *Governor) Budget(name string) int64 {
+	g := gv.registry.Get(name)
+	if g == nil {
+		return 0
+	}
+
+	gv.mu.Lock()
+	defer gv.mu.Unlock()
+	if b, ok := gv.budgets[g]; ok {
+		return b.budget
+	}
+	return 0
+}
+
+// Stats 返回内存治理器的统计信息
+func (gv *Governor) Stats() map[string]interface{} {
+	gv.mu.Lock()
+	budgets := make(map[string]int64, len(gv.budgets))
+	for g, b := range gv.budgets {
+		budgets[g.name] = b.budget
+	}
+	gv.mu.Unlock()
+
+	return map[string]interface{}{
+		"memory_limit":  gv.memoryLimit,
+		"heap_limit":    gv.heapLimit,
+		"used_bytes":    atomic.LoadInt64(&gv.usedBytes),
+		"heap_bytes":    atomic.LoadInt64(&gv.heapBytes),
+		"evicted_bytes": atomic.LoadInt64(&gv.evictedBytes),
+		"pressure":      atomic.LoadInt64(&gv.pressure),
+		"budgets":       budgets,
+	}
+}
+
+func (gv *Governor) run() {
+	defer close(gv.done)
+
+	ticker := time.NewTicker(gv.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-gv.stop:
+			return
+		case <-ticker.C:
+			gv.enforce()
+		}
+	}
+}
+
+// enforce 统计用量、重新分配预算，超出上限时淘汰
+func (gv *Governor) enforce() {
+	groups := gv.groups()
+	usage := make([]int64, len(groups))
+	var total int64
+	for i, g := range groups {
+		usage[i] = g.mainCache.usedBytes()
+		total += usage[i]
+	}
+	atomic.StoreInt64(&gv.usedBytes, total)
+
+	// 需要释放的字节数取缓存用量和堆内存两者超出上限较多的一个，堆内存超出的部分最多释放全部缓存
+	var need int64
+	if gv.memoryLimit > 0 && total > gv.memoryLimit {
+		need = total - gv.memoryLimit
+	}
+	if gv.heapLimit > 0 {
+		heap := readHeapBytes()
+		atomic.StoreInt64(&gv.heapBytes, heap)
+		if heap > gv.heapLimit {
+			need = max(need, min(heap-gv.heapLimit, total))
+		}
+	}
+
+	target := gv.memoryLimit
+	if need > 0 {
+		target = total - need
+	}
+	budgets := gv.rebalance(groups, usage, target)
+	if budgets == nil && need <= 0 {
+		// 只设置了堆内存上限且没有压力：恢复压力期间分配的预算，否则各组的容量会一直停留在压力时的预算
+		gv.restoreBudgets()
+		return
+	}
+	freed := gv.apply(groups, usage, budgets)
+	atomic.AddInt64(&gv.evictedBytes, freed)
+	if need <= 0 {
+		return
+	}
+
+	atomic.AddInt64(&gv.pressure, 1)
+	if freed < need {
+		n := gv.reclaim(groups, usage, budgets, need-freed)
+		atomic.AddInt64(&gv.evictedBytes, n)
+		freed += n
+	}
+	logrus.Warnf("[LCache] memory pressure: cache uses %d bytes, evicted %d of %d bytes", total, freed, need)
+}
+
+// apply 把预算设置为各组存储的容量，超出预算的存储随之淘汰，返回释放的字节数并更新 usage；budgets 为 nil 时不做修改
+func (gv *Governor) apply(groups []*Group, usage, budgets []int64) int64 {
+	if budgets == nil {
+		return 0
+	}
+
+	var freed int64
+	for i, g := range groups {
+		if !g.mainCache.applyBudget(budgets[i]) {
+			continue
+		}
+		used := g.mainCache.usedBytes()
+		if used < usage[i] {
+			freed += usage[i] - used
+		}
+		usage[i] = used
+	}
+	return freed
+}
+
+// groups 返回注册中心中的所有组，并移除已不在注册中心中的组的预算
+func (gv *Governor) groups() []*Group {
+	var groups []*Group
+	for _, name := range gv.registry.List() {
+		if g := gv.registry.Get(name); g != nil {
+			groups = append(groups, g)
+		}
+	}
+
+	gv.mu.Lock()
+	defer gv.mu.Unlock()
+
+	live := make(map[*Group]struct{}, len(groups))
+	for _, g := range groups {
+		live[g] = struct{}{}
+	}
+	for g := range gv.budgets {
+		if _, ok := live[g]; !ok {
+			delete(gv.budgets, g)
+		}
+	}
+	return groups
+}
+
+// rebalance 按各组的效用把 target 分配为预算，target <= 0 时不分配
+// 每个组先得到 target/(2n) 以内的保底预算，剩余部分按效用比例分给用量超出已分配预算的组，
+// 用量低于份额的组只分到其用量，多出的部分继续分给其他组；所有组的用量都满足后，仍然剩余的部分按效用比例作为各组增长的余量。
+// 预算为 0 表示不限制，因此每个组的预算至少为 1 字节，合计最多超出 target n 字节
+func (gv *Governor) rebalance(groups []*Group, usage []int64, target int64) []int64 {
+	gv.mu.Lock()
+	defer gv.mu.Unlock()
+
+	weights := make([]float64, len(groups))
+	for i, g := range groups {
+		b, ok := gv.budgets[g]
+		if !ok {
+			b = &groupBudget{}
+			gv.budgets[g] = b
+		}
+		hits := atomic.LoadInt64(&g.stats.localHits)
+		b.utility = utilityDecay*float64(hits-b.lastHits) + (1-utilityDecay)*b.utility
+		b.lastHits = hits
+		weights[i] = b.utility + 1
+	}
+	if target <= 0 || len(groups) == 0 {
+		return nil
+	}
+
+	budgets := make([]int64, len(groups))
+	floor := target / int64(2*len(groups))
+	remaining := target
+	for i := range groups {
+		budgets[i] = min(usage[i], floor)
+		remaining -= budgets[i]
+	}
+	for remaining > 0 {
+		var weight float64
+		for i := range groups {
+			if budgets[i] < usage[i] {
+				weight += weights[i]
+			}
+		}
+		if weight == 0 {
+			break
+		}
+
+		var granted int64
+		for i := range groups {
+			if budgets[i] >= usage[i] {
+				continue
+			}
+			share := min(int64(float64(remaining)*weights[i]/weight), usage[i]-budgets[i])
+			budgets[i] += share
+			granted += share
+		}
+		if granted == 0 {
+			break
+		}
+		remaining -= granted
+	}
+	if remaining > 0 {
+		var weight float64
+		for i := range groups {
+			weight += weights[i]
+		}
+		for i := range groups {
+			budgets[i] += int64(float64(remaining) * weights[i] / weight)
+		}
+	}
+
+	for i, g := range groups {
+		budgets[i] = max(budgets[i], 1)
+		gv.budgets[g].budget = budgets[i]
+	}
+	return budgets
+}
+
+// reclaim 淘汰至少 need 字节：先从超出配额的租户中淘汰，再从超出预算最多的组开始淘汰其超出的部分，
+// 仍然不足时按用量比例从所有组中淘汰，返回实际释放的字节数
+func (gv *Governor) reclaim(groups []*Group, usage, budgets []int64, need int64) int64 {
+	var freed int64
+	if gv.tenants != nil {
+		freed = gv.tenants.reclaimOverQuota(need)
+		if freed >= need {
+			return freed
+		}
+		// 租户的组已被淘汰，重新统计用量
+		for i, g := range groups {
+			usage[i] = g.mainCache.usedBytes()
+		}
+	}
+
+	if budgets != nil {
+		order := make([]int, len(groups))
+		for i := range order {
+			order[i] = i
+		}
+		over := func(i int) int64 { return usage[i] - budgets[i] }
+		sort.Slice(order, func(a, b int) bool { return over(order[a]) > over(order[b]) })
+		for _, i := range order {
+			if freed >= need || over(i) <= 0 {
+				break
+			}
+			n := groups[i].mainCache.evict(min(over(i), need-freed))
+			usage[i] -= n
+			freed += n
+		}
+	}
+
+	if freed >= need {
+		return freed
+	}
+	var total int64
+	for _, u := range usage {
+		total += u
+	}
+	if total <= 0 {
+		return freed
+	}
+	remaining := need - freed
+	for i, g := range groups {
+		if share := remaining * usage[i] / total; share > 0 {
+			freed += g.mainCache.evict(share)
+		}
+	}
+	return freed
+}
+
+// readHeapBytes 读取堆上对象占用的字节数
+func readHeapBytes() int64 {
+	sample := []metrics.Sample{{Name: heapObjectsMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return int64(sample[0].Value.Uint64())
+}
@@ -0,0 +1,115 @@
+package LCache
+
+import (
+	"LCache/store"
+	"context"
+	"math"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGovernorRebalance(t *testing.T) {
+	tests := []struct {
+		name   string
+		usage  []int64
+		hits   []int64
+		target int64
+		want   []int64
+	}{
+		{
+			name:   "no target",
+			usage:  []int64{100, 100},
+			target: 0,
+			want:   nil,
+		},
+		{
+			name:   "headroom split by weight",
+			usage:  []int64{100, 100},
+			target: 1000,
+			want:   []int64{500, 500},
+		},
+		{
+			name:   "pressure with equal weights",
+			usage:  []int64{600, 600},
+			target: 600,
+			want:   []int64{300, 300},
+		},
+		{
+			name:   "hot group gets the remainder",
+			usage:  []int64{600, 600},
+			hits:   []int64{99, 0},
+			target: 600,
+			want:   []int64{444, 155},
+		},
+		{
+			name:   "small group keeps its usage",
+			usage:  []int64{50, 1000},
+			target: 600,
+			want:   []int64{50, 550},
+		},
+		{
+			name:   "idle group gets at least one byte",
+			usage:  []int64{0, 1000},
+			target: 500,
+			want:   []int64{1, 500},
+		},
+		{
+			name:   "shares rounding to zero",
+			usage:  []int64{0, 0, 0},
+			target: 2,
+			want:   []int64{1, 1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gv := &Governor{budgets: make(map[*Group]*groupBudget)}
+			groups := make([]*Group, len(tt.usage))
+			for i := range groups {
+				groups[i] = newTestGroupIn(t, NewRegistry(), nil)
+				if tt.hits != nil {
+					atomic.StoreInt64(&groups[i].stats.localHits, tt.hits[i])
+				}
+			}
+
+			got := gv.rebalance(groups, tt.usage, tt.target)
+			if len(got) != len(tt.want) {
+				t.Fatalf("rebalance = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("rebalance = %v, want %v", got, tt.want)
+				}
+				if b := gv.budgets[groups[i]].budget; b != got[i] {
+					t.Errorf("recorded budget of group %d = %d, want %d", i, b, got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGovernorRestoresBudgetsWithoutPressure(t *testing.T) {
+	reg := NewRegistry()
+	g := newTestGroupIn(t, reg, nil, WithCacheOptions(CacheOptions{CacheType: store.LRU, MaxBytes: 1 << 20}))
+	if err := g.Set(context.Background(), "k", []byte("v")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	maxBytes := func() int64 {
+		return g.mainCache.store.(interface{ MaxBytes() int64 }).MaxBytes()
+	}
+
+	// 模拟一次堆内存压力留下的预算
+	gv := &Governor{registry: reg, heapLimit: math.MaxInt64, budgets: make(map[*Group]*groupBudget)}
+	gv.budgets[g] = &groupBudget{budget: 100}
+	g.mainCache.applyBudget(100)
+	if got := maxBytes(); got != 100 {
+		t.Fatalf("max bytes under budget = %d, want 100", got)
+	}
+
+	gv.enforce()
+	if got := gv.Budget(g.name); got != 0 {
+		t.Errorf("budget after pressure = %d, want 0", got)
+	}
+	if got := maxBytes(); got != 1<<20 {
+		t.Errorf("max bytes after pressure = %d, want %d", got, 1<<20)
+	}
+}
@@ -11,12 +11,6 @@ import (
 	"time"
 )
 
-// 整个 LCache 系统中用于 注册、获取多个缓存组（Group） 的全局注册中心
-var (
-	groupsMu sync.RWMutex
-	groups   = make(map[string]*Group)
-)
-
 // ErrKeyRequired 键不能为空错误
 var ErrKeyRequired = errors.New("key is required")
 
@@ -41,26 +35,44 @@ func (f GetterFunc) Get(ctx context.Context, key string) ([]byte, error) {
 
 // Group 是一个缓存命名空间
 type Group struct {
-	name       string              // 缓存组名称（唯一标识）
-	getter     Getter              // 缓存未命中时的回调加载器
-	mainCache  *Cache              // 本地缓存存储结构（支持 LRU/LRU2）
-	peers      PeerPicker          // 对等节点选择器（支持分布式获取）
-	loader     *singleflight.Group // 单飞机制，防止并发重复加载
-	expiration time.Duration       // 每个 key 的统一过期时间
-	closed     int32               // 是否已关闭（原子标记）
-	stats      groupStats          // 命中/加载统计
+	clock             hybridClock         // 条目版本号时钟（原子访问，放在首位保证 64 位对齐）
+	name              string              // 缓存组名称（唯一标识）
+	getter            Getter              // 缓存未命中时的回调加载器
+	mainCache         *Cache              // 本地缓存存储结构（支持 LRU/LRU2）
+	peers             PeerPicker          // 对等节点选择器（支持分布式获取）
+	loader            *singleflight.Group // 单飞机制，防止并发重复加载
+	expiration        int64               // 每个 key 的统一过期时间（原子访问的 time.Duration，可由 UpdateOptions 修改）
+	closed            int32               // 是否已关闭（原子标记）
+	stats             groupStats          // 命中/加载统计
+	keyLocks          keyLocks            // 按键分段的写入锁，保证原子操作的读-改-写不被打断
+	tombstones        tombstones          // 最近删除的键及其版本号，用于拒绝晚到的旧写入
+	consistency       int32               // Set/Delete 默认的节点同步方式（原子访问的 WriteConsistency）
+	setter            Setter              // 写入后端数据源的接口（可选）
+	deleter           Deleter             // 删除后端数据的接口（可选）
+	writeBehindOpts   *WriteBehindOptions // 异步回写配置，为 nil 时同步写穿
+	writeBehind       *writeBehind        // 异步回写队列
+	codec             Codec               // 值的压缩算法，为 nil 时不压缩
+	compressThreshold int                 // 压缩阈值，小于该长度的值不压缩
+	encryptor         *valueEncryptor     // 值加密器，为 nil 时不加密
+	admission         *loadLimiter        // Getter 调用的准入控制，为 nil 时不限制
+	watchers          watchHub            // 键变化的观察者
+	hooks             *hookRunner         // 生命周期回调，为 nil 时不调用
+	updateMu          sync.Mutex          // 串行执行 UpdateOptions
+	registry          *Registry           // 组所属的注册中心
 }
 
 // groupStats 保存组的统计信息
 type groupStats struct {
-	loads        int64 // 加载总次数（从本地或远程）
-	localHits    int64 // 本地命中次数
-	localMisses  int64 // 本地未命中次数
-	peerHits     int64 // 从 Peer 节点成功获取的次数
-	peerMisses   int64 // Peer 获取失败的次数
-	loaderHits   int64 // 成功通过 Getter 加载的次数
-	loaderErrors int64 // Getter 加载失败次数
-	loadDuration int64 // 总加载耗时（纳秒）
+	loads              int64 // 加载总次数（从本地或远程）
+	localHits          int64 // 本地命中次数
+	localMisses        int64 // 本地未命中次数
+	peerHits           int64 // 从 Peer 节点成功获取的次数
+	peerMisses         int64 // Peer 获取失败的次数
+	loaderHits         int64 // 成功通过 Getter 加载的次数
+	loaderErrors       int64 // Getter 加载失败次数
+	loadDuration       int64 // 总加载耗时（纳秒）
+	staleWrites        int64 // 因版本号过旧被拒绝的写入和删除次数
+	writeThroughErrors int64 // 同步写穿后端失败的次数
 }
 
 // GroupOption 定义Group的配置选项
@@ -69,7 +81,7 @@ type GroupOption func(*Group)
 // WithExpiration 配置缓存项的统一过期时间
 func WithExpiration(d time.Duration) GroupOption {
 	return func(g *Group) {
-		g.expiration = d
+		g.expiration = int64(d)
 	}
 }
 
@@ -87,8 +99,64 @@ func WithCacheOptions(opts CacheOptions) GroupOption {
 	}
 }
 
-// NewGroup 创建一个新的 Group 实例
+// WriteOption 定义单次写入的选项
+type WriteOption func(*writeOptions)
+
+// writeOptions 单次写入的配置
+type writeOptions struct {
+	tags        []string         // 写入时附加的标签
+	version     uint64           // 节点间同步时携带的版本号，0 表示由本节点的时钟生成
+	consistency WriteConsistency // 同步到其他节点的方式，0 表示使用组的默认设置
+	codec       string           // 节点间同步时 value 的压缩算法，为空表示未压缩
+	encrypted   bool             // 节点间同步时 value 是否已加密
+	replica     bool             // 写入是其他节点同步过来的副本
+}
+
+// WithTags 为写入的键附加标签，之后可以通过 InvalidateTag 按标签批量失效
+// 每次写入都会替换键原有的标签，不带 WithTags 的写入会清除键的标签
+func WithTags(tags ...string) WriteOption {
+	return func(o *writeOptions) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// withVersion 使用指定的版本号写入，用于节点间同步，使副本与源节点的版本号一致
+func withVersion(version uint64) WriteOption {
+	return func(o *writeOptions) {
+		o.version = version
+	}
+}
+
+// withEncoding 表示 value 已经是压缩、加密后的保存形式，用于节点间同步，接收方直接保存（组启用了加密而值未加密时重新加密）
+func withEncoding(codec string, encrypted bool) WriteOption {
+	return func(o *writeOptions) {
+		o.codec = codec
+		o.encrypted = encrypted
+	}
+}
+
+// asReplica 表示写入是其他节点同步过来的副本，只更新本地缓存：后端数据源已由最先处理写入的节点更新，也不再同步到其他节点
+func asReplica() WriteOption {
+	return func(o *writeOptions) {
+		o.replica = true
+	}
+}
+
+func applyWriteOptions(opts []WriteOption) writeOptions {
+	var o writeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// NewGroup 在默认注册中心 DefaultRegistry 中创建一个新的 Group 实例
 func NewGroup(name string, cacheBytes int64, getter Getter, opts ...GroupOption) *Group {
+	return DefaultRegistry.NewGroup(name, cacheBytes, getter, opts...)
+}
+
+// NewGroup 在注册中心 r 中创建一个新的 Group 实例，同名的组会被替换
+func (r *Registry) NewGroup(name string, cacheBytes int64, getter Getter, opts ...GroupOption) *Group {
 	if getter == nil {
 		panic("nil Getter")
 	}
@@ -102,32 +170,38 @@ func NewGroup(name string, cacheBytes int64, getter Getter, opts ...GroupOption)
 		getter:    getter,
 		mainCache: NewCache(cacheOpts),
 		loader:    &singleflight.Group{},
+		registry:  r,
 	}
 
 	// 应用选项
 	for _, opt := range opts {
 		opt(g)
 	}
+	// 存储在首次写入时才创建，缓存类型错误要在创建组时暴露，否则组永远不缓存、每次 Get 都调用 Getter
+	if err := g.mainCache.opts.validate(); err != nil {
+		panic(fmt.Sprintf("invalid cache options for group %s: %v", name, err))
+	}
 
-	// 注册到全局组映射
-	groupsMu.Lock()
-	defer groupsMu.Unlock()
+	g.mainCache.encryptor = g.encryptor
+	g.mainCache.onRemoved = g.notifyRemoved
+	if g.hooks != nil {
+		g.hooks.start()
+	}
 
-	if _, exists := groups[name]; exists {
-		logrus.Warnf("Group with name %s already exists, will be replaced", name)
+	if g.writeBehindOpts != nil && (g.setter != nil || g.deleter != nil) {
+		g.writeBehind = newWriteBehind(name, g.setter, g.deleter, *g.writeBehindOpts)
 	}
 
-	groups[name] = g
-	logrus.Infof("Created cache group [%s] with cacheBytes=%d, expiration=%v", name, cacheBytes, g.expiration)
+	// 注册到所属的注册中心
+	r.add(g)
+	logrus.Infof("Created cache group [%s] with cacheBytes=%d, expiration=%v", name, cacheBytes, g.ttl())
 
 	return g
 }
 
-// GetGroup 获取指定名称的组
+// GetGroup 从默认注册中心获取指定名称的组
 func GetGroup(name string) *Group {
-	groupsMu.RLock()
-	defer groupsMu.RUnlock()
-	return groups[name]
+	return DefaultRegistry.Get(name)
 }
 
 // Get 从缓存获取数据
@@ -144,18 +218,45 @@ func (g *Group) Get(ctx context.Context, key string) (ByteView, error) {
 	// 从本地缓存获取
 	view, ok := g.mainCache.Get(ctx, key)
 	if ok {
-		atomic.AddInt64(&g.stats.localHits, 1)
-		return view, nil
+		decoded, err := decodeView(view)
+		if err == nil {
+			atomic.AddInt64(&g.stats.localHits, 1)
+			g.hooks.hit(key)
+			return decoded, nil
+		}
+		// 无法解码的条目（例如加密它的密钥已经下线）按未命中处理，移除后重新加载
+		logrus.Warnf("[LCache] dropping undecodable value of key %s in group [%s]: %v", key, g.name, err)
+		g.dropUndecodable(key, view.version)
 	}
 
 	atomic.AddInt64(&g.stats.localMisses, 1)
+	g.hooks.miss(key)
 
 	// 尝试从其他节点获取或加载
-	return g.load(ctx, key)
+	view, err := g.load(ctx, key)
+	if err != nil {
+		return ByteView{}, err
+	}
+	decoded, err := decodeView(view)
+	if err != nil {
+		return ByteView{}, fmt.Errorf("failed to decode value of key %s: %w", key, err)
+	}
+	return decoded, nil
+}
+
+// dropUndecodable 移除无法解码的条目，条目已被更新时保留新值
+func (g *Group) dropUndecodable(key string, version uint64) {
+	unlock := g.keyLocks.lock(key)
+	defer unlock()
+
+	if view, ok := g.mainCache.peek(key); ok && view.version == version {
+		g.mainCache.Delete(key)
+	}
 }
 
 // Set 设置缓存值
-func (g *Group) Set(ctx context.Context, key string, value []byte) error {
+// 同步方式由 WithWriteConsistency 或 WithDefaultWriteConsistency 决定，同步方式下返回对端节点的错误
+func (g *Group) Set(ctx context.Context, key string, value []byte, opts ...WriteOption) error {
 	// 检查组是否已关闭
 	if atomic.LoadInt32(&g.closed) == 1 {
 		return ErrGroupClosed
@@ -168,29 +269,55 @@ func (g *Group) Set(ctx context.Context, key string, value []byte) error {
 		return ErrValueRequired
 	}
 
-	// 检查是否是从其他节点同步过来的请求
-	isPeerRequest := ctx.Value("from_peer") != nil
-
-	// 创建缓存视图
-	view := ByteView{b: cloneBytes(value)}
-
-	// 设置到本地缓存
-	if g.expiration > 0 {
-		g.mainCache.AddWithExpiration(key, view, time.Now().Add(g.expiration))
+	// 创建缓存视图，同步过来的写入沿用源节点的版本号
+	wo := applyWriteOptions(opts)
+	if wo.version == 0 {
+		wo.version = g.clock.now()
+	} else if !g.clock.observe(wo.version) {
+		return fmt.Errorf("%w: set of key %s with version %d", ErrVersionTooNew, key, wo.version)
+	}
+	var (
+		view ByteView
+		err  error
+	)
+	if wo.codec != "" || wo.encrypted {
+		view, err = g.receivedView(value, wo.codec, wo.encrypted, wo.version)
 	} else {
-		g.mainCache.Add(key, view)
+		view, err = g.newView(value, wo.version)
 	}
+	if err != nil {
+		return err
+	}
+
+	// 持有键锁写后端和缓存，并发写入到达后端和缓存的顺序一致；异步同步可能乱序到达，版本号比本地旧的写入直接丢弃，也不写后端
+	unlock := g.keyLocks.lock(key)
+	if g.isStale(key, wo.version, "write") {
+		unlock()
+		return nil
+	}
+	// 只有最先处理写入的节点同步到后端数据源，写穿失败或回写队列已满时不更新缓存
+	if !wo.replica {
+		if err := g.writeBack(ctx, key, value, false); err != nil {
+			unlock()
+			return err
+		}
+	}
+	g.addIfNewerLocked(key, view, true, wo.tags...)
+	unlock()
 
-	// 如果不是从其他节点同步过来的请求，且启用了分布式模式，同步到其他节点
-	if !isPeerRequest && g.peers != nil {
-		go g.syncToPeers(ctx, "set", key, value)
+	// 如果不是从其他节点同步过来的副本，且启用了分布式模式，同步到其他节点
+	// 同步方式下对端的错误会返回给调用方，此时本地已经写入
+	if !wo.replica && g.peers != nil {
+		// 压缩、加密后的字节直接发给其他节点，不需要重新处理
+		return g.replicate(ctx, "set", key, view.b, wo.consistency,
+			append(opts, withVersion(wo.version), withEncoding(view.codecName(), view.enc != nil)))
 	}
 
 	return nil
 }
 
-// Delete 删除缓存值
-func (g *Group) Delete(ctx context.Context, key string) error {
+// Delete 删除缓存值，并留下带版本号的删除墓碑，使晚到的旧写入不会让已删除的值复活
+func (g *Group) Delete(ctx context.Context, key string, opts ...WriteOption) error {
 	// 检查组是否已关闭
 	if atomic.LoadInt32(&g.closed) == 1 {
 		return ErrGroupClosed
@@ -200,47 +327,59 @@ func (g *Group) Delete(ctx context.Context, key string) error {
 		return ErrKeyRequired
 	}
 
-	// 从本地缓存删除
-	g.mainCache.Delete(key)
+	wo := applyWriteOptions(opts)
+	if wo.version == 0 {
+		wo.version = g.clock.now()
+	} else if !g.clock.observe(wo.version) {
+		return fmt.Errorf("%w: delete of key %s with version %d", ErrVersionTooNew, key, wo.version)
+	}
 
-	// 检查是否是从其他节点同步过来的请求
-	isPeerRequest := ctx.Value("from_peer") != nil
+	// 与 Set 相同，持有键锁删除后端和缓存，版本号比本地旧的删除直接丢弃
+	unlock := g.keyLocks.lock(key)
+	if g.isStale(key, wo.version, "delete") {
+		unlock()
+		return nil
+	}
+	// 只有最先处理删除的节点同步到后端数据源，失败时不删除缓存
+	if !wo.replica {
+		if err := g.writeBack(ctx, key, nil, true); err != nil {
+			unlock()
+			return err
+		}
+	}
+	g.deleteIfNewerLocked(key, wo.version)
+	unlock()
 
-	// 如果不是从其他节点同步过来的请求，且启用了分布式模式，同步到其他节点
-	if !isPeerRequest && g.peers != nil {
-		go g.syncToPeers(ctx, "delete", key, nil)
+	// 如果不是从其他节点同步过来的副本，且启用了分布式模式，同步到其他节点
+	if !wo.replica && g.peers != nil {
+		return g.replicate(ctx, "delete", key, nil, wo.consistency, []WriteOption{withVersion(wo.version)})
 	}
 
 	return nil
 }
 
-// syncToPeers 同步操作到其他节点
-func (g *Group) syncToPeers(ctx context.Context, op string, key string, value []byte) {
+// syncToPeers 同步操作到键的所有者节点，本节点就是所有者时无需同步
+func (g *Group) syncToPeers(ctx context.Context, op string, key string, value []byte, opts ...WriteOption) error {
 	if g.peers == nil {
-		return
+		return nil
 	}
 
 	// 选择对等节点
 	peer, ok, isSelf := g.peers.PickPeer(key)
 	if !ok || isSelf {
-		return
+		return nil
 	}
 
-	// 创建同步请求上下文
-	// 这样可以在对方 Group.Set/Delete 方法里识别 isPeerRequest == true，从而避免二次同步
-	syncCtx := context.WithValue(context.Background(), "from_peer", true)
-
-	var err error
-	switch op {
-	case "set":
-		err = peer.Set(syncCtx, g.name, key, value)
-	case "delete":
-		_, err = peer.Delete(g.name, key)
-	}
+	return g.syncToPeer(ctx, peer, op, key, value, opts...)
+}
 
-	if err != nil {
-		logrus.Errorf("[LCache] failed to sync %s to peer: %v", op, err)
+// Scan 分页列出本节点缓存中匹配 glob 模式 match 的键，只包含本地缓存，不访问其他节点
+// cursor 为空表示从头开始，返回的 next 为空表示遍历结束，否则作为下一次调用的 cursor
+func (g *Group) Scan(cursor, match string, count int) ([]string, string, error) {
+	if atomic.LoadInt32(&g.closed) == 1 {
+		return nil, "", ErrGroupClosed
 	}
+	return g.mainCache.Scan(cursor, match, count)
 }
 
 // Clear 清空当前缓存组内的所有缓存项
@@ -254,25 +393,34 @@ func (g *Group) Clear() {
 	logrus.Infof("[LCache] cleared cache for group [%s]", g.name)
 }
 
-// Close 关闭组并释放资源
+// Close 关闭组并释放资源，启用异步回写时会先提交队列中剩余的写入，并返回最终未能写入后端的错误
 func (g *Group) Close() error {
 	// 如果已经关闭，直接返回
 	if !atomic.CompareAndSwapInt32(&g.closed, 0, 1) {
 		return nil
 	}
 
+	// 提交异步回写队列中剩余的写入
+	var err error
+	if g.writeBehind != nil {
+		err = g.writeBehind.close()
+	}
+
+	g.watchers.closeAll(ErrGroupClosed)
+	if g.hooks != nil {
+		g.hooks.close()
+	}
+
 	// 关闭本地缓存
 	if g.mainCache != nil {
 		g.mainCache.Close()
 	}
 
-	// 从全局组映射中移除
-	groupsMu.Lock()
-	delete(groups, g.name)
-	groupsMu.Unlock()
+	// 从所属的注册中心移除
+	g.registry.remove(g)
 
 	logrus.Infof("[LCache] closed cache group [%s]", g.name)
-	return nil
+	return err
 }
 
 // load 加载数据
@@ -281,7 +429,16 @@ func (g *Group) load(ctx context.Context, key string) (value ByteView, err error
 	startTime := time.Now()
 	// g.loader.Do() 会确保 同一个 key 同一时刻只触发一次真正的加载，其他并发请求会等待结果
 	viewi, err := g.loader.Do(key, func() (interface{}, error) {
-		return g.loadData(ctx, key) // 内部真正从 Getter 或 Peer 获取数据的函数
+		// 版本号取加载开始的时刻，加载期间发生的写入和删除更新，加载结果不会覆盖它们
+		version := g.clock.now()
+		g.hooks.loadStart(key)
+		start := time.Now()
+		view, err := g.loadData(ctx, key) // 内部真正从 Getter 或 Peer 获取数据的函数
+		g.hooks.loadDone(key, time.Since(start), err)
+		if err == nil && view.version == 0 {
+			view.version = version
+		}
+		return view, err
 	})
 
 	// 记录加载时间
@@ -296,12 +453,8 @@ func (g *Group) load(ctx context.Context, key string) (value ByteView, err error
 
 	view := viewi.(ByteView)
 
-	// 设置到本地缓存
-	if g.expiration > 0 {
-		g.mainCache.AddWithExpiration(key, view, time.Now().Add(g.expiration))
-	} else {
-		g.mainCache.Add(key, view)
-	}
+	// 设置到本地缓存，加载不改变键的值，不通知观察者
+	g.addIfNewer(key, view, false)
 
 	return view, nil
 }
@@ -313,35 +466,74 @@ func (g *Group) loadData(ctx context.Context, key string) (value ByteView, err e
 		peer, ok, isSelf := g.peers.PickPeer(key) // 使用一致性哈希等机制选择一个对等节点
 		if ok && !isSelf {
 			// 如果选择到其他节点（不是本机），调用 getFromPeer() 获取
+			start := time.Now()
 			value, err := g.getFromPeer(ctx, peer, key)
+			g.hooks.peerFetch(key, time.Since(start), err)
 			if err == nil {
 				atomic.AddInt64(&g.stats.peerHits, 1)
 				return value, nil
 			}
 
 			atomic.AddInt64(&g.stats.peerMisses, 1)
+			// 所有者节点过载时直接返回，不在本地重复它刚拒绝的加载
+			if err := fromStatusError(err); errors.Is(err, ErrOverloaded) {
+				return ByteView{}, err
+			}
 			logrus.Warnf("[LCache] failed to get from peer: %v", err)
 		}
 	}
 
-	// 若 Peer 失败，从本地数据源加载
+	// 若 Peer 失败，从本地数据源加载，配置了准入控制时先获得执行机会
+	var release func(error)
+	if g.admission != nil {
+		if release, err = g.admission.acquire(ctx); err != nil {
+			return ByteView{}, err
+		}
+	}
 	bytes, err := g.getter.Get(ctx, key)
+	if release != nil {
+		release(err)
+	}
 	if err != nil {
 		return ByteView{}, fmt.Errorf("failed to get data: %w", err)
 	}
 
-	// 加载成功：复制为只读视图
+	// 加载成功：复制为只读视图，并按组的配置压缩、加密
 	atomic.AddInt64(&g.stats.loaderHits, 1)
-	return ByteView{b: cloneBytes(bytes)}, nil
+	return g.newView(bytes, 0)
 }
 
 // getFromPeer 从其他节点获取数据
 func (g *Group) getFromPeer(ctx context.Context, peer Peer, key string) (ByteView, error) {
+	// 支持保存形式传输的节点直接返回压缩、加密后的字节，本地以同样的形式保存
+	if cp, ok := peer.(CodecPeer); ok {
+		wv, err := cp.GetEncoded(ctx, g.name, key)
+		if err != nil {
+			return ByteView{}, fmt.Errorf("failed to get from peer: %w", err)
+		}
+		if !g.clock.observe(wv.Version) {
+			return ByteView{}, fmt.Errorf("failed to get from peer: %w: %d", ErrVersionTooNew, wv.Version)
+		}
+		return g.receivedView(wv.Value, wv.Codec, wv.Encrypted, wv.Version)
+	}
+
+	// 支持版本号的节点一并返回条目的版本号，使本地副本与所有者节点一致
+	if vp, ok := peer.(VersionedPeer); ok {
+		bytes, version, err := vp.GetVersioned(ctx, g.name, key)
+		if err != nil {
+			return ByteView{}, fmt.Errorf("failed to get from peer: %w", err)
+		}
+		if !g.clock.observe(version) {
+			return ByteView{}, fmt.Errorf("failed to get from peer: %w: %d", ErrVersionTooNew, version)
+		}
+		return g.newView(bytes, version)
+	}
+
 	bytes, err := peer.Get(g.name, key)
 	if err != nil {
 		return ByteView{}, fmt.Errorf("failed to get from peer: %w", err)
 	}
-	return ByteView{b: bytes}, nil
+	return g.newView(bytes, 0)
 }
 
 // RegisterPeers 注册PeerPicker
@@ -359,7 +551,7 @@ func (g *Group) Stats() map[string]interface{} {
 	stats := map[string]interface{}{
 		"name":          g.name,
 		"closed":        atomic.LoadInt32(&g.closed) == 1,
-		"expiration":    g.expiration,
+		"expiration":    g.ttl(),
 		"loads":         atomic.LoadInt64(&g.stats.loads),
 		"local_hits":    atomic.LoadInt64(&g.stats.localHits),
 		"local_misses":  atomic.LoadInt64(&g.stats.localMisses),
@@ -367,6 +559,31 @@ func (g *Group) Stats() map[string]interface{} {
 		"peer_misses":   atomic.LoadInt64(&g.stats.peerMisses),
 		"loader_hits":   atomic.LoadInt64(&g.stats.loaderHits),
 		"loader_errors": atomic.LoadInt64(&g.stats.loaderErrors),
+		"stale_writes":  atomic.LoadInt64(&g.stats.staleWrites),
+		"tombstones":    g.tombstones.len(),
+	}
+
+	if g.codec != nil {
+		stats["codec"] = g.codec.Name()
+	}
+	stats["encrypted"] = g.encryptor != nil
+	if g.setter != nil || g.deleter != nil {
+		stats["write_through_errors"] = atomic.LoadInt64(&g.stats.writeThroughErrors)
+	}
+	if g.admission != nil {
+		for k, v := range g.admission.stats() {
+			stats["load_"+k] = v
+		}
+	}
+	if g.hooks != nil {
+		for k, v := range g.hooks.stats() {
+			stats["hooks_"+k] = v
+		}
+	}
+	if g.writeBehind != nil {
+		for k, v := range g.writeBehind.stats() {
+			stats["write_behind_"+k] = v
+		}
 	}
 
 	// 计算各种命中率
@@ -391,44 +608,19 @@ func (g *Group) Stats() map[string]interface{} {
 	return stats
 }
 
-// 以下是缓存分组 Group 的全局生命周期管理 提供了三个非常有用的函数，主要用于测试、热更新或资源清理场景。
+// 以下是默认注册中心的全局生命周期管理 提供了三个非常有用的函数，主要用于测试、热更新或资源清理场景。
 
-// ListGroups 返回所有缓存组的名称
+// ListGroups 返回默认注册中心中所有缓存组的名称
 func ListGroups() []string {
-	groupsMu.RLock()
-	defer groupsMu.RUnlock()
-
-	names := make([]string, 0, len(groups))
-	for name := range groups {
-		names = append(names, name)
-	}
-
-	return names
+	return DefaultRegistry.List()
 }
 
-// DestroyGroup 销毁指定名称的缓存组
+// DestroyGroup 销毁默认注册中心中指定名称的缓存组
 func DestroyGroup(name string) bool {
-	groupsMu.Lock()
-	defer groupsMu.Unlock()
-
-	if g, exists := groups[name]; exists {
-		g.Close()
-		delete(groups, name)
-		logrus.Infof("[LCache] destroyed cache group [%s]", name)
-		return true
-	}
-
-	return false
+	return DefaultRegistry.Destroy(name)
 }
 
-// DestroyAllGroups 销毁所有缓存组
+// DestroyAllGroups 销毁默认注册中心中的所有缓存组
 func DestroyAllGroups() {
-	groupsMu.Lock()
-	defer groupsMu.Unlock()
-
-	for name, g := range groups {
-		g.Close()
-		delete(groups, name)
-		logrus.Infof("[LCache] destroyed cache group [%s]", name)
-	}
+	DefaultRegistry.DestroyAll()
 }
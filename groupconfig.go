@@ -0,0 +1,428 @@
+package LCache
+
+import (
+	"LCache/registry"
+	"LCache/store"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// ErrInvalidGroupConfig 集中配置中的组定义不合法
+var ErrInvalidGroupConfig = errors.New("invalid group config")
+
+// configResyncDelay 监听中断后重新全量同步前的等待时间
+const configResyncDelay = time.Second
+
+// Duration 在配置中以 "30s"、"1h30m" 形式序列化的时长
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\": %v", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// GroupConfig 集中保存在 etcd 中的组定义，以 JSON 格式保存在 GroupConfigKey 返回的键下
+type GroupConfig struct {
+	Name            string          `json:"name"`
+	MaxBytes        int64           `json:"max_bytes"`                  // 最大内存使用量；lru2 按条目数限制容量（见 CapPerBucket），运行中修改 lru2 组的 max_bytes 不生效
+	TTL             Duration        `json:"ttl,omitempty"`              // 统一过期时间，为 0 时永不过期
+	CacheType       store.CacheType `json:"cache_type,omitempty"`       // 缓存类型（淘汰策略），默认 lru2
+	CleanupInterval Duration        `json:"cleanup_interval,omitempty"` // 清理过期条目的间隔，默认 1 分钟
+	Replication     string          `json:"replication,omitempty"`      // Set/Delete 默认的节点同步方式：async、sync-owner 或 sync-all，默认 async
+	CapPerBucket    uint16          `json:"cap_per_bucket,omitempty"`   // lru2 每个桶一级缓存的条目数，默认 512
+	Level2Cap       uint16          `json:"level2_cap,omitempty"`       // lru2 每个桶二级缓存的条目数，默认 256
+}
+
+// validate 检查组定义是否合法
+func (c GroupConfig) validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidGroupConfig)
+	}
+	if c.MaxBytes <= 0 {
+		return fmt.Errorf("%w: group %s: max_bytes must be positive", ErrInvalidGroupConfig, c.Name)
+	}
+	if c.TTL < 0 || c.CleanupInterval < 0 {
+		return fmt.Errorf("%w: group %s: durations must not be negative", ErrInvalidGroupConfig, c.Name)
+	}
+	if c.CacheType != "" && !slices.Contains(store.CacheTypes(), c.CacheType) {
+		return fmt.Errorf("%w: group %s: %w: %q", ErrInvalidGroupConfig, c.Name, store.ErrUnknownCacheType, c.CacheType)
+	}
+	if _, err := parseWriteConsistency(c.Replication); err != nil {
+		return fmt.Errorf("%w: group %s: %v", ErrInvalidGroupConfig, c.Name, err)
+	}
+	return nil
+}
+
+// cacheOptions 返回组定义对应的缓存配置，未设置的项使用默认值
+func (c GroupConfig) cacheOptions() CacheOptions {
+	opts := DefaultCacheOptions()
+	opts.MaxBytes = c.MaxBytes
+	if c.CacheType != "" {
+		opts.CacheType = c.CacheType
+	}
+	if c.CleanupInterval > 0 {
+		opts.CleanupTime = time.Duration(c.CleanupInterval)
+	}
+	if c.CapPerBucket > 0 {
+		opts.CapPerBucket = c.CapPerBucket
+	}
+	if c.Level2Cap > 0 {
+		opts.Level2Cap = c.Level2Cap
+	}
+	return opts
+}
+
+// GroupConfigKey 返回服务 svcName 下组 name 的定义在 etcd 中的键
+func GroupConfigKey(svcName, name string) string {
+	return groupConfigPrefix(svcName) + name
+}
+
+func groupConfigPrefix(svcName string) string {
+	return "/config/" + svcName + "/groups/"
+}
+
+// PutGroupConfig 校验并保存组定义，所有监听该服务的节点会按新的定义创建或更新组
+func PutGroupConfig(ctx context.Context, etcdCli *clientv3.Client, svcName string, cfg GroupConfig) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if _, err := etcdCli.Put(ctx, GroupConfigKey(svcName, cfg.Name), string(b)); err != nil {
+		return fmt.Errorf("failed to put group config: %v", err)
+	}
+	return nil
+}
+
+// DeleteGroupConfig 删除组定义，所有监听该服务的节点会销毁由它创建的组
+func DeleteGroupConfig(ctx context.Context, etcdCli *clientv3.Client, svcName, name string) error {
+	if _, err := etcdCli.Delete(ctx, GroupConfigKey(svcName, name)); err != nil {
+		return fmt.Errorf("failed to delete group config: %v", err)
+	}
+	return nil
+}
+
+// GetterFactory 根据组定义创建组的 Getter
+type GetterFactory func(cfg GroupConfig) (Getter, error)
+
+var (
+	getterFactoriesMu sync.RWMutex
+	getterFactories   = make(map[string]GetterFactory)
+)
+
+// RegisterGetterFactory 为组 name 注册 Getter 工厂，集中配置中出现该组时用它创建组
+// 同一个组重复注册会 panic；没有注册工厂的组定义会被忽略并记录日志
+func RegisterGetterFactory(name string, factory GetterFactory) {
+	getterFactoriesMu.Lock()
+	defer getterFactoriesMu.Unlock()
+
+	if factory == nil {
+		panic("LCache: RegisterGetterFactory factory is nil")
+	}
+	if _, exists := getterFactories[name]; exists {
+		panic(fmt.Sprintf("LCache: RegisterGetterFactory called twice for group %q", name))
+	}
+	getterFactories[name] = factory
+}
+
+func getterFactory(name string) (GetterFactory, bool) {
+	getterFactoriesMu.RLock()
+	defer getterFactoriesMu.RUnlock()
+	factory, ok := getterFactories[name]
+	return factory, ok
+}
+
+// ConfigSyncOption 定义 GroupConfigSync 的配置选项
+type ConfigSyncOption func(*GroupConfigSync)
+
+// WithConfigServiceName 设置服务名称，默认与节点发现使用的服务名称相同
+func WithConfigServiceName(name string) ConfigSyncOption {
+	return func(s *GroupConfigSync) {
+		s.svcName = name
+	}
+}
+
+// WithConfigGroupOptions 设置创建组时额外使用的选项（如 WithPeers、WithHooks），它们在组定义之前应用
+func WithConfigGroupOptions(opts ...GroupOption) ConfigSyncOption {
+	return func(s *GroupConfigSync) {
+		s.groupOpts = append(s.groupOpts, opts...)
+	}
+}
+
+// WithConfigRegistry 设置创建组所在的注册中心，默认 DefaultRegistry
+func WithConfigRegistry(r *Registry) ConfigSyncOption {
+	return func(s *GroupConfigSync) {
+		s.registry = r
+	}
+}
+
+// GroupConfigSync 监听 etcd 中的组定义，使本节点的组与之保持一致：
+// 新增的定义用注册的 GetterFactory 创建组，修改的定义通过 UpdateOptions 原地生效，删除的定义销毁对应的组。
+// 只管理由它创建的组，代码中用 NewGroup 创建的同名组不受影响
+type GroupConfigSync struct {
+	svcName   string
+	groupOpts []GroupOption
+	registry  *Registry
+	etcdCli   *clientv3.Client
+	ownCli    bool // etcdCli 由 GroupConfigSync 创建，关闭时一并关闭
+
+	mu      sync.Mutex
+	groups  map[string]*Group      // 由集中配置创建的组
+	configs map[string]GroupConfig // 组当前生效的定义
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewGroupConfigSync 全量同步一次组定义后开始监听变化，etcdCli 为 nil 时按 registry.DefaultConfig 创建客户端
+func NewGroupConfigSync(etcdCli *clientv3.Client, opts ...ConfigSyncOption) (*GroupConfigSync, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &GroupConfigSync{
+		svcName:  defaultSvcName,
+		registry: DefaultRegistry,
+		etcdCli:  etcdCli,
+		groups:   make(map[string]*Group),
+		configs:  make(map[string]GroupConfig),
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.etcdCli == nil {
+		cli, err := clientv3.New(clientv3.Config{
+			Endpoints:   registry.DefaultConfig.Endpoints,
+			DialTimeout: registry.DefaultConfig.DialTimeout,
+		})
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create etcd client: %v", err)
+		}
+		s.etcdCli, s.ownCli = cli, true
+	}
+
+	rev, err := s.syncAll()
+	if err != nil {
+		s.closeClient()
+		cancel()
+		return nil, err
+	}
+
+	go s.watch(rev)
+	return s, nil
+}
+
+// Groups 返回由集中配置创建的组的名称
+func (s *GroupConfigSync) Groups() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.groups))
+	for name := range s.groups {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Close 停止监听组定义，已创建的组保留，需要时由调用方销毁
+func (s *GroupConfigSync) Close() error {
+	s.cancel()
+	<-s.done
+	s.closeClient()
+	return nil
+}
+
+func (s *GroupConfigSync) closeClient() {
+	if s.ownCli {
+		s.etcdCli.Close()
+	}
+}
+
+// syncAll 读取全部组定义并应用，销毁定义已不存在的组，返回读取时的修订版本
+func (s *GroupConfigSync) syncAll() (int64, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, 3*time.Second)
+	defer cancel()
+
+	prefix := groupConfigPrefix(s.svcName)
+	resp, err := s.etcdCli.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get group configs: %v", err)
+	}
+
+	seen := make(map[string]struct{}, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		name := strings.TrimPrefix(string(kv.Key), prefix)
+		seen[name] = struct{}{}
+		s.apply(name, kv.Value)
+	}
+
+	s.mu.Lock()
+	var stale []string
+	for name := range s.groups {
+		if _, ok := seen[name]; !ok {
+			stale = append(stale, name)
+		}
+	}
+	s.mu.Unlock()
+	for _, name := range stale {
+		s.remove(name)
+	}
+
+	return resp.Header.Revision, nil
+}
+
+// watch 从修订版本 rev 之后监听组定义的变化，监听中断（如修订版本已被压缩）时重新全量同步
+func (s *GroupConfigSync) watch(rev int64) {
+	defer close(s.done)
+
+	prefix := groupConfigPrefix(s.svcName)
+	for {
+		watchChan := s.etcdCli.Watch(s.ctx, prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1))
+		for resp := range watchChan {
+			if err := resp.Err(); err != nil {
+				logrus.Warnf("[LCache] group config watch interrupted: %v", err)
+				break
+			}
+			for _, ev := range resp.Events {
+				name := strings.TrimPrefix(string(ev.Kv.Key), prefix)
+				switch ev.Type {
+				case clientv3.EventTypePut:
+					s.apply(name, ev.Kv.Value)
+				case clientv3.EventTypeDelete:
+					s.remove(name)
+				}
+			}
+			rev = resp.Header.Revision
+		}
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(configResyncDelay):
+			}
+			r, err := s.syncAll()
+			if err == nil {
+				rev = r
+				break
+			}
+			logrus.Errorf("[LCache] failed to resync group configs: %v", err)
+		}
+	}
+}
+
+// apply 解析并应用组 name 的定义，定义不合法或无法应用时保持组的当前状态
+func (s *GroupConfigSync) apply(name string, value []byte) {
+	var cfg GroupConfig
+	if err := json.Unmarshal(value, &cfg); err != nil {
+		logrus.Errorf("[LCache] %v: group %s: %v", ErrInvalidGroupConfig, name, err)
+		return
+	}
+	if cfg.Name != name {
+		logrus.Errorf("[LCache] %v: key of group %s holds config named %q", ErrInvalidGroupConfig, name, cfg.Name)
+		return
+	}
+	if err := s.applyConfig(cfg); err != nil {
+		logrus.Errorf("[LCache] failed to apply config of group [%s]: %v", name, err)
+	}
+}
+
+// applyConfig 按组定义创建组，或把修改后的定义应用到已创建的组上
+func (s *GroupConfigSync) applyConfig(cfg GroupConfig) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	consistency, _ := parseWriteConsistency(cfg.Replication)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if g, ok := s.groups[cfg.Name]; ok {
+		if s.configs[cfg.Name] == cfg {
+			return nil
+		}
+		opts := cfg.cacheOptions()
+		updates := []UpdateOption{
+			UpdateCacheType(opts.CacheType),
+			UpdateBucketCapacity(opts.CapPerBucket, opts.Level2Cap),
+			UpdateCleanupInterval(opts.CleanupTime),
+			UpdateExpiration(time.Duration(cfg.TTL)),
+			UpdateWriteConsistency(consistency),
+		}
+		// lru2 不使用 MaxBytes，修改会返回 ErrMaxBytesUnsupported；更换缓存类型时新存储按新的 max_bytes 创建
+		if opts.CacheType != store.LRU2 || s.configs[cfg.Name].cacheOptions().CacheType != store.LRU2 {
+			updates = append(updates, UpdateMaxBytes(opts.MaxBytes))
+		}
+		if err := g.UpdateOptions(updates...); err != nil {
+			return err
+		}
+		s.configs[cfg.Name] = cfg
+		logrus.Infof("[LCache] updated group [%s] from cluster config", cfg.Name)
+		return nil
+	}
+
+	if s.registry.Get(cfg.Name) != nil {
+		return fmt.Errorf("group %s was created in code and is not managed by cluster config", cfg.Name)
+	}
+	factory, ok := getterFactory(cfg.Name)
+	if !ok {
+		return fmt.Errorf("no getter factory registered for group %s", cfg.Name)
+	}
+	getter, err := factory(cfg)
+	if err != nil {
+		return fmt.Errorf("getter factory failed: %v", err)
+	}
+
+	opts := append(slices.Clone(s.groupOpts),
+		WithCacheOptions(cfg.cacheOptions()),
+		WithExpiration(time.Duration(cfg.TTL)),
+		WithDefaultWriteConsistency(consistency),
+	)
+	s.groups[cfg.Name] = s.registry.NewGroup(cfg.Name, cfg.MaxBytes, getter, opts...)
+	s.configs[cfg.Name] = cfg
+	logrus.Infof("[LCache] created group [%s] from cluster config", cfg.Name)
+	return nil
+}
+
+// remove 销毁由集中配置创建的组 name
+func (s *GroupConfigSync) remove(name string) {
+	s.mu.Lock()
+	g, ok := s.groups[name]
+	delete(s.groups, name)
+	delete(s.configs, name)
+	s.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	if err := g.Close(); err != nil {
+		logrus.Errorf("[LCache] failed to close group [%s]: %v", name, err)
+	}
+	logrus.Infof("[LCache] destroyed group [%s] removed from cluster config", name)
+}
@@ -0,0 +1,54 @@
+package LCache
+
+import (
+	"LCache/store"
+	"context"
+	"testing"
+)
+
+func TestApplyConfigUpdatesLRU2Group(t *testing.T) {
+	RegisterGetterFactory(t.Name(), func(cfg GroupConfig) (Getter, error) {
+		return GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+			return []byte(key), nil
+		}), nil
+	})
+	s := &GroupConfigSync{
+		registry: NewRegistry(),
+		groups:   make(map[string]*Group),
+		configs:  make(map[string]GroupConfig),
+	}
+	t.Cleanup(func() {
+		for _, g := range s.groups {
+			g.Close()
+		}
+	})
+
+	cfg := GroupConfig{Name: t.Name(), MaxBytes: 1 << 20}
+	if err := s.applyConfig(cfg); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	g := s.groups[t.Name()]
+
+	// 默认的 lru2 修改 max_bytes 不返回 ErrMaxBytesUnsupported，桶容量按定义修改
+	cfg.MaxBytes = 2 << 20
+	cfg.CapPerBucket, cfg.Level2Cap = 64, 32
+	if err := s.applyConfig(cfg); err != nil {
+		t.Fatalf("update lru2: %v", err)
+	}
+	opts := g.mainCache.options()
+	if opts.CacheType != store.LRU2 || opts.CapPerBucket != 64 || opts.Level2Cap != 32 {
+		t.Errorf("options = %s %d/%d, want lru2 64/32", opts.CacheType, opts.CapPerBucket, opts.Level2Cap)
+	}
+	if s.configs[t.Name()] != cfg {
+		t.Error("updated config was not recorded")
+	}
+
+	// 更换为按字节限制容量的类型时使用新的 max_bytes
+	cfg.CacheType, cfg.MaxBytes = store.LRU, 4<<20
+	if err := s.applyConfig(cfg); err != nil {
+		t.Fatalf("switch to lru: %v", err)
+	}
+	if opts := g.mainCache.options(); opts.CacheType != store.LRU || opts.MaxBytes != 4<<20 {
+		t.Errorf("options = %s %d, want lru %d", opts.CacheType, opts.MaxBytes, 4<<20)
+	}
+}
@@ -0,0 +1,92 @@
+package LCache
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Registry 缓存组的注册中心，组名在同一个注册中心内唯一
+// Server 只能访问其注册中心中的组，同一进程中的多个 Server 使用不同的注册中心即可互相隔离
+type Registry struct {
+	mu     sync.RWMutex
+	groups map[string]*Group
+}
+
+// DefaultRegistry 默认注册中心，NewGroup、GetGroup 等包级函数和未指定注册中心的 Server 使用它
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry 创建一个空的注册中心
+func NewRegistry() *Registry {
+	return &Registry{groups: make(map[string]*Group)}
+}
+
+// Get 获取指定名称的组
+func (r *Registry) Get(name string) *Group {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.groups[name]
+}
+
+// List 返回所有缓存组的名称
+func (r *Registry) List() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.groups))
+	for name := range r.groups {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Destroy 关闭并移除指定名称的组
+func (r *Registry) Destroy(name string) bool {
+	r.mu.RLock()
+	g, exists := r.groups[name]
+	r.mu.RUnlock()
+	if !exists {
+		return false
+	}
+
+	// Close 会把组从注册中心移除，不能在持有锁时调用
+	g.Close()
+	logrus.Infof("[LCache] destroyed cache group [%s]", name)
+	return true
+}
+
+// DestroyAll 关闭并移除所有组
+func (r *Registry) DestroyAll() {
+	r.mu.RLock()
+	groups := make([]*Group, 0, len(r.groups))
+	for _, g := range r.groups {
+		groups = append(groups, g)
+	}
+	r.mu.RUnlock()
+
+	for _, g := range groups {
+		g.Close()
+		logrus.Infof("[LCache] destroyed cache group [%s]", g.name)
+	}
+}
+
+// add 注册组，替换同名的组
+func (r *Registry) add(g *Group) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.groups[g.name]; exists {
+		logrus.Warnf("Group with name %s already exists, will be replaced", g.name)
+	}
+	r.groups[g.name] = g
+}
+
+// remove 移除组，同名的组已被替换时保留新的组
+func (r *Registry) remove(g *Group) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.groups[g.name] == g {
+		delete(r.groups, g.name)
+	}
+}
@@ -0,0 +1,253 @@
+package LCache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultHookWorkers   = 1    // 默认执行回调的后台协程数，单个协程使回调按事件发生的顺序执行
+	defaultHookQueueSize = 1024 // 默认的回调队列长度
+)
+
+// EvictReason 条目被动离开缓存的原因
+type EvictReason int
+
+const (
+	EvictCapacity    EvictReason = iota // 因容量被淘汰
+	EvictInvalidated                    // 按前缀或标签批量失效
+	EvictCleared                        // 组被清空
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictInvalidated:
+		return "invalidated"
+	case EvictCleared:
+		return "cleared"
+	default:
+		return "unknown"
+	}
+}
+
+// GroupHooks 组的生命周期回调，未设置的回调不会被调用
+// 回调默认由一个后台协程按事件发生的顺序异步执行，队列满时丢弃，不会阻塞缓存操作；回调中的 panic 会被捕获并记录日志，
+// 不会影响缓存操作。WithAsyncHooks 调整协程数和队列长度，WithSyncHooks 改为同步执行
+type GroupHooks struct {
+	OnHit       func(key string)                                    // 本地缓存命中
+	OnMiss      func(key string)                                    // 本地缓存未命中
+	OnLoadStart func(key string)                                    // 开始加载（每个键的并发加载只触发一次）
+	OnLoadDone  func(key string, duration time.Duration, err error) // 加载结束
+	OnPeerFetch func(key string, duration time.Duration, err error) // 从其他节点获取结束
+	OnSet       func(key string, version uint64)                    // 写入，包括原子操作和从其他节点同步过来的写入
+	OnDelete    func(key string, version uint64)                    // 删除
+	OnExpire    func(key string)                                    // 过期
+	OnEvict     func(key string, reason EvictReason)                // 因容量、批量失效或清空离开缓存
+}
+
+// WithHooks 设置组的生命周期回调
+func WithHooks(hooks GroupHooks) GroupOption {
+	return func(g *Group) {
+		g.hookRunner().hooks = hooks
+	}
+}
+
+// WithAsyncHooks 由 workers 个后台协程异步执行回调（默认 1 个），队列最多容纳 queueSize 个待执行的回调（默认 1024），
+// 队列满时丢弃新的回调并计入统计 hooks_dropped，不会阻塞缓存操作。多于一个协程时回调的执行顺序不保证与事件发生的顺序一致
+func WithAsyncHooks(workers, queueSize int) GroupOption {
+	return func(g *Group) {
+		r := g.hookRunner()
+		r.workers = max(workers, 1)
+		r.queueSize = max(queueSize, 1)
+	}
+}
+
+// WithSyncHooks 在缓存操作的调用路径上同步执行回调
+// 部分回调（OnSet、OnDelete、OnExpire、OnEvict）执行时持有缓存内部的锁，因此不能阻塞，也不能再访问同一个组
+func WithSyncHooks() GroupOption {
+	return func(g *Group) {
+		g.hookRunner().workers = 0
+	}
+}
+
+func (g *Group) hookRunner() *hookRunner {
+	if g.hooks == nil {
+		g.hooks = &hookRunner{group: g.name, workers: defaultHookWorkers, queueSize: defaultHookQueueSize}
+	}
+	return g.hooks
+}
+
+// hookRunner 执行组的回调，workers 为 0 时同步执行
+type hookRunner struct {
+	hooks     GroupHooks
+	group     string
+	workers   int
+	queueSize int
+
+	mu     sync.RWMutex
+	queue  chan func()
+	closed bool
+	wg     sync.WaitGroup
+
+	dropped int64 // 因队列已满丢弃的回调数
+	panics  int64 // 发生 panic 的回调数
+}
+
+// start 启动异步执行的后台协程
+func (r *hookRunner) start() {
+	if r.workers == 0 {
+		return
+	}
+	r.queue = make(chan func(), r.queueSize)
+	for i := 0; i < r.workers; i++ {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			for fn := range r.queue {
+				r.call(fn)
+			}
+		}()
+	}
+}
+
+// dispatch 同步执行或放入异步队列
+func (r *hookRunner) dispatch(fn func()) {
+	if r.queue == nil {
+		r.call(fn)
+		return
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return
+	}
+	select {
+	case r.queue <- fn:
+	default:
+		atomic.AddInt64(&r.dropped, 1)
+	}
+}
+
+// call 执行回调并捕获 panic
+func (r *hookRunner) call(fn func()) {
+	defer func() {
+		if p := recover(); p != nil {
+			atomic.AddInt64(&r.panics, 1)
+			logrus.Errorf("[LCache] hook of group [%s] panicked: %v", r.group, p)
+		}
+	}()
+	fn()
+}
+
+// close 停止接收新的回调，等待队列中已有的回调执行完
+func (r *hookRunner) close() {
+	if r.queue == nil {
+		return
+	}
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	close(r.queue)
+	r.mu.Unlock()
+	r.wg.Wait()
+}
+
+func (r *hookRunner) stats() map[string]interface{} {
+	return map[string]interface{}{
+		"dropped": atomic.LoadInt64(&r.dropped),
+		"panics":  atomic.LoadInt64(&r.panics),
+	}
+}
+
+func (r *hookRunner) hit(key string) {
+	if r != nil && r.hooks.OnHit != nil {
+		r.dispatch(func() { r.hooks.OnHit(key) })
+	}
+}
+
+func (r *hookRunner) miss(key string) {
+	if r != nil && r.hooks.OnMiss != nil {
+		r.dispatch(func() { r.hooks.OnMiss(key) })
+	}
+}
+
+func (r *hookRunner) loadStart(key string) {
+	if r != nil && r.hooks.OnLoadStart != nil {
+		r.dispatch(func() { r.hooks.OnLoadStart(key) })
+	}
+}
+
+func (r *hookRunner) loadDone(key string, d time.Duration, err error) {
+	if r != nil && r.hooks.OnLoadDone != nil {
+		r.dispatch(func() { r.hooks.OnLoadDone(key, d, err) })
+	}
+}
+
+func (r *hookRunner) peerFetch(key string, d time.Duration, err error) {
+	if r != nil && r.hooks.OnPeerFetch != nil {
+		r.dispatch(func() { r.hooks.OnPeerFetch(key, d, err) })
+	}
+}
+
+func (r *hookRunner) set(key string, version uint64) {
+	if r != nil && r.hooks.OnSet != nil {
+		r.dispatch(func() { r.hooks.OnSet(key, version) })
+	}
+}
+
+func (r *hookRunner) delete(key string, version uint64) {
+	if r != nil && r.hooks.OnDelete != nil {
+		r.dispatch(func() { r.hooks.OnDelete(key, version) })
+	}
+}
+
+func (r *hookRunner) expire(key string) {
+	if r != nil && r.hooks.OnExpire != nil {
+		r.dispatch(func() { r.hooks.OnExpire(key) })
+	}
+}
+
+func (r *hookRunner) evict(key string, reason EvictReason) {
+	if r != nil && r.hooks.OnEvict != nil {
+		r.dispatch(func() { r.hooks.OnEvict(key, reason) })
+	}
+}
+
+// notifySet 写入本地缓存后通知观察者和回调，调用前必须持有键锁以保证同一个键的事件有序
+func (g *Group) notifySet(key string, view ByteView) {
+	g.watchers.emit(WatchSet, key, view.version, view)
+	g.hooks.set(key, view.version)
+}
+
+// notifyDelete 删除本地缓存后通知观察者和回调，调用前必须持有键锁
+func (g *Group) notifyDelete(key string, version uint64) {
+	g.watchers.emit(WatchDelete, key, version, ByteView{})
+	g.hooks.delete(key, version)
+}
+
+// notifyRemoved 条目因过期、淘汰、批量失效或清空离开本地缓存时通知观察者和回调
+func (g *Group) notifyRemoved(key string, view ByteView, reason removalReason) {
+	switch reason {
+	case removalExpired:
+		g.watchers.emit(WatchExpire, key, view.version, view)
+		g.hooks.expire(key)
+	case removalInvalidated:
+		g.watchers.emit(WatchDelete, key, view.version, ByteView{})
+		g.hooks.evict(key, EvictInvalidated)
+	case removalCleared:
+		g.watchers.emit(WatchEvict, key, view.version, view)
+		g.hooks.evict(key, EvictCleared)
+	default:
+		g.watchers.emit(WatchEvict, key, view.version, view)
+		g.hooks.evict(key, EvictCapacity)
+	}
+}
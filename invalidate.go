@@ -0,0 +1,114 @@
+package LCache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrPrefixRequired 前缀不能为空错误
+var ErrPrefixRequired = errors.New("prefix is required")
+
+// ErrTagRequired 标签不能为空错误
+var ErrTagRequired = errors.New("tag is required")
+
+// DeletePrefix 删除所有以 prefix 开头的键，返回删除的键数
+// 非来自其他节点的请求会广播到集群中的所有节点并等待完成，返回值包含各节点删除的数量；
+// 部分节点失败时仍会返回已删除的数量和失败原因
+func (g *Group) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	if atomic.LoadInt32(&g.closed) == 1 {
+		return 0, ErrGroupClosed
+	}
+	if prefix == "" {
+		return 0, ErrPrefixRequired
+	}
+
+	deleted := g.mainCache.DeletePrefix(prefix)
+	logrus.Infof("[LCache] deleted %d keys with prefix %s from group [%s]", deleted, prefix, g.name)
+
+	if ctx.Value("from_peer") != nil {
+		return deleted, nil
+	}
+
+	remote, err := g.broadcastInvalidate(ctx, func(inv Invalidator, ctx context.Context) (int, error) {
+		return inv.DeletePrefix(ctx, g.name, prefix)
+	})
+	return deleted + remote, err
+}
+
+// InvalidateTag 删除所有带有 tag 标签的键，返回删除的键数，广播规则与 DeletePrefix 相同
+func (g *Group) InvalidateTag(ctx context.Context, tag string) (int, error) {
+	if atomic.LoadInt32(&g.closed) == 1 {
+		return 0, ErrGroupClosed
+	}
+	if tag == "" {
+		return 0, ErrTagRequired
+	}
+
+	deleted := g.mainCache.InvalidateTag(tag)
+	logrus.Infof("[LCache] invalidated %d keys with tag %s from group [%s]", deleted, tag, g.name)
+
+	if ctx.Value("from_peer") != nil {
+		return deleted, nil
+	}
+
+	remote, err := g.broadcastInvalidate(ctx, func(inv Invalidator, ctx context.Context) (int, error) {
+		return inv.InvalidateTag(ctx, g.name, tag)
+	})
+	return deleted + remote, err
+}
+
+// broadcastInvalidate 并发地在所有远程节点上执行失效操作，返回各节点删除数量之和以及所有失败
+func (g *Group) broadcastInvalidate(ctx context.Context, fn func(inv Invalidator, ctx context.Context) (int, error)) (int, error) {
+	if g.peers == nil {
+		return 0, nil
+	}
+	lister, ok := g.peers.(PeerLister)
+	if !ok {
+		return 0, fmt.Errorf("peer picker %T cannot list peers for broadcast", g.peers)
+	}
+
+	// 对端请求带上标记，避免对端再次广播；广播是后台流量，按 PriorityBulk 限流
+	syncCtx := bulkContext(context.WithValue(ctx, "from_peer", true))
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		total   int
+		errs    []error
+		remotes = lister.Peers()
+	)
+	for _, peer := range remotes {
+		inv, ok := peer.(Invalidator)
+		if !ok {
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("peer %T does not support invalidation", peer))
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := fn(inv, syncCtx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			total += n
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		logrus.Errorf("[LCache] failed to broadcast invalidation to %d of %d peers: %v", len(errs), len(remotes), errs)
+	}
+	return total, errors.Join(errs...)
+}
@@ -19,9 +19,15 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	LCache_Get_FullMethodName    = "/pb.LCache/Get"
-	LCache_Set_FullMethodName    = "/pb.LCache/Set"
-	LCache_Delete_FullMethodName = "/pb.LCache/Delete"
+	LCache_Get_FullMethodName            = "/pb.LCache/Get"
+	LCache_Set_FullMethodName            = "/pb.LCache/Set"
+	LCache_Delete_FullMethodName         = "/pb.LCache/Delete"
+	LCache_Scan_FullMethodName           = "/pb.LCache/Scan"
+	LCache_Invalidate_FullMethodName     = "/pb.LCache/Invalidate"
+	LCache_Incr_FullMethodName           = "/pb.LCache/Incr"
+	LCache_SetIfAbsent_FullMethodName    = "/pb.LCache/SetIfAbsent"
+	LCache_CompareAndSwap_FullMethodName = "/pb.LCache/CompareAndSwap"
+	LCache_Watch_FullMethodName          = "/pb.LCache/Watch"
 )
 
 // LCacheClient is the client API for LCache service.
@@ -31,6 +37,12 @@ type LCacheClient interface {
 	Get(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ResponseForGet, error)
 	Set(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ResponseForGet, error)
 	Delete(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ResponseForDelete, error)
+	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResponse, error)
+	Invalidate(ctx context.Context, in *InvalidateRequest, opts ...grpc.CallOption) (*InvalidateResponse, error)
+	Incr(ctx context.Context, in *IncrRequest, opts ...grpc.CallOption) (*IncrResponse, error)
+	SetIfAbsent(ctx context.Context, in *Request, opts ...grpc.CallOption) (*CASResponse, error)
+	CompareAndSwap(ctx context.Context, in *CASRequest, opts ...grpc.CallOption) (*CASResponse, error)
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error)
 }
 
 type lCacheClient struct {
@@ -71,6 +83,75 @@ func (c *lCacheClient) Delete(ctx context.Context, in *Request, opts ...grpc.Cal
 	return out, nil
 }
 
+func (c *lCacheClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ScanResponse)
+	err := c.cc.Invoke(ctx, LCache_Scan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lCacheClient) Invalidate(ctx context.Context, in *InvalidateRequest, opts ...grpc.CallOption) (*InvalidateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InvalidateResponse)
+	err := c.cc.Invoke(ctx, LCache_Invalidate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lCacheClient) Incr(ctx context.Context, in *IncrRequest, opts ...grpc.CallOption) (*IncrResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IncrResponse)
+	err := c.cc.Invoke(ctx, LCache_Incr_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lCacheClient) SetIfAbsent(ctx context.Context, in *Request, opts ...grpc.CallOption) (*CASResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CASResponse)
+	err := c.cc.Invoke(ctx, LCache_SetIfAbsent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lCacheClient) CompareAndSwap(ctx context.Context, in *CASRequest, opts ...grpc.CallOption) (*CASResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CASResponse)
+	err := c.cc.Invoke(ctx, LCache_CompareAndSwap_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lCacheClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LCache_ServiceDesc.Streams[0], LCache_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, WatchEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LCache_WatchClient = grpc.ServerStreamingClient[WatchEvent]
+
 // LCacheServer is the server API for LCache service.
 // All implementations must embed UnimplementedLCacheServer
 // for forward compatibility.
@@ -78,6 +159,12 @@ type LCacheServer interface {
 	Get(context.Context, *Request) (*ResponseForGet, error)
 	Set(context.Context, *Request) (*ResponseForGet, error)
 	Delete(context.Context, *Request) (*ResponseForDelete, error)
+	Scan(context.Context, *ScanRequest) (*ScanResponse, error)
+	Invalidate(context.Context, *InvalidateRequest) (*InvalidateResponse, error)
+	Incr(context.Context, *IncrRequest) (*IncrResponse, error)
+	SetIfAbsent(context.Context, *Request) (*CASResponse, error)
+	CompareAndSwap(context.Context, *CASRequest) (*CASResponse, error)
+	Watch(*WatchRequest, grpc.ServerStreamingServer[WatchEvent]) error
 	mustEmbedUnimplementedLCacheServer()
 }
 
@@ -97,6 +184,24 @@ func (UnimplementedLCacheServer) Set(context.Context, *Request) (*ResponseForGet
 func (UnimplementedLCacheServer) Delete(context.Context, *Request) (*ResponseForDelete, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
+func (UnimplementedLCacheServer) Scan(context.Context, *ScanRequest) (*ScanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Scan not implemented")
+}
+func (UnimplementedLCacheServer) Invalidate(context.Context, *InvalidateRequest) (*InvalidateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Invalidate not implemented")
+}
+func (UnimplementedLCacheServer) Incr(context.Context, *IncrRequest) (*IncrResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Incr not implemented")
+}
+func (UnimplementedLCacheServer) SetIfAbsent(context.Context, *Request) (*CASResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetIfAbsent not implemented")
+}
+func (UnimplementedLCacheServer) CompareAndSwap(context.Context, *CASRequest) (*CASResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompareAndSwap not implemented")
+}
+func (UnimplementedLCacheServer) Watch(*WatchRequest, grpc.ServerStreamingServer[WatchEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
 func (UnimplementedLCacheServer) mustEmbedUnimplementedLCacheServer() {}
 func (UnimplementedLCacheServer) testEmbeddedByValue()                {}
 
@@ -172,6 +277,107 @@ func _LCache_Delete_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LCache_Scan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LCacheServer).Scan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LCache_Scan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LCacheServer).Scan(ctx, req.(*ScanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LCache_Invalidate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InvalidateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LCacheServer).Invalidate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LCache_Invalidate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LCacheServer).Invalidate(ctx, req.(*InvalidateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LCache_Incr_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IncrRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LCacheServer).Incr(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LCache_Incr_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LCacheServer).Incr(ctx, req.(*IncrRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LCache_SetIfAbsent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LCacheServer).SetIfAbsent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LCache_SetIfAbsent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LCacheServer).SetIfAbsent(ctx, req.(*Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LCache_CompareAndSwap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CASRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LCacheServer).CompareAndSwap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LCache_CompareAndSwap_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LCacheServer).CompareAndSwap(ctx, req.(*CASRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LCache_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LCacheServer).Watch(m, &grpc.GenericServerStream[WatchRequest, WatchEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LCache_WatchServer = grpc.ServerStreamingServer[WatchEvent]
+
 // LCache_ServiceDesc is the grpc.ServiceDesc for LCache service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -191,7 +397,33 @@ var LCache_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Delete",
 			Handler:    _LCache_Delete_Handler,
 		},
+		{
+			MethodName: "Scan",
+			Handler:    _LCache_Scan_Handler,
+		},
+		{
+			MethodName: "Invalidate",
+			Handler:    _LCache_Invalidate_Handler,
+		},
+		{
+			MethodName: "Incr",
+			Handler:    _LCache_Incr_Handler,
+		},
+		{
+			MethodName: "SetIfAbsent",
+			Handler:    _LCache_SetIfAbsent_Handler,
+		},
+		{
+			MethodName: "CompareAndSwap",
+			Handler:    _LCache_CompareAndSwap_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _LCache_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "cache.proto",
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/sirupsen/logrus"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc/credentials"
 	"log"
 	"strings"
 	"sync"
@@ -24,21 +25,36 @@ type PeerPicker interface {
 // Peer 定义了缓存节点的接口
 type Peer interface {
 	Get(group string, key string) ([]byte, error)
-	Set(ctx context.Context, group string, key string, value []byte) error
-	Delete(group string, key string) (bool, error)
+	Set(ctx context.Context, group string, key string, value []byte, opts ...WriteOption) error
+	Delete(ctx context.Context, group string, key string, opts ...WriteOption) (bool, error)
 	Close() error
 }
 
+// PeerLister 可以列出所有远程节点的 PeerPicker，用于需要广播到整个集群的操作
+type PeerLister interface {
+	Peers() []Peer
+}
+
+// Invalidator 支持批量失效的节点
+type Invalidator interface {
+	DeletePrefix(ctx context.Context, group string, prefix string) (int, error)
+	InvalidateTag(ctx context.Context, group string, tag string) (int, error)
+}
+
 // ClientPicker 实现了PeerPicker接口
 type ClientPicker struct {
-	selfAddr string
-	svcName  string
-	mu       sync.RWMutex
-	consHash *consistenthash.Map
-	clients  map[string]*Client
-	etcdCli  *clientv3.Client
-	ctx      context.Context
-	cancel   context.CancelFunc
+	selfAddr      string
+	svcName       string
+	mu            sync.RWMutex
+	consHash      *consistenthash.Map
+	clients       map[string]*Client
+	etcdCli       *clientv3.Client
+	creds         credentials.TransportCredentials // 连接其他节点使用的传输层凭据，为 nil 时不加密
+	tls           *pickerTLS                       // mTLS 配置，为 nil 时不启用
+	token         string                           // 访问其他节点使用的认证令牌
+	insecureToken bool                             // 是否允许在未加密的连接上发送令牌
+	ctx           context.Context
+	cancel        context.CancelFunc
 }
 
 // PickerOption 定义配置选项
@@ -51,6 +67,58 @@ func WithServiceName(name string) PickerOption {
 	}
 }
 
+// pickerTLS 连接其他节点的 mTLS 配置
+type pickerTLS struct {
+	caFile      string
+	certFile    string
+	keyFile     string
+	allowedSANs []string
+	reload      time.Duration
+}
+
+func (p *ClientPicker) tlsConfig() *pickerTLS {
+	if p.tls == nil {
+		p.tls = &pickerTLS{}
+	}
+	return p.tls
+}
+
+// WithPeerTLS 使用双向 TLS 连接其他节点：用 caFile 校验服务端证书，并出示 certFile/keyFile 作为客户端证书
+func WithPeerTLS(caFile, certFile, keyFile string) PickerOption {
+	return func(p *ClientPicker) {
+		cfg := p.tlsConfig()
+		cfg.caFile, cfg.certFile, cfg.keyFile = caFile, certFile, keyFile
+	}
+}
+
+// WithPeerAllowedSANs 只连接证书 SAN 匹配任一 glob 模式的节点，未设置时按拨号地址校验服务端证书的主机名
+func WithPeerAllowedSANs(sans ...string) PickerOption {
+	return func(p *ClientPicker) {
+		p.tlsConfig().allowedSANs = sans
+	}
+}
+
+// WithPeerCertReloadInterval 设置检查证书文件是否更新的最小间隔，文件更新后新的连接使用新证书
+func WithPeerCertReloadInterval(d time.Duration) PickerOption {
+	return func(p *ClientPicker) {
+		p.tlsConfig().reload = d
+	}
+}
+
+// WithPeerToken 设置访问其他节点使用的认证令牌，节点间会转发写入和失效请求，因此该身份需要相应组的写入和管理权限
+func WithPeerToken(token string) PickerOption {
+	return func(p *ClientPicker) {
+		p.token = token
+	}
+}
+
+// WithInsecurePeerToken 允许在未启用 mTLS 的连接上发送 WithPeerToken 设置的令牌，令牌可能被窃听，只应用于测试或可信网络
+func WithInsecurePeerToken() PickerOption {
+	return func(p *ClientPicker) {
+		p.insecureToken = true
+	}
+}
+
 // PrintPeers 打印当前已发现的节点（仅用于调试）
 func (p *ClientPicker) PrintPeers() {
 	p.mu.RLock()
@@ -78,6 +146,22 @@ func NewClientPicker(addr string, opts ...PickerOption) (*ClientPicker, error) {
 		opt(picker)
 	}
 
+	if cfg := picker.tls; cfg != nil {
+		if err := validateSANPatterns(cfg.allowedSANs); err != nil {
+			cancel()
+			return nil, err
+		}
+		certs, err := newCertReloader(cfg.caFile, cfg.certFile, cfg.keyFile, cfg.reload)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to load TLS credentials: %v", err)
+		}
+		picker.creds = certs.clientCredentials(cfg.allowedSANs)
+	}
+
+	// 本节点也在哈希环上，使所有节点对键的所有者达成一致
+	picker.consHash.Add(addr)
+
 	cli, err := clientv3.New(clientv3.Config{
 		Endpoints:   registry.DefaultConfig.Endpoints,
 		DialTimeout: registry.DefaultConfig.DialTimeout,
@@ -178,7 +262,17 @@ func (p *ClientPicker) fetchAllServices() error {
 
 // set 添加服务实例
 func (p *ClientPicker) set(addr string) {
-	if client, err := NewClient(addr, p.svcName, p.etcdCli); err == nil {
+	opts := []ClientOption{asPeer()}
+	if p.creds != nil {
+		opts = append(opts, WithTransportCredentials(p.creds))
+	}
+	if p.token != "" {
+		opts = append(opts, WithToken(p.token))
+	}
+	if p.insecureToken {
+		opts = append(opts, WithInsecureToken())
+	}
+	if client, err := NewClient(addr, p.svcName, p.etcdCli, opts...); err == nil {
 		p.consHash.Add(addr)
 		p.clients[addr] = client
 		logrus.Infof("Successfully created client for %s", addr)
@@ -199,6 +293,9 @@ func (p *ClientPicker) PickPeer(key string) (Peer, bool, bool) {
 	defer p.mu.RUnlock()
 
 	if addr := p.consHash.Get(key); addr != "" {
+		if addr == p.selfAddr {
+			return nil, true, true
+		}
 		if client, ok := p.clients[addr]; ok {
 			return client, true, addr == p.selfAddr
 		}
@@ -206,6 +303,18 @@ func (p *ClientPicker) PickPeer(key string) (Peer, bool, bool) {
 	return nil, false, false
 }
 
+// Peers 返回所有已发现的远程节点
+func (p *ClientPicker) Peers() []Peer {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	peers := make([]Peer, 0, len(p.clients))
+	for _, client := range p.clients {
+		peers = append(peers, client)
+	}
+	return peers
+}
+
 // Close 关闭所有资源
 func (p *ClientPicker) Close() error {
 	p.cancel()
@@ -0,0 +1,353 @@
+package LCache
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	priorityHeader   = "lcache-priority"    // 请求优先级的元数据键
+	retryAfterHeader = "lcache-retry-after" // 被限流时建议的重试等待时间（毫秒）的元数据键
+
+	defaultThrottleRetries = 3               // Client 被限流后默认的重试次数
+	rateLimitSweepInterval = 1 * time.Minute // 清理空闲令牌桶的间隔
+)
+
+// Priority 请求优先级，服务端限流时低优先级的请求不能用完留给高优先级请求的令牌
+type Priority int
+
+const (
+	PriorityNormal      Priority = iota // 普通请求，默认优先级
+	PriorityInteractive                 // 交互式读取，可以使用全部令牌
+	PriorityBulk                        // 节点间的写入复制和失效广播、批量预热等后台流量
+)
+
+// priorityReserve 各优先级的请求取令牌后桶内至少要保留的令牌比例
+var priorityReserve = map[Priority]float64{
+	PriorityInteractive: 0,
+	PriorityNormal:      0.2,
+	PriorityBulk:        0.5,
+}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityBulk:
+		return "bulk"
+	default:
+		return "normal"
+	}
+}
+
+func parsePriority(s string) Priority {
+	switch s {
+	case "interactive":
+		return PriorityInteractive
+	case "bulk":
+		return PriorityBulk
+	default:
+		return PriorityNormal
+	}
+}
+
+// priorityKey 请求优先级在 context 中的键
+type priorityKey struct{}
+
+// ContextWithPriority 返回携带请求优先级的 context，Client 用它发出的请求按该优先级限流，覆盖 WithPriority
+func ContextWithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// bulkContext 为节点间的内部请求设置 PriorityBulk，调用方已经用 ContextWithPriority 指定优先级时沿用调用方的
+func bulkContext(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return ctx
+	}
+	return ContextWithPriority(ctx, PriorityBulk)
+}
+
+// RateLimit 令牌桶限速配置：每秒补充 Rate 个令牌，最多积累 Burst 个，Rate <= 0 表示不限制
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitOptions 服务端限流配置，请求需要同时通过调用方和组的限制
+type RateLimitOptions struct {
+	PerClient RateLimit            // 每个调用方的限制，调用方为认证身份，未启用认证时为对端 IP
+	PerGroup  RateLimit            // 每个组的默认限制
+	Groups    map[string]RateLimit // 按组名覆盖 PerGroup
+}
+
+// tokenBucket 令牌桶，调用方负责加锁
+type tokenBucket struct {
+	rate   float64 // 每秒补充的令牌数
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	burst := math.Max(float64(limit.Burst), 1)
+	return &tokenBucket{rate: limit.Rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// delay 按流逝的时间补充令牌，返回取一个令牌后仍保留 reserve 比例的令牌需要等待的时间，0 表示可以立即取
+func (b *tokenBucket) delay(now time.Time, reserve float64) time.Duration {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	// 桶太小时无法保留，退化为不区分优先级
+	need := math.Max(1, math.Min(1+reserve*b.burst, b.burst))
+	if b.tokens >= need {
+		return 0
+	}
+	return max(time.Duration((need-b.tokens)/b.rate*float64(time.Second)), time.Millisecond)
+}
+
+// take 取一个令牌，调用前需要确认 delay 返回 0
+func (b *tokenBucket) take() {
+	b.tokens--
+}
+
+// full 桶是否已满，已满的桶与新建的桶等价，可以丢弃
+func (b *tokenBucket) full() bool {
+	return b.tokens >= b.burst
+}
+
+// rateLimiter 按调用方和组限流
+type rateLimiter struct {
+	opts RateLimitOptions
+
+	mu        sync.Mutex
+	clients   map[string]*tokenBucket
+	groups    map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(opts RateLimitOptions) *rateLimiter {
+	return &rateLimiter{
+		opts:      opts,
+		clients:   make(map[string]*tokenBucket),
+		groups:    make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (r *rateLimiter) groupLimit(group string) RateLimit {
+	if limit, ok := r.opts.Groups[group]; ok {
+		return limit
+	}
+	return r.opts.PerGroup
+}
+
+// allow 检查请求能否执行，被限流时返回建议的等待时间和触发限流的范围
+func (r *rateLimiter) allow(client, group string, p Priority) (time.Duration, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.lastSweep) >= rateLimitSweepInterval {
+		r.sweep(now)
+	}
+
+	reserve := priorityReserve[p]
+	var buckets []*tokenBucket
+	var wait time.Duration
+	var scope string
+
+	if r.opts.PerClient.Rate > 0 {
+		b := bucketFor(r.clients, client, r.opts.PerClient)
+		if d := b.delay(now, reserve); d > wait {
+			wait, scope = d, "client "+client
+		}
+		buckets = append(buckets, b)
+	}
+	if limit := r.groupLimit(group); limit.Rate > 0 {
+		b := bucketFor(r.groups, group, limit)
+		if d := b.delay(now, reserve); d > wait {
+			wait, scope = d, "group "+group
+		}
+		buckets = append(buckets, b)
+	}
+
+	// 所有限制都通过后才取令牌，避免被拒绝的请求消耗其他桶的令牌
+	if wait > 0 {
+		return wait, scope
+	}
+	for _, b := range buckets {
+		b.take()
+	}
+	return 0, ""
+}
+
+func bucketFor(buckets map[string]*tokenBucket, key string, limit RateLimit) *tokenBucket {
+	b, ok := buckets[key]
+	if !ok {
+		b = newTokenBucket(limit)
+		buckets[key] = b
+	}
+	return b
+}
+
+// sweep 丢弃已经补满的令牌桶，避免调用方很多时 map 无限增长，调用前必须持有锁
+func (r *rateLimiter) sweep(now time.Time) {
+	for _, buckets := range []map[string]*tokenBucket{r.clients, r.groups} {
+		for key, b := range buckets {
+			b.delay(now, 0)
+			if b.full() {
+				delete(buckets, key)
+			}
+		}
+	}
+	r.lastSweep = now
+}
+
+// rateLimitInterceptor 限流的一元拦截器，只作用于 LCache 服务，被限流的请求返回 ResourceExhausted，
+// 并在 trailer 中给出建议的重试等待时间
+func rateLimitInterceptor(r *rateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !strings.HasPrefix(info.FullMethod, "/pb.LCache/") {
+			return handler(ctx, req)
+		}
+
+		var group string
+		if g, ok := req.(interface{ GetGroup() string }); ok {
+			group = g.GetGroup()
+		}
+		client, p := callerID(ctx), requestPriority(ctx)
+		if wait, scope := r.allow(client, group, p); wait > 0 {
+			logrus.Debugf("[LCache] throttled %s request %s from %s, retry after %v", p, info.FullMethod, client, wait)
+			grpc.SetTrailer(ctx, metadata.Pairs(retryAfterHeader, strconv.FormatInt(wait.Milliseconds(), 10)))
+			return nil, status.Errorf(codes.ResourceExhausted, "rate limit exceeded for %s", scope)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitStreamInterceptor 流式 RPC 的限流拦截器，只在建立流时计一次请求
+func rateLimitStreamInterceptor(r *rateLimiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !strings.HasPrefix(info.FullMethod, "/pb.LCache/") {
+			return handler(srv, ss)
+		}
+
+		// 外层的认证拦截器在收到请求后才把身份写入 context，因此在收到请求时再读取
+		stream := &recvHookStream{ServerStream: ss}
+		stream.onRecv = func(m interface{}) error {
+			var group string
+			if g, ok := m.(interface{ GetGroup() string }); ok {
+				group = g.GetGroup()
+			}
+			ctx := ss.Context()
+			client, p := callerID(ctx), requestPriority(ctx)
+			if wait, scope := r.allow(client, group, p); wait > 0 {
+				stream.SetTrailer(metadata.Pairs(retryAfterHeader, strconv.FormatInt(wait.Milliseconds(), 10)))
+				return status.Errorf(codes.ResourceExhausted, "rate limit exceeded for %s", scope)
+			}
+			return nil
+		}
+		return handler(srv, stream)
+	}
+}
+
+// callerID 返回限流使用的调用方标识：认证身份，未启用认证时为对端 IP
+func callerID(ctx context.Context) string {
+	if identity, ok := IdentityFromContext(ctx); ok {
+		return identity
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// requestPriority 从请求元数据中取出优先级，未携带时为 PriorityNormal
+func requestPriority(ctx context.Context) Priority {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return PriorityNormal
+	}
+	if v := md.Get(priorityHeader); len(v) > 0 {
+		return parsePriority(v[0])
+	}
+	return PriorityNormal
+}
+
+// throttleInterceptor 客户端拦截器：在请求上附加优先级，被限流时按服务端建议的时间等待后重试，
+// 最多重试 maxRetries 次，等待时间超过 ctx 的截止时间时直接返回错误
+func throttleInterceptor(defaultPriority Priority, maxRetries int) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		p, ok := ctx.Value(priorityKey{}).(Priority)
+		if !ok {
+			p = defaultPriority
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, priorityHeader, p.String())
+
+		for attempt := 0; ; attempt++ {
+			var trailer metadata.MD
+			err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Trailer(&trailer))...)
+			wait, ok := retryAfter(err, trailer)
+			if !ok || attempt >= maxRetries {
+				return err
+			}
+
+			// 加上抖动，避免被限流的客户端同时重试
+			wait += rand.N(wait/10 + 1)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+				return err
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// priorityStreamInterceptor 客户端流拦截器：在流上附加优先级
+func priorityStreamInterceptor(defaultPriority Priority) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		p, ok := ctx.Value(priorityKey{}).(Priority)
+		if !ok {
+			p = defaultPriority
+		}
+		return streamer(metadata.AppendToOutgoingContext(ctx, priorityHeader, p.String()), desc, cc, method, opts...)
+	}
+}
+
+// retryAfter 从被限流的响应中取出建议的重试等待时间
+func retryAfter(err error, trailer metadata.MD) (time.Duration, bool) {
+	if status.Code(err) != codes.ResourceExhausted {
+		return 0, false
+	}
+	v := trailer.Get(retryAfterHeader)
+	if len(v) == 0 {
+		return 0, false
+	}
+	ms, perr := strconv.ParseInt(v[0], 10, 64)
+	if perr != nil || ms < 0 {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
@@ -0,0 +1,108 @@
+package LCache
+
+import (
+	"LCache/store"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrMaxBytesUnsupported 存储按条目数限制容量（如 lru2），修改 MaxBytes 不会有任何效果
+var ErrMaxBytesUnsupported = errors.New("cache type does not limit capacity by max bytes")
+
+// UpdateOption 定义 UpdateOptions 的配置选项
+type UpdateOption func(*groupUpdate)
+
+// groupUpdate 运行时修改后的组配置
+type groupUpdate struct {
+	cache       CacheOptions
+	expiration  time.Duration
+	consistency WriteConsistency
+}
+
+// UpdateMaxBytes 修改缓存的最大内存使用量，缩容时立即淘汰多出的条目
+// lru2 按每个桶的条目数限制容量，不使用 MaxBytes，修改时 UpdateOptions 返回 ErrMaxBytesUnsupported，应使用 UpdateBucketCapacity
+func UpdateMaxBytes(maxBytes int64) UpdateOption {
+	return func(u *groupUpdate) {
+		u.cache.MaxBytes = maxBytes
+	}
+}
+
+// UpdateBucketCapacity 修改 LRU2 每个桶一级和二级缓存的容量，参数为 0 时对应的容量不变
+func UpdateBucketCapacity(capPerBucket, level2Cap uint16) UpdateOption {
+	return func(u *groupUpdate) {
+		if capPerBucket > 0 {
+			u.cache.CapPerBucket = capPerBucket
+		}
+		if level2Cap > 0 {
+			u.cache.Level2Cap = level2Cap
+		}
+	}
+}
+
+// UpdateExpiration 修改组的统一过期时间，只对之后写入和加载的条目生效，d <= 0 表示永不过期
+func UpdateExpiration(d time.Duration) UpdateOption {
+	return func(u *groupUpdate) {
+		u.expiration = d
+	}
+}
+
+// UpdateWriteConsistency 修改组内 Set 和 Delete 默认的同步方式
+func UpdateWriteConsistency(c WriteConsistency) UpdateOption {
+	return func(u *groupUpdate) {
+		u.consistency = c
+	}
+}
+
+// UpdateCleanupInterval 修改清理过期条目的间隔
+func UpdateCleanupInterval(d time.Duration) UpdateOption {
+	return func(u *groupUpdate) {
+		u.cache.CleanupTime = d
+	}
+}
+
+// UpdateCacheType 更换缓存类型（淘汰策略），缓存中的条目会被迁移到新类型的存储中
+func UpdateCacheType(cacheType store.CacheType) UpdateOption {
+	return func(u *groupUpdate) {
+		u.cache.CacheType = cacheType
+	}
+}
+
+// UpdateOptions 在不清空缓存的情况下修改运行中的组的配置
+// 存储支持时原地调整容量和清理间隔；更换缓存类型或存储不支持原地修改时（如 arena 的容量），按新配置创建存储并迁移条目：
+// 新存储立即接管写入，读取在新存储未命中时回退到旧存储，旧存储中的条目逐个复制完后被关闭，迁移期间组始终可用。
+// 迁移在调用方的协程中进行，UpdateOptions 在迁移完成后返回；新存储创建失败时不做任何修改并返回错误
+func (g *Group) UpdateOptions(opts ...UpdateOption) error {
+	if atomic.LoadInt32(&g.closed) == 1 {
+		return ErrGroupClosed
+	}
+
+	g.updateMu.Lock()
+	defer g.updateMu.Unlock()
+
+	u := groupUpdate{
+		cache:       g.mainCache.options(),
+		expiration:  g.ttl(),
+		consistency: WriteConsistency(atomic.LoadInt32(&g.consistency)),
+	}
+	for _, opt := range opts {
+		opt(&u)
+	}
+
+	if err := g.mainCache.reconfigure(u.cache, g.keyLocks.lock); err != nil {
+		return err
+	}
+	atomic.StoreInt64(&g.expiration, int64(u.expiration))
+	atomic.StoreInt32(&g.consistency, int32(u.consistency))
+
+	logrus.Infof("[LCache] updated options of group [%s]: type=%s, maxBytes=%d, expiration=%v, cleanup=%v",
+		g.name, u.cache.CacheType, u.cache.MaxBytes, u.expiration, u.cache.CleanupTime)
+	return nil
+}
+
+// ttl 返回组当前的统一过期时间
+func (g *Group) ttl() time.Duration {
+	return time.Duration(atomic.LoadInt64(&g.expiration))
+}
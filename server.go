@@ -3,16 +3,20 @@ package LCache
 import (
 	"LCache/registry"
 	"context"
-	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
-	"sync"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/health"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 
 	pb "LCache/pb"
 	"github.com/sirupsen/logrus"
@@ -24,7 +28,7 @@ type Server struct {
 	pb.UnimplementedLCacheServer
 	addr       string           // 服务地址
 	svcName    string           // 服务名称
-	groups     *sync.Map        // 缓存组
+	registry   *Registry        // 服务的缓存组所在的注册中心
 	grpcServer *grpc.Server     // gRPC服务器
 	etcdCli    *clientv3.Client // etcd客户端
 	stopCh     chan error       // 停止信号
@@ -33,12 +37,22 @@ type Server struct {
 
 // ServerOptions 服务器配置选项
 type ServerOptions struct {
-	EtcdEndpoints []string      // etcd端点
-	DialTimeout   time.Duration // 连接超时
-	MaxMsgSize    int           // 最大消息大小
-	TLS           bool          // 是否启用TLS
-	CertFile      string        // 证书文件
-	KeyFile       string        // 密钥文件
+	EtcdEndpoints []string          // etcd端点
+	DialTimeout   time.Duration     // 连接超时
+	MaxMsgSize    int               // 最大消息大小
+	TLS           bool              // 是否启用TLS
+	CertFile      string            // 证书文件
+	KeyFile       string            // 密钥文件
+	CAFile        string            // 校验客户端证书的 CA 文件，非空时要求客户端出示证书（mTLS）
+	AllowedSANs   []string          // 允许的客户端证书 SAN（glob 模式），为空时接受 CA 签发的任意证书
+	CertReload    time.Duration     // 检查证书文件是否更新的最小间隔，0 表示使用默认值
+	SnapshotDir   string            // 快照目录，非空时 Stop 时保存快照、Start 时恢复
+	Auth          Authenticator     // 令牌认证，为 nil 时不认证
+	ACL           *ACL              // 按组的访问控制，为 nil 时已认证的身份拥有全部权限
+	Audit         AuditLogger       // 记录被拒绝的请求，为 nil 时输出到日志
+	RateLimit     *RateLimitOptions // 按调用方和组限流，为 nil 时不限流
+	Registry      *Registry         // 服务的缓存组所在的注册中心，为 nil 时使用 DefaultRegistry
+	Tenants       *Tenants          // 租户集合，非空时按租户的请求配额限流，Registry 为 nil 时使用租户的注册中心
 }
 
 // DefaultServerOptions 默认配置
@@ -74,6 +88,76 @@ func WithTLS(certFile, keyFile string) ServerOption {
 	}
 }
 
+// WithMutualTLS 启用双向 TLS：使用 certFile/keyFile 作为服务端证书，并要求客户端出示由 caFile 签发的证书
+func WithMutualTLS(caFile, certFile, keyFile string) ServerOption {
+	return func(o *ServerOptions) {
+		o.TLS = true
+		o.CAFile = caFile
+		o.CertFile = certFile
+		o.KeyFile = keyFile
+	}
+}
+
+// WithAllowedSANs 只接受 SAN 匹配任一 glob 模式的客户端证书，例如 "*.lcache.internal"
+func WithAllowedSANs(sans ...string) ServerOption {
+	return func(o *ServerOptions) {
+		o.AllowedSANs = sans
+	}
+}
+
+// WithCertReloadInterval 设置检查证书文件是否更新的最小间隔，文件更新后新的连接使用新证书
+func WithCertReloadInterval(d time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.CertReload = d
+	}
+}
+
+// WithAuth 启用令牌认证和按组的访问控制：请求需要在元数据 authorization 中携带 "Bearer <token>"，
+// 未认证的请求返回 Unauthenticated，没有权限的请求返回 PermissionDenied
+func WithAuth(auth Authenticator, acl *ACL) ServerOption {
+	return func(o *ServerOptions) {
+		o.Auth = auth
+		o.ACL = acl
+	}
+}
+
+// WithAuditLogger 设置被拒绝请求的审计日志
+func WithAuditLogger(l AuditLogger) ServerOption {
+	return func(o *ServerOptions) {
+		o.Audit = l
+	}
+}
+
+// WithRateLimit 启用按调用方和组的限流，被限流的请求返回 ResourceExhausted 并携带建议的重试等待时间，
+// 低优先级的请求（见 ContextWithPriority）不能用完留给高优先级请求的令牌
+func WithRateLimit(opts RateLimitOptions) ServerOption {
+	return func(o *ServerOptions) {
+		o.RateLimit = &opts
+	}
+}
+
+// WithRegistry 设置服务的缓存组所在的注册中心，请求只能访问其中的组
+func WithRegistry(r *Registry) ServerOption {
+	return func(o *ServerOptions) {
+		o.Registry = r
+	}
+}
+
+// WithTenants 启用多租户：携带 lcache-tenant 元数据的请求访问该租户的组并计入租户的请求配额，
+// 超出配额时返回 ResourceExhausted，未注册的租户返回 NotFound
+func WithTenants(ts *Tenants) ServerOption {
+	return func(o *ServerOptions) {
+		o.Tenants = ts
+	}
+}
+
+// WithSnapshotDir 设置快照目录：Stop 时将所有组的缓存写入该目录，Start 时从中恢复已创建的组
+func WithSnapshotDir(dir string) ServerOption {
+	return func(o *ServerOptions) {
+		o.SnapshotDir = dir
+	}
+}
+
 // NewServer 创建新的 LCache 服务端
 func NewServer(addr, svcName string, opts ...ServerOption) (*Server, error) {
 	// 拷贝默认配置，避免多个 Server 共用同一个指针
@@ -98,18 +182,66 @@ func NewServer(addr, svcName string, opts ...ServerOption) (*Server, error) {
 	}
 
 	if options.TLS {
-		creds, err := loadTLSCredentials(options.CertFile, options.KeyFile)
+		if err := validateSANPatterns(options.AllowedSANs); err != nil {
+			return nil, err
+		}
+		certs, err := newCertReloader(options.CAFile, options.CertFile, options.KeyFile, options.CertReload)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load TLS credentials: %v", err)
 		}
-		serverOpts = append(serverOpts, grpc.Creds(creds))
+		serverOpts = append(serverOpts, grpc.Creds(certs.serverCredentials(options.AllowedSANs)))
+	}
+
+	// 先认证再按租户和调用方限流，限流时可以按认证身份区分调用方
+	var interceptors []grpc.UnaryServerInterceptor
+	if options.Auth != nil {
+		audit := options.Audit
+		if audit == nil {
+			audit = AuditLoggerFunc(logAudit)
+		}
+		interceptors = append(interceptors, authInterceptor(options.Auth, options.ACL, audit))
+	}
+	if options.Tenants != nil {
+		interceptors = append(interceptors, tenantQuotaInterceptor(options.Tenants, options.Auth != nil, options.ACL))
+	}
+	var limiter *rateLimiter
+	if options.RateLimit != nil {
+		limiter = newRateLimiter(*options.RateLimit)
+		interceptors = append(interceptors, rateLimitInterceptor(limiter))
+	}
+	if len(interceptors) > 0 {
+		serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(interceptors...))
+	}
+	var streamInterceptors []grpc.StreamServerInterceptor
+	if options.Auth != nil {
+		audit := options.Audit
+		if audit == nil {
+			audit = AuditLoggerFunc(logAudit)
+		}
+		streamInterceptors = append(streamInterceptors, authStreamInterceptor(options.Auth, options.ACL, audit))
+	}
+	if options.Tenants != nil {
+		streamInterceptors = append(streamInterceptors, tenantQuotaStreamInterceptor(options.Tenants, options.Auth != nil, options.ACL))
+	}
+	if options.RateLimit != nil {
+		streamInterceptors = append(streamInterceptors, rateLimitStreamInterceptor(limiter))
+	}
+	if len(streamInterceptors) > 0 {
+		serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(streamInterceptors...))
+	}
+
+	if options.Registry == nil && options.Tenants != nil {
+		options.Registry = options.Tenants.registry
+	}
+	if options.Registry == nil {
+		options.Registry = DefaultRegistry
 	}
 
 	// 构建 Server 实例
 	srv := &Server{
 		addr:       addr,
 		svcName:    svcName,
-		groups:     &sync.Map{},
+		registry:   options.Registry,
 		grpcServer: grpc.NewServer(serverOpts...),
 		etcdCli:    etcdCli,
 		stopCh:     make(chan error, 1),
@@ -134,6 +266,10 @@ func (s *Server) Start() error {
 		return fmt.Errorf("failed to listen on %s: %v", s.addr, err)
 	}
 
+	if s.opts.SnapshotDir != "" {
+		s.restoreSnapshots()
+	}
+
 	// 注册到 etcd
 	go func() {
 		err := registry.Register(s.svcName, s.addr, s.stopCh)
@@ -164,6 +300,11 @@ func (s *Server) Stop() {
 	s.grpcServer.GracefulStop()
 	logrus.Info("gRPC server stopped")
 
+	// 在请求全部结束后保存快照
+	if s.opts.SnapshotDir != "" {
+		s.saveSnapshots()
+	}
+
 	// 关闭 etcd 客户端
 	if s.etcdCli != nil {
 		if err := s.etcdCli.Close(); err != nil {
@@ -176,33 +317,43 @@ func (s *Server) Stop() {
 
 // Get 实现Cache服务的Get方法
 func (s *Server) Get(ctx context.Context, req *pb.Request) (*pb.ResponseForGet, error) {
-	group := GetGroup(req.Group)
+	group := s.registry.Get(requestGroup(ctx, req.Group))
 	if group == nil {
 		return nil, fmt.Errorf("group %s not found", req.Group)
 	}
 
 	view, err := group.Get(ctx, req.Key)
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
-	return &pb.ResponseForGet{Value: view.ByteSLice()}, nil
+	// 压缩、加密的值原样返回，由调用方解码
+	return &pb.ResponseForGet{
+		Value:     view.b,
+		Version:   view.Version(),
+		Codec:     view.codecName(),
+		Encrypted: view.enc != nil,
+	}, nil
 }
 
 // Set 实现Cache服务的Set方法
 func (s *Server) Set(ctx context.Context, req *pb.Request) (*pb.ResponseForGet, error) {
-	group := GetGroup(req.Group)
+	group := s.registry.Get(requestGroup(ctx, req.Group))
 	if group == nil {
 		return nil, fmt.Errorf("group %s not found", req.Group)
 	}
 
-	// 从 context 中获取标记，如果没有则创建新的 context
-	fromPeer := ctx.Value("from_peer")
-	if fromPeer == nil {
-		ctx = context.WithValue(ctx, "from_peer", true)
+	// 其他节点同步过来的写入作为副本只写本地缓存；客户端的写入由本节点写后端并同步到其他节点
+	// 只有可信的节点发来的值按保存形式直接保存，客户端的值按组的配置压缩、加密，不能绕过组的加密
+	fromPeer, err := s.fromPeer(ctx, group.name)
+	if err != nil {
+		return nil, err
 	}
-
-	if err := group.Set(ctx, req.Key, req.Value); err != nil {
+	opts := []WriteOption{WithTags(req.Tags...), withVersion(s.peerVersion(ctx, group.name, req.Version))}
+	if fromPeer {
+		opts = append(opts, asReplica(), withEncoding(req.Codec, req.Encrypted))
+	}
+	if err := group.Set(ctx, req.Key, req.Value, opts...); err != nil {
 		return nil, err
 	}
 
@@ -211,22 +362,260 @@ func (s *Server) Set(ctx context.Context, req *pb.Request) (*pb.ResponseForGet,
 
 // Delete 实现Cache服务的Delete方法
 func (s *Server) Delete(ctx context.Context, req *pb.Request) (*pb.ResponseForDelete, error) {
-	group := GetGroup(req.Group)
+	group := s.registry.Get(requestGroup(ctx, req.Group))
 	if group == nil {
 		return nil, fmt.Errorf("group %s not found", req.Group)
 	}
 
-	err := group.Delete(ctx, req.Key)
+	// 其他节点同步过来的删除作为副本只在本地执行，否则会再次同步回来，在节点间循环
+	fromPeer, err := s.fromPeer(ctx, group.name)
+	if err != nil {
+		return nil, err
+	}
+	opts := []WriteOption{withVersion(s.peerVersion(ctx, group.name, req.Version))}
+	if fromPeer {
+		opts = append(opts, asReplica())
+	}
+
+	err = group.Delete(ctx, req.Key, opts...)
 	return &pb.ResponseForDelete{Value: err == nil}, err
 }
 
-// loadTLSCredentials 加载TLS证书
-func loadTLSCredentials(certFile, keyFile string) (credentials.TransportCredentials, error) {
-	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+// fromPeer 判断写入是否是其他节点同步过来的副本：带有节点标记的请求必须来自可信的节点（见 trustedPeer），否则返回 PermissionDenied，
+// 不能把它当作客户端的写入处理，否则同步的写入会生成新的版本号再同步回去
+func (s *Server) fromPeer(ctx context.Context, group string) (bool, error) {
+	if !markedAsPeer(ctx) {
+		return false, nil
+	}
+	if !trustedPeer(ctx, s.opts.Auth != nil, s.opts.ACL, group) {
+		return false, status.Errorf(codes.PermissionDenied, "peer request to group %s requires %s permission", group, PermPeer)
+	}
+	return true, nil
+}
+
+// peerVersion 返回写入应沿用的版本号：只有节点间同步的请求沿用对端的版本号，其他调用方的写入返回 0，由本节点生成版本号
+// 启用认证时节点必须通过认证并拥有组的 PermPeer 权限；未启用认证时无法区分节点和客户端，
+// 带有节点标记的请求都会被信任，此时只依靠时钟漂移上限（见 hybridClock.observe）防止版本号被推到远超当前时间
+func (s *Server) peerVersion(ctx context.Context, group string, version uint64) uint64 {
+	if version == 0 || !trustedPeer(ctx, s.opts.Auth != nil, s.opts.ACL, group) {
+		return 0
+	}
+	return version
+}
+
+// Incr 实现Cache服务的Incr方法，在本节点上执行原子加减
+func (s *Server) Incr(ctx context.Context, req *pb.IncrRequest) (*pb.IncrResponse, error) {
+	group := s.registry.Get(requestGroup(ctx, req.Group))
+	if group == nil {
+		return nil, fmt.Errorf("group %s not found", req.Group)
+	}
+
+	// 原子操作已经路由到本节点，必须在本地执行
+	ctx = context.WithValue(ctx, "from_peer", true)
+
+	view, err := group.incr(ctx, req.Key, req.Delta)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	value, err := strconv.ParseInt(view.String(), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.IncrResponse{Value: value, Version: view.Version()}, nil
+}
+
+// SetIfAbsent 实现Cache服务的SetIfAbsent方法
+func (s *Server) SetIfAbsent(ctx context.Context, req *pb.Request) (*pb.CASResponse, error) {
+	group := s.registry.Get(requestGroup(ctx, req.Group))
+	if group == nil {
+		return nil, fmt.Errorf("group %s not found", req.Group)
+	}
+
+	ctx = context.WithValue(ctx, "from_peer", true)
+
+	set, version, err := group.setIfAbsent(ctx, req.Key, req.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CASResponse{Swapped: set, Version: version}, nil
+}
+
+// CompareAndSwap 实现Cache服务的CompareAndSwap方法
+func (s *Server) CompareAndSwap(ctx context.Context, req *pb.CASRequest) (*pb.CASResponse, error) {
+	group := s.registry.Get(requestGroup(ctx, req.Group))
+	if group == nil {
+		return nil, fmt.Errorf("group %s not found", req.Group)
+	}
+
+	ctx = context.WithValue(ctx, "from_peer", true)
+
+	swapped, version, err := group.compareAndSwap(ctx, req.Key, req.Value, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CASResponse{Swapped: swapped, Version: version}, nil
+}
+
+// Invalidate 实现Cache服务的Invalidate方法，按前缀或标签批量删除本节点的键
+func (s *Server) Invalidate(ctx context.Context, req *pb.InvalidateRequest) (*pb.InvalidateResponse, error) {
+	group := s.registry.Get(requestGroup(ctx, req.Group))
+	if group == nil {
+		return nil, fmt.Errorf("group %s not found", req.Group)
+	}
+
+	// 来自其他节点的广播只在本地执行，避免再次广播
+	if ctx.Value("from_peer") == nil {
+		ctx = context.WithValue(ctx, "from_peer", true)
+	}
+
+	var (
+		deleted int
+		err     error
+	)
+	switch {
+	case req.Prefix != "" && req.Tag != "":
+		return nil, fmt.Errorf("only one of prefix and tag can be set")
+	case req.Prefix != "":
+		deleted, err = group.DeletePrefix(ctx, req.Prefix)
+	default:
+		deleted, err = group.InvalidateTag(ctx, req.Tag)
+	}
 	if err != nil {
 		return nil, err
 	}
-	return credentials.NewTLS(&tls.Config{
-		Certificates: []tls.Certificate{cert},
-	}), nil
+
+	return &pb.InvalidateResponse{Deleted: int64(deleted)}, nil
+}
+
+// Watch 实现Cache服务的Watch方法，把本节点缓存中键的变化推送给调用方
+// 调用方接收太慢时以 Aborted 结束，组关闭时以 Unavailable 结束
+func (s *Server) Watch(req *pb.WatchRequest, stream pb.LCache_WatchServer) error {
+	group := s.registry.Get(requestGroup(stream.Context(), req.Group))
+	if group == nil {
+		return fmt.Errorf("group %s not found", req.Group)
+	}
+
+	w, err := group.Watch(stream.Context(), WithWatchKeys(req.Keys...), WithWatchPrefix(req.Prefix))
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	for ev := range w.Events() {
+		msg := &pb.WatchEvent{
+			Type:    pb.WatchEvent_Type(ev.Type),
+			Key:     ev.Key,
+			Version: ev.Version,
+		}
+		// 压缩、加密的值原样返回，由调用方解码
+		if req.WithValue && ev.Type != WatchDelete {
+			msg.Value = ev.view.b
+			msg.Codec = ev.view.codecName()
+			msg.Encrypted = ev.view.enc != nil
+		}
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+
+	switch err := w.Err(); {
+	case errors.Is(err, ErrSlowWatcher):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, ErrGroupClosed):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return nil
+	}
+}
+
+// maxScanCount Scan RPC 每页最多返回的键数
+const maxScanCount = 1000
+
+// Scan 实现Cache服务的Scan方法，分页列出本节点缓存中的键
+func (s *Server) Scan(ctx context.Context, req *pb.ScanRequest) (*pb.ScanResponse, error) {
+	group := s.registry.Get(requestGroup(ctx, req.Group))
+	if group == nil {
+		return nil, fmt.Errorf("group %s not found", req.Group)
+	}
+
+	count := int(req.Count)
+	if count > maxScanCount {
+		count = maxScanCount
+	}
+
+	keys, next, err := group.Scan(req.Cursor, req.Match, count)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ScanResponse{Keys: keys, Cursor: next}, nil
+}
+
+// saveSnapshots 将所有组的缓存写入快照目录，先写临时文件再重命名，避免留下不完整的快照
+func (s *Server) saveSnapshots() {
+	if err := os.MkdirAll(s.opts.SnapshotDir, 0o755); err != nil {
+		logrus.Errorf("Failed to create snapshot dir: %v", err)
+		return
+	}
+
+	for _, name := range s.registry.List() {
+		group := s.registry.Get(name)
+		if group == nil {
+			continue
+		}
+		if err := saveGroupSnapshot(group, snapshotPath(s.opts.SnapshotDir, name)); err != nil {
+			logrus.Errorf("Failed to snapshot group %s: %v", name, err)
+		}
+	}
+}
+
+// restoreSnapshots 为已创建的组恢复快照，没有快照文件的组保持为空
+func (s *Server) restoreSnapshots() {
+	for _, name := range s.registry.List() {
+		group := s.registry.Get(name)
+		if group == nil {
+			continue
+		}
+
+		f, err := os.Open(snapshotPath(s.opts.SnapshotDir, name))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				logrus.Errorf("Failed to open snapshot of group %s: %v", name, err)
+			}
+			continue
+		}
+		if err := group.Restore(f); err != nil {
+			logrus.Errorf("Failed to restore group %s: %v", name, err)
+		}
+		f.Close()
+	}
+}
+
+func saveGroupSnapshot(group *Group, path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := group.Snapshot(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// snapshotPath 返回组的快照文件路径，组名经过转义以避免路径穿越
+func snapshotPath(dir, group string) string {
+	return filepath.Join(dir, url.PathEscape(group)+".snapshot")
 }
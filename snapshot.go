@@ -0,0 +1,276 @@
+package LCache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 快照格式（流式，整数均为小端或 varint）：
+//
+//	头部：   magic "LCSNAP" | version(1) | createdAt(8, UnixNano) | len(group) uvarint | group
+//	条目：   recordEntry(1) | len(key) uvarint | key | len(value) uvarint | value | ttl varint(纳秒，0 表示永不过期) | crc32(4)
+//	结尾：   recordEnd(1) | count uvarint | crc32(4)
+//
+// 每条记录的 crc32 覆盖该记录 crc 之前的所有字节，结尾的 count 用于检测截断
+//
+// version 3 的 value 为 版本号(8) + 值的保存形式（见 ByteView.Encode），恢复时保留条目原来的版本号；
+// version 2 的 value 为保存形式（见 ByteView.appendStored），压缩、加密的值在快照中保持原样；
+// version 1 的 value 为原始字节。旧版本的快照恢复时仍然支持，条目使用新生成的版本号
+const (
+	snapshotMagic   = "LCSNAP"
+	snapshotVersion = 3
+
+	snapshotRecordEnd   = 0
+	snapshotRecordEntry = 1
+
+	snapshotMaxKeyLen   = 1 << 16
+	snapshotMaxValueLen = 1 << 30
+)
+
+// ErrInvalidSnapshot 快照格式错误或校验失败
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+// Snapshot 将组内所有未过期的缓存项以流式格式写入 w，包含每个条目的剩余 TTL
+func (g *Group) Snapshot(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	var header bytes.Buffer
+	header.WriteString(snapshotMagic)
+	header.WriteByte(snapshotVersion)
+	binary.Write(&header, binary.LittleEndian, time.Now().UnixNano())
+	writeSnapshotBytes(&header, []byte(g.name))
+	if _, err := bw.Write(header.Bytes()); err != nil {
+		return fmt.Errorf("failed to write snapshot header: %w", err)
+	}
+
+	var (
+		count uint64
+		err   error
+		rec   bytes.Buffer
+	)
+	g.mainCache.Range(func(key string, value ByteView, ttl time.Duration) bool {
+		rec.Reset()
+		rec.WriteByte(snapshotRecordEntry)
+		writeSnapshotBytes(&rec, []byte(key))
+		writeSnapshotBytes(&rec, value.Encode())
+		writeSnapshotVarint(&rec, int64(ttl))
+		binary.Write(&rec, binary.LittleEndian, crc32.ChecksumIEEE(rec.Bytes()))
+
+		if _, err = bw.Write(rec.Bytes()); err != nil {
+			return false
+		}
+		count++
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot entry: %w", err)
+	}
+
+	rec.Reset()
+	rec.WriteByte(snapshotRecordEnd)
+	writeSnapshotUvarint(&rec, count)
+	binary.Write(&rec, binary.LittleEndian, crc32.ChecksumIEEE(rec.Bytes()))
+	if _, err := bw.Write(rec.Bytes()); err != nil {
+		return fmt.Errorf("failed to write snapshot trailer: %w", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to flush snapshot: %w", err)
+	}
+
+	logrus.Infof("[LCache] snapshot of group [%s] written, %d entries", g.name, count)
+	return nil
+}
+
+// Restore 从 r 中读取快照并写入本地缓存，快照生成后已经过期的条目会被跳过
+// 条目保留快照中的版本号，与写入一样持有键锁并检查版本，本地已有更新的值或墓碑时跳过，不会覆盖并发写入的新值；
+// 恢复的条目只写入本地，不会同步到其他节点；条目边读边写，因此校验失败时可能已恢复了部分条目
+func (g *Group) Restore(r io.Reader) error {
+	if atomic.LoadInt32(&g.closed) == 1 {
+		return ErrGroupClosed
+	}
+
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(snapshotMagic)+1)
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return fmt.Errorf("%w: failed to read header: %v", ErrInvalidSnapshot, err)
+	}
+	if string(magic[:len(snapshotMagic)]) != snapshotMagic {
+		return fmt.Errorf("%w: bad magic", ErrInvalidSnapshot)
+	}
+	version := magic[len(snapshotMagic)]
+	if version < 1 || version > snapshotVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, version)
+	}
+
+	var createdAt int64
+	if err := binary.Read(br, binary.LittleEndian, &createdAt); err != nil {
+		return fmt.Errorf("%w: failed to read header: %v", ErrInvalidSnapshot, err)
+	}
+	name, err := readSnapshotBytes(br, snapshotMaxKeyLen)
+	if err != nil {
+		return fmt.Errorf("%w: failed to read header: %v", ErrInvalidSnapshot, err)
+	}
+	if string(name) != g.name {
+		logrus.Warnf("[LCache] restoring snapshot of group [%s] into group [%s]", name, g.name)
+	}
+
+	elapsed := time.Duration(time.Now().UnixNano() - createdAt)
+	var restored, skipped, stale uint64
+
+	for {
+		// 用 TeeReader 收集记录的原始字节以计算 crc
+		var rec bytes.Buffer
+		tr := &snapshotTeeReader{r: br, buf: &rec}
+
+		kind, err := tr.ReadByte()
+		if err != nil {
+			return fmt.Errorf("%w: truncated snapshot: %v", ErrInvalidSnapshot, err)
+		}
+
+		switch kind {
+		case snapshotRecordEntry:
+			key, err := readSnapshotBytes(tr, snapshotMaxKeyLen)
+			if err != nil {
+				return fmt.Errorf("%w: bad entry: %v", ErrInvalidSnapshot, err)
+			}
+			value, err := readSnapshotBytes(tr, snapshotMaxValueLen)
+			if err != nil {
+				return fmt.Errorf("%w: bad entry: %v", ErrInvalidSnapshot, err)
+			}
+			ttl, err := binary.ReadVarint(tr)
+			if err != nil {
+				return fmt.Errorf("%w: bad entry: %v", ErrInvalidSnapshot, err)
+			}
+			if err := verifySnapshotChecksum(br, rec.Bytes()); err != nil {
+				return err
+			}
+
+			var view ByteView
+			switch version {
+			case 1:
+				view, err = g.newView(value, g.clock.now())
+			case 2:
+				view, err = decodeStored(value, g.encryptor, g.clock.now())
+			default:
+				if len(value) < 8 {
+					return fmt.Errorf("%w: bad entry: value too short", ErrInvalidSnapshot)
+				}
+				view, err = decodeStored(value[8:], g.encryptor, binary.LittleEndian.Uint64(value))
+			}
+			if err != nil {
+				return fmt.Errorf("failed to restore key %s: %w", key, err)
+			}
+
+			var expireAt time.Time
+			if ttl > 0 {
+				remaining := time.Duration(ttl) - elapsed
+				if remaining <= 0 {
+					skipped++
+					continue
+				}
+				expireAt = time.Now().Add(remaining)
+			}
+			if !g.restoreIfNewer(string(key), view, expireAt) {
+				stale++
+				continue
+			}
+			restored++
+
+		case snapshotRecordEnd:
+			count, err := binary.ReadUvarint(tr)
+			if err != nil {
+				return fmt.Errorf("%w: bad trailer: %v", ErrInvalidSnapshot, err)
+			}
+			if err := verifySnapshotChecksum(br, rec.Bytes()); err != nil {
+				return err
+			}
+			if read := restored + skipped + stale; count != read {
+				return fmt.Errorf("%w: trailer reports %d entries, read %d", ErrInvalidSnapshot, count, read)
+			}
+
+			logrus.Infof("[LCache] restored group [%s] from snapshot, %d entries restored, %d expired and %d stale entries skipped",
+				g.name, restored, skipped, stale)
+			return nil
+
+		default:
+			return fmt.Errorf("%w: unknown record type %d", ErrInvalidSnapshot, kind)
+		}
+	}
+}
+
+// snapshotTeeReader 读取的同时把字节记录到 buf
+type snapshotTeeReader struct {
+	r   *bufio.Reader
+	buf *bytes.Buffer
+}
+
+func (t *snapshotTeeReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	t.buf.Write(p[:n])
+	return n, err
+}
+
+func (t *snapshotTeeReader) ReadByte() (byte, error) {
+	b, err := t.r.ReadByte()
+	if err == nil {
+		t.buf.WriteByte(b)
+	}
+	return b, err
+}
+
+func verifySnapshotChecksum(r io.Reader, rec []byte) error {
+	var sum uint32
+	if err := binary.Read(r, binary.LittleEndian, &sum); err != nil {
+		return fmt.Errorf("%w: failed to read checksum: %v", ErrInvalidSnapshot, err)
+	}
+	if sum != crc32.ChecksumIEEE(rec) {
+		return fmt.Errorf("%w: checksum mismatch", ErrInvalidSnapshot)
+	}
+	return nil
+}
+
+func writeSnapshotUvarint(buf *bytes.Buffer, v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	buf.Write(tmp[:binary.PutUvarint(tmp[:], v)])
+}
+
+func writeSnapshotVarint(buf *bytes.Buffer, v int64) {
+	var tmp [binary.MaxVarintLen64]byte
+	buf.Write(tmp[:binary.PutVarint(tmp[:], v)])
+}
+
+func writeSnapshotBytes(buf *bytes.Buffer, b []byte) {
+	writeSnapshotUvarint(buf, uint64(len(b)))
+	buf.Write(b)
+}
+
+// snapshotReader 同时支持按字节和按块读取
+type snapshotReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+func readSnapshotBytes(r snapshotReader, max uint64) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > max {
+		return nil, fmt.Errorf("length %d exceeds limit %d", n, max)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
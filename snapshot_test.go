@@ -0,0 +1,179 @@
+package LCache
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"testing"
+	"time"
+)
+
+type snapshotTestEntry struct {
+	key   string
+	value []byte
+	ttl   time.Duration
+}
+
+// buildSnapshot 按快照格式手工构造指定版本的快照，value 已是该版本的编码
+func buildSnapshot(version byte, createdAt time.Time, entries []snapshotTestEntry) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(snapshotMagic)
+	buf.WriteByte(version)
+	binary.Write(&buf, binary.LittleEndian, createdAt.UnixNano())
+	writeSnapshotBytes(&buf, []byte("snapshot"))
+
+	var rec bytes.Buffer
+	for _, e := range entries {
+		rec.Reset()
+		rec.WriteByte(snapshotRecordEntry)
+		writeSnapshotBytes(&rec, []byte(e.key))
+		writeSnapshotBytes(&rec, e.value)
+		writeSnapshotVarint(&rec, int64(e.ttl))
+		binary.Write(&rec, binary.LittleEndian, crc32.ChecksumIEEE(rec.Bytes()))
+		buf.Write(rec.Bytes())
+	}
+
+	rec.Reset()
+	rec.WriteByte(snapshotRecordEnd)
+	writeSnapshotUvarint(&rec, uint64(len(entries)))
+	binary.Write(&rec, binary.LittleEndian, crc32.ChecksumIEEE(rec.Bytes()))
+	buf.Write(rec.Bytes())
+	return buf.Bytes()
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := newTestGroup(t, nil, WithExpiration(time.Hour))
+	for _, key := range []string{"a", "b", "c"} {
+		if err := src.Set(ctx, key, []byte("value-"+key)); err != nil {
+			t.Fatalf("Set(%q): %v", key, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := src.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if v := buf.Bytes()[len(snapshotMagic)]; v != snapshotVersion {
+		t.Fatalf("snapshot version = %d, want %d", v, snapshotVersion)
+	}
+
+	dst := newTestGroup(t, nil)
+	if err := dst.Restore(&buf); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		want, _ := cachedValue(t, src, key)
+		got, ok := cachedValue(t, dst, key)
+		if !ok {
+			t.Fatalf("key %q not restored", key)
+		}
+		if got.String() != "value-"+key {
+			t.Errorf("key %q = %q, want %q", key, got.String(), "value-"+key)
+		}
+		if got.Version() != want.Version() {
+			t.Errorf("key %q version = %d, want %d", key, got.Version(), want.Version())
+		}
+	}
+
+	var ttl time.Duration
+	dst.mainCache.Range(func(key string, value ByteView, remaining time.Duration) bool {
+		ttl = remaining
+		return false
+	})
+	if ttl <= 0 || ttl > time.Hour {
+		t.Errorf("restored ttl = %v, want within (0, 1h]", ttl)
+	}
+}
+
+func TestRestoreOlderVersions(t *testing.T) {
+	stored := ByteView{b: []byte("stored")}.appendStored(nil)
+	// v3 的值为版本号加保存形式
+	versioned := append(binary.LittleEndian.AppendUint64(nil, 42), stored...)
+
+	tests := []struct {
+		name    string
+		version byte
+		value   []byte
+		want    string
+	}{
+		{"v1 raw bytes", 1, []byte("raw"), "raw"},
+		{"v2 stored form", 2, stored, "stored"},
+		{"v3 version and stored form", 3, versioned, "stored"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGroup(t, nil)
+			data := buildSnapshot(tt.version, time.Now(), []snapshotTestEntry{{key: "k", value: tt.value}})
+			if err := g.Restore(bytes.NewReader(data)); err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			got, ok := cachedValue(t, g, "k")
+			if !ok {
+				t.Fatal("key not restored")
+			}
+			if got.String() != tt.want {
+				t.Errorf("value = %q, want %q", got.String(), tt.want)
+			}
+			if got.Version() == 0 {
+				t.Error("restored entry has no version")
+			}
+			if tt.version == 3 && got.Version() != 42 {
+				t.Errorf("version = %d, want 42", got.Version())
+			}
+		})
+	}
+}
+
+func TestRestoreRejectsCorruptSnapshot(t *testing.T) {
+	valid := buildSnapshot(1, time.Now(), []snapshotTestEntry{{key: "k", value: []byte("payload")}})
+
+	corrupt := bytes.Clone(valid)
+	// 改动条目中的值，条目的 crc 不再匹配
+	corrupt[bytes.LastIndex(corrupt, []byte("payload"))] = 'x'
+
+	badTrailer := bytes.Clone(valid)
+	badTrailer[len(badTrailer)-1] ^= 0xff
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"entry checksum mismatch", corrupt},
+		{"trailer checksum mismatch", badTrailer},
+		{"truncated trailer", valid[:len(valid)-3]},
+		{"missing trailer", valid[:len(valid)-6]},
+		{"bad magic", append([]byte("XXSNAP"), valid[len(snapshotMagic):]...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGroup(t, nil)
+			if err := g.Restore(bytes.NewReader(tt.data)); !errors.Is(err, ErrInvalidSnapshot) {
+				t.Fatalf("Restore error = %v, want ErrInvalidSnapshot", err)
+			}
+		})
+	}
+}
+
+func TestRestoreSkipsExpiredEntries(t *testing.T) {
+	g := newTestGroup(t, nil)
+	data := buildSnapshot(1, time.Now().Add(-time.Minute), []snapshotTestEntry{
+		{key: "expired", value: []byte("v"), ttl: 30 * time.Second},
+		{key: "alive", value: []byte("v"), ttl: time.Hour},
+		{key: "forever", value: []byte("v")},
+	})
+	if err := g.Restore(bytes.NewReader(data)); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if _, ok := cachedValue(t, g, "expired"); ok {
+		t.Error("expired entry was restored")
+	}
+	for _, key := range []string{"alive", "forever"} {
+		if _, ok := cachedValue(t, g, key); !ok {
+			t.Errorf("key %q not restored", key)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package store
+
+import "container/list"
+
+// ARC 的两个常驻队列
+const (
+	arcT1 uint8 = iota // 只访问过一次的条目
+	arcT2              // 访问过至少两次的条目
+)
+
+// arcGhost 是幽灵队列中的条目，只记录键和大小
+type arcGhost struct {
+	key  string
+	size int64
+}
+
+// arcPolicy 实现自适应替换缓存（ARC）
+// 两个常驻队列 T1/T2 分别保存近期和高频条目，幽灵队列 B1/B2 记录从中淘汰的键；
+// 命中幽灵队列时调整 T1 的目标大小 target，使缓存在“近期性”和“频次”之间自适应，
+// 原论文以条目数计算，这里统一换算为字节数
+type arcPolicy struct {
+	capacity    int64
+	target      int64         // T1 的目标字节数（论文中的 p）
+	resident    [2]*list.List // T1、T2
+	residentLen [2]int64
+	ghosts      [2]*list.List // B1、B2
+	ghostLen    [2]int64
+	ghostIndex  map[string]*list.Element
+	ghostOf     map[string]uint8 // 键所在的幽灵队列
+	hitB2       bool             // 最近一次写入是否命中 B2，用于 REPLACE 的平局判断
+}
+
+// newARCCache 创建一个 ARC 缓存实例
+func newARCCache(opts Options) *policyStore {
+	return newPolicyStore(opts, newARCPolicy(opts.MaxBytes))
+}
+
+func newARCPolicy(maxBytes int64) *arcPolicy {
+	p := &arcPolicy{
+		capacity:   maxBytes,
+		ghostIndex: make(map[string]*list.Element),
+		ghostOf:    make(map[string]uint8),
+	}
+	for i := 0; i < 2; i++ {
+		p.resident[i] = list.New()
+		p.ghosts[i] = list.New()
+	}
+	return p
+}
+
+func (p *arcPolicy) add(e *policyEntry) {
+	p.hitB2 = false
+
+	if g, ok := p.ghostOf[e.key]; ok {
+		b1, b2 := p.ghostLen[arcT1], p.ghostLen[arcT2]
+		if g == arcT1 {
+			// 命中 B1：说明 T1 太小，增大目标值
+			p.target += e.size * maxInt64(ratio(b2, b1), 1)
+			if p.capacity > 0 && p.target > p.capacity {
+				p.target = p.capacity
+			}
+		} else {
+			// 命中 B2：说明 T2 太小，减小目标值
+			p.target -= e.size * maxInt64(ratio(b1, b2), 1)
+			if p.target < 0 {
+				p.target = 0
+			}
+			p.hitB2 = true
+		}
+		p.removeGhost(e.key)
+		p.push(e, arcT2)
+		return
+	}
+
+	p.push(e, arcT1)
+}
+
+func (p *arcPolicy) access(e *policyEntry) {
+	p.unlink(e)
+	p.push(e, arcT2)
+}
+
+func (p *arcPolicy) update(e *policyEntry, oldSize int64) {
+	p.residentLen[e.queue] += e.size - oldSize
+	p.access(e)
+}
+
+func (p *arcPolicy) remove(e *policyEntry) {
+	p.unlink(e)
+}
+
+func (p *arcPolicy) victim() *policyEntry {
+	t1, t2 := p.resident[arcT1].Len(), p.resident[arcT2].Len()
+	if t1 == 0 && t2 == 0 {
+		return nil
+	}
+
+	// REPLACE：T1 超过目标值时从 T1 淘汰，否则从 T2 淘汰
+	from := arcT2
+	if t1 > 0 && (t2 == 0 || p.residentLen[arcT1] > p.target || (p.hitB2 && p.residentLen[arcT1] == p.target)) {
+		from = arcT1
+	}
+
+	victim := p.resident[from].Back().Value.(*policyEntry)
+	p.unlink(victim)
+	p.pushGhost(victim.key, victim.size, from)
+	p.trimGhosts()
+	return victim
+}
+
+func (p *arcPolicy) reset() {
+	for i := 0; i < 2; i++ {
+		p.resident[i].Init()
+		p.ghosts[i].Init()
+		p.residentLen[i] = 0
+		p.ghostLen[i] = 0
+	}
+	p.ghostIndex = make(map[string]*list.Element)
+	p.ghostOf = make(map[string]uint8)
+	p.target = 0
+	p.hitB2 = false
+}
+
+// resize 修改容量，T1 的目标大小和幽灵队列随之收缩
+func (p *arcPolicy) resize(maxBytes int64) {
+	p.capacity = maxBytes
+	if p.capacity > 0 && p.target > p.capacity {
+		p.target = p.capacity
+	}
+	p.trimGhosts()
+}
+
+// trimGhosts 限制幽灵队列的总大小不超过缓存容量
+func (p *arcPolicy) trimGhosts() {
+	if p.capacity <= 0 {
+		return
+	}
+	for p.ghostLen[arcT1]+p.ghostLen[arcT2] > p.capacity {
+		from := arcT2
+		if p.residentLen[arcT1]+p.ghostLen[arcT1] > p.capacity || p.ghosts[arcT2].Len() == 0 {
+			from = arcT1
+		}
+		elem := p.ghosts[from].Back()
+		if elem == nil {
+			return
+		}
+		p.removeGhost(elem.Value.(*arcGhost).key)
+	}
+}
+
+func (p *arcPolicy) push(e *policyEntry, queue uint8) {
+	e.queue = queue
+	e.elem = p.resident[queue].PushFront(e)
+	p.residentLen[queue] += e.size
+}
+
+func (p *arcPolicy) unlink(e *policyEntry) {
+	p.resident[e.queue].Remove(e.elem)
+	p.residentLen[e.queue] -= e.size
+	e.elem = nil
+}
+
+func (p *arcPolicy) pushGhost(key string, size int64, queue uint8) {
+	p.ghostIndex[key] = p.ghosts[queue].PushFront(&arcGhost{key: key, size: size})
+	p.ghostOf[key] = queue
+	p.ghostLen[queue] += size
+}
+
+func (p *arcPolicy) removeGhost(key string) {
+	elem, ok := p.ghostIndex[key]
+	if !ok {
+		return
+	}
+	queue := p.ghostOf[key]
+	p.ghosts[queue].Remove(elem)
+	p.ghostLen[queue] -= elem.Value.(*arcGhost).size
+	delete(p.ghostIndex, key)
+	delete(p.ghostOf, key)
+}
+
+// ratio 计算 a/b，b 为 0 时返回 1
+func ratio(a, b int64) int64 {
+	if b == 0 {
+		return 1
+	}
+	return a / b
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,474 @@
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+// ErrValueNotBytes 序列化型存储只能保存实现了 BytesValue 的值
+var ErrValueNotBytes = errors.New("value does not implement store.BytesValue")
+
+// ErrEntryTooLarge 条目超过了单个分段的容量
+var ErrEntryTooLarge = errors.New("entry is larger than arena segment")
+
+// 条目头部布局（小端）：
+//
+//	hash(8) | expireAt(8) | keyLen(2) | flags(1) | 保留(1) | valLen(4)
+const (
+	arenaHeaderSize    = 24
+	arenaFlagDeleted   = 1 << 0
+	arenaMinSegment    = 1 << 10 // 单个分段的最小字节数
+	arenaDefaultMemory = 64 << 20
+	arenaMaxKeyLen     = 1<<16 - 1
+)
+
+// arenaHeader 条目头部
+type arenaHeader struct {
+	hash     uint64
+	expireAt int64
+	keyLen   uint16
+	flags    uint8
+	valLen   uint32
+}
+
+func (h *arenaHeader) size() uint64 {
+	return arenaHeaderSize + uint64(h.keyLen) + uint64(h.valLen)
+}
+
+// arenaStore 是基于预分配字节环形缓冲区的存储，参考 freecache / bigcache 的思路：
+// 条目按 头部 + 键 + 值 序列化写入分段的环形缓冲区，索引只保存 哈希 -> 偏移量，
+// 不含任何指针，GC 无需扫描缓存内容；空间不足时从环头开始按 FIFO 淘汰
+type arenaStore struct {
+	segments      []*arenaSegment
+	mask          uint64
+	seed          maphash.Seed
+	onEvicted     func(key string, value Value)
+	decode        func(b []byte) Value
+	cleanupTicker *time.Ticker
+	closeCh       chan struct{}
+	closeOnce     sync.Once
+}
+
+// arenaSegment 环形缓冲区分段，各分段独立加锁
+type arenaSegment struct {
+	mu    sync.Mutex
+	buf   []byte
+	head  uint64            // 最旧条目的绝对偏移量
+	tail  uint64            // 下一次写入的绝对偏移量，tail-head <= len(buf)
+	index map[uint64]uint64 // 键哈希 -> 条目的绝对偏移量
+	store *arenaStore
+}
+
+// newArenaCache 创建一个 arena 缓存实例
+func newArenaCache(opts Options) *arenaStore {
+	if opts.BucketCount == 0 {
+		opts.BucketCount = 16
+	}
+	if opts.CleanupInterval <= 0 {
+		opts.CleanupInterval = time.Minute
+	}
+	maxBytes := opts.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = arenaDefaultMemory
+	}
+
+	mask := uint64(maskOfNextPowOf2(opts.BucketCount))
+	segSize := maxBytes / int64(mask+1)
+	if segSize < arenaMinSegment {
+		segSize = arenaMinSegment
+	}
+
+	s := &arenaStore{
+		segments:      make([]*arenaSegment, mask+1),
+		mask:          mask,
+		seed:          maphash.MakeSeed(),
+		onEvicted:     opts.OnEvicted,
+		decode:        opts.DecodeValue,
+		cleanupTicker: time.NewTicker(opts.CleanupInterval),
+		closeCh:       make(chan struct{}),
+	}
+	for i := range s.segments {
+		s.segments[i] = &arenaSegment{
+			buf:   make([]byte, segSize),
+			index: make(map[uint64]uint64),
+			store: s,
+		}
+	}
+
+	go s.cleanupLoop()
+
+	return s
+}
+
+// Get 获取缓存项，如果存在且未过期则返回
+func (s *arenaStore) Get(key string) (Value, bool) {
+	hash := maphash.String(s.seed, key)
+	seg := s.segment(hash)
+
+	seg.mu.Lock()
+	defer seg.mu.Unlock()
+
+	off, h, ok := seg.lookup(hash, key)
+	if !ok {
+		return nil, false
+	}
+	if h.expireAt > 0 && time.Now().UnixNano() >= h.expireAt {
+		seg.remove(off, h)
+		return nil, false
+	}
+
+	val := make([]byte, h.valLen)
+	seg.read(off+arenaHeaderSize+uint64(h.keyLen), val)
+	return s.decodeValue(val), true
+}
+
+// Set 添加或更新缓存项
+func (s *arenaStore) Set(key string, value Value) error {
+	return s.SetWithExpiration(key, value, 0)
+}
+
+// SetWithExpiration 添加或更新缓存项，并设置过期时间
+func (s *arenaStore) SetWithExpiration(key string, value Value, expiration time.Duration) error {
+	if value == nil {
+		s.Delete(key)
+		return nil
+	}
+
+	bv, ok := value.(BytesValue)
+	if !ok {
+		return ErrValueNotBytes
+	}
+	if len(key) > arenaMaxKeyLen {
+		return ErrEntryTooLarge
+	}
+	val := encodeValue(bv)
+
+	h := arenaHeader{
+		hash:   maphash.String(s.seed, key),
+		keyLen: uint16(len(key)),
+		valLen: uint32(len(val)),
+	}
+	if expiration > 0 {
+		h.expireAt = time.Now().Add(expiration).UnixNano()
+	}
+
+	seg := s.segment(h.hash)
+	if h.size() > uint64(len(seg.buf)) {
+		return ErrEntryTooLarge
+	}
+
+	seg.mu.Lock()
+	defer seg.mu.Unlock()
+
+	// 旧条目原地标记删除，覆盖写入不触发淘汰回调
+	if off, ok := seg.index[h.hash]; ok {
+		old := seg.header(off)
+		if seg.keyEquals(off, &old, key) {
+			seg.markDeleted(off)
+			delete(seg.index, h.hash)
+		} else {
+			// 哈希冲突：被替换的条目视为淘汰
+			seg.remove(off, old)
+		}
+	}
+
+	for seg.tail+h.size()-seg.head > uint64(len(seg.buf)) {
+		seg.evictHead()
+	}
+
+	off := seg.tail
+	seg.writeHeader(off, &h)
+	seg.write(off+arenaHeaderSize, []byte(key))
+	seg.write(off+arenaHeaderSize+uint64(h.keyLen), val)
+	seg.tail += h.size()
+	seg.index[h.hash] = off
+
+	return nil
+}
+
+// Delete 从缓存中删除指定键的项
+func (s *arenaStore) Delete(key string) bool {
+	hash := maphash.String(s.seed, key)
+	seg := s.segment(hash)
+
+	seg.mu.Lock()
+	defer seg.mu.Unlock()
+
+	off, h, ok := seg.lookup(hash, key)
+	if !ok {
+		return false
+	}
+	seg.remove(off, h)
+	return true
+}
+
+// Clear 清空缓存
+func (s *arenaStore) Clear() {
+	for _, seg := range s.segments {
+		seg.mu.Lock()
+		if s.onEvicted != nil {
+			for _, off := range seg.index {
+				h := seg.header(off)
+				s.onEvicted(seg.entry(off, &h))
+			}
+		}
+		seg.index = make(map[uint64]uint64)
+		seg.head, seg.tail = 0, 0
+		seg.mu.Unlock()
+	}
+}
+
+// Len 返回缓存中的项数
+func (s *arenaStore) Len() int {
+	count := 0
+	for _, seg := range s.segments {
+		seg.mu.Lock()
+		count += len(seg.index)
+		seg.mu.Unlock()
+	}
+	return count
+}
+
+// Close 关闭缓存，停止清理协程
+func (s *arenaStore) Close() {
+	s.closeOnce.Do(func() {
+		s.cleanupTicker.Stop()
+		close(s.closeCh)
+	})
+}
+
+// Range 逐个分段遍历所有未过期的条目，每次只持有一个分段的锁
+func (s *arenaStore) Range(fn func(key string, value Value, ttl time.Duration) bool) {
+	for _, seg := range s.segments {
+		seg.mu.Lock()
+		entries := make([]rangeEntry, 0, len(seg.index))
+		for _, off := range seg.index {
+			h := seg.header(off)
+			key, value := seg.entry(off, &h)
+			entries = append(entries, rangeEntry{key: key, value: value, expireAt: h.expireAt})
+		}
+		seg.mu.Unlock()
+
+		if !rangeEntries(entries, time.Now().UnixNano(), fn) {
+			return
+		}
+	}
+}
+
+// Scan 分页返回匹配的键，逐个分段收集候选键，每次只持有一个分段的锁
+func (s *arenaStore) Scan(cursor, match string, count int) ([]string, string, error) {
+	filter, err := newScanFilter(cursor, match)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var keys []string
+	for _, seg := range s.segments {
+		seg.mu.Lock()
+		now := time.Now().UnixNano()
+		for _, off := range seg.index {
+			h := seg.header(off)
+			if h.expireAt > 0 && now >= h.expireAt {
+				continue
+			}
+			key := make([]byte, h.keyLen)
+			seg.read(off+arenaHeaderSize, key)
+			keys = append(keys, string(key))
+		}
+		seg.mu.Unlock()
+	}
+
+	keys, next := filter.page(keys, count)
+	return keys, next, nil
+}
+
+// UsedBytes 返回环形缓冲区中已占用的字节数（包括尚未回收的已删除条目）
+func (s *arenaStore) UsedBytes() int64 {
+	var used int64
+	for _, seg := range s.segments {
+		seg.mu.Lock()
+		used += int64(seg.tail - seg.head)
+		seg.mu.Unlock()
+	}
+	return used
+}
+
+// Evict 轮流从每个分段的环头回收条目（FIFO），直到环形缓冲区的占用至少减少 bytes 字节
+// 缓冲区是预分配的，回收只为之后的写入腾出空间，不会把内存还给运行时
+func (s *arenaStore) Evict(bytes int64) int64 {
+	var freed int64
+	for freed < bytes {
+		evicted := false
+		for _, seg := range s.segments {
+			if freed >= bytes {
+				break
+			}
+			seg.mu.Lock()
+			if seg.head < seg.tail {
+				before := seg.tail - seg.head
+				seg.evictHead()
+				freed += int64(before - (seg.tail - seg.head))
+				evicted = true
+			}
+			seg.mu.Unlock()
+		}
+		if !evicted {
+			break
+		}
+	}
+	return freed
+}
+
+func (s *arenaStore) segment(hash uint64) *arenaSegment {
+	return s.segments[hash&s.mask]
+}
+
+func (s *arenaStore) decodeValue(b []byte) Value {
+	if s.decode != nil {
+		return s.decode(b)
+	}
+	return RawBytes(b)
+}
+
+// SetCleanupInterval 调整清理过期条目的间隔，d <= 0 时不变
+func (s *arenaStore) SetCleanupInterval(d time.Duration) {
+	if d > 0 {
+		s.cleanupTicker.Reset(d)
+	}
+}
+
+// cleanupLoop 定期清理过期条目的协程
+func (s *arenaStore) cleanupLoop() {
+	for {
+		select {
+		case <-s.cleanupTicker.C:
+			for _, seg := range s.segments {
+				seg.mu.Lock()
+				seg.removeExpired()
+				seg.mu.Unlock()
+			}
+		case <-s.closeCh:
+			return
+		}
+	}
+}
+
+// 以下方法调用前必须持有分段锁
+
+// lookup 根据哈希和键查找有效条目
+func (seg *arenaSegment) lookup(hash uint64, key string) (uint64, arenaHeader, bool) {
+	off, ok := seg.index[hash]
+	if !ok {
+		return 0, arenaHeader{}, false
+	}
+	h := seg.header(off)
+	if !seg.keyEquals(off, &h, key) {
+		return 0, arenaHeader{}, false
+	}
+	return off, h, true
+}
+
+// remove 删除索引中的条目并触发淘汰回调
+func (seg *arenaSegment) remove(off uint64, h arenaHeader) {
+	if seg.store.onEvicted != nil {
+		seg.store.onEvicted(seg.entry(off, &h))
+	}
+	seg.markDeleted(off)
+	delete(seg.index, h.hash)
+}
+
+// evictHead 回收环头的条目，仍有效的条目会触发淘汰回调
+func (seg *arenaSegment) evictHead() {
+	h := seg.header(seg.head)
+	if h.flags&arenaFlagDeleted == 0 {
+		if off, ok := seg.index[h.hash]; ok && off == seg.head {
+			seg.remove(off, h)
+		}
+	}
+	seg.head += h.size()
+}
+
+// removeExpired 从环头到环尾扫描，删除已过期的条目
+func (seg *arenaSegment) removeExpired() {
+	now := time.Now().UnixNano()
+	for off := seg.head; off < seg.tail; {
+		h := seg.header(off)
+		if h.flags&arenaFlagDeleted == 0 && h.expireAt > 0 && now >= h.expireAt {
+			if idx, ok := seg.index[h.hash]; ok && idx == off {
+				seg.remove(off, h)
+			}
+		}
+		off += h.size()
+	}
+}
+
+// entry 读取条目的键和值
+func (seg *arenaSegment) entry(off uint64, h *arenaHeader) (string, Value) {
+	key := make([]byte, h.keyLen)
+	seg.read(off+arenaHeaderSize, key)
+	val := make([]byte, h.valLen)
+	seg.read(off+arenaHeaderSize+uint64(h.keyLen), val)
+	return string(key), seg.store.decodeValue(val)
+}
+
+func (seg *arenaSegment) keyEquals(off uint64, h *arenaHeader, key string) bool {
+	if int(h.keyLen) != len(key) {
+		return false
+	}
+	start := (off + arenaHeaderSize) % uint64(len(seg.buf))
+	end := start + uint64(h.keyLen)
+	if end <= uint64(len(seg.buf)) {
+		return string(seg.buf[start:end]) == key
+	}
+	// 键跨越了环尾
+	first := uint64(len(seg.buf)) - start
+	return string(seg.buf[start:]) == key[:first] && string(seg.buf[:end-uint64(len(seg.buf))]) == key[first:]
+}
+
+func (seg *arenaSegment) header(off uint64) arenaHeader {
+	var b [arenaHeaderSize]byte
+	seg.read(off, b[:])
+	return arenaHeader{
+		hash:     binary.LittleEndian.Uint64(b[0:8]),
+		expireAt: int64(binary.LittleEndian.Uint64(b[8:16])),
+		keyLen:   binary.LittleEndian.Uint16(b[16:18]),
+		flags:    b[18],
+		valLen:   binary.LittleEndian.Uint32(b[20:24]),
+	}
+}
+
+func (seg *arenaSegment) writeHeader(off uint64, h *arenaHeader) {
+	var b [arenaHeaderSize]byte
+	binary.LittleEndian.PutUint64(b[0:8], h.hash)
+	binary.LittleEndian.PutUint64(b[8:16], uint64(h.expireAt))
+	binary.LittleEndian.PutUint16(b[16:18], h.keyLen)
+	b[18] = h.flags
+	binary.LittleEndian.PutUint32(b[20:24], h.valLen)
+	seg.write(off, b[:])
+}
+
+func (seg *arenaSegment) markDeleted(off uint64) {
+	pos := (off + 18) % uint64(len(seg.buf))
+	seg.buf[pos] |= arenaFlagDeleted
+}
+
+// read 从绝对偏移量 off 处读取数据，处理环形回绕
+func (seg *arenaSegment) read(off uint64, dst []byte) {
+	pos := off % uint64(len(seg.buf))
+	n := copy(dst, seg.buf[pos:])
+	if n < len(dst) {
+		copy(dst[n:], seg.buf)
+	}
+}
+
+// write 向绝对偏移量 off 处写入数据，处理环形回绕
+func (seg *arenaSegment) write(off uint64, src []byte) {
+	pos := off % uint64(len(seg.buf))
+	n := copy(seg.buf[pos:], src)
+	if n < len(src) {
+		copy(seg.buf, src[n:])
+	}
+}
@@ -0,0 +1,347 @@
+package store
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrCorruptRecord 磁盘记录校验失败
+var ErrCorruptRecord = errors.New("corrupt disk record")
+
+// 磁盘记录布局（小端）：
+//
+//	crc32(4) | expireAt(8) | keyLen(4) | valLen(4) | key | value
+//
+// crc32 覆盖 crc 之后的所有字节
+const (
+	diskHeaderSize = 20
+
+	diskCompactTarget   = 0.8     // 压缩后有效数据占预算的比例上限
+	diskMinCompactBytes = 1 << 20 // 垃圾数据超过该值且超过有效数据时触发压缩
+)
+
+// diskEntry 索引中记录的磁盘条目位置
+type diskEntry struct {
+	offset   int64
+	size     int64 // 整条记录的字节数
+	expireAt int64 // 过期时间戳（纳秒），0 表示永不过期
+}
+
+// diskLog 追加写日志文件加内存索引实现的磁盘存储
+// 写入总是追加到文件末尾，旧记录成为垃圾；文件大小超过预算或垃圾过多时执行压缩，
+// 将有效记录重写到新文件。文件仅作为缓存使用，关闭时删除，不在重启后恢复
+type diskLog struct {
+	mu        sync.Mutex
+	dir       string
+	path      string
+	file      *os.File
+	size      int64 // 文件大小，即下一条记录的写入偏移
+	live      int64 // 有效记录的字节数
+	maxBytes  int64
+	index     map[string]diskEntry
+	onDropped func(key string, val []byte) // 因预算或过期被丢弃时调用
+}
+
+// openDiskLog 在 dir 下创建一个新的日志文件
+func openDiskLog(dir string, maxBytes int64, onDropped func(key string, val []byte)) (*diskLog, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create disk tier dir: %v", err)
+	}
+	f, err := os.CreateTemp(dir, "lcache-tier-*.log")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create disk tier file: %v", err)
+	}
+
+	return &diskLog{
+		dir:       dir,
+		path:      f.Name(),
+		file:      f,
+		maxBytes:  maxBytes,
+		index:     make(map[string]diskEntry),
+		onDropped: onDropped,
+	}, nil
+}
+
+// put 追加写入一条记录
+func (d *diskLog) put(key string, val []byte, expireAt int64) error {
+	size := int64(diskHeaderSize + len(key) + len(val))
+	if size > d.maxBytes {
+		return ErrEntryTooLarge
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, err := d.file.WriteAt(encodeDiskRecord(key, val, expireAt), d.size); err != nil {
+		return fmt.Errorf("failed to write disk record: %v", err)
+	}
+
+	if old, ok := d.index[key]; ok {
+		d.live -= old.size
+	}
+	d.index[key] = diskEntry{offset: d.size, size: size, expireAt: expireAt}
+	d.live += size
+	d.size += size
+
+	if d.size > d.maxBytes || (d.size-d.live > diskMinCompactBytes && d.size-d.live > d.live) {
+		if err := d.compact(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// get 读取记录，返回值和过期时间
+func (d *diskLog) get(key string) ([]byte, int64, bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	e, ok := d.index[key]
+	if !ok {
+		return nil, 0, false, nil
+	}
+
+	_, val, err := d.read(e)
+	if err != nil {
+		d.remove(key, e)
+		return nil, 0, false, err
+	}
+	return val, e.expireAt, true, nil
+}
+
+// take 读取并删除记录，用于将条目提升回内存层
+func (d *diskLog) take(key string) ([]byte, int64, bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	e, ok := d.index[key]
+	if !ok {
+		return nil, 0, false, nil
+	}
+	d.remove(key, e)
+
+	_, val, err := d.read(e)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return val, e.expireAt, true, nil
+}
+
+// delete 删除记录，返回被删除记录的值
+func (d *diskLog) delete(key string) ([]byte, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	e, ok := d.index[key]
+	if !ok {
+		return nil, false
+	}
+	d.remove(key, e)
+
+	_, val, err := d.read(e)
+	if err != nil {
+		return nil, true
+	}
+	return val, true
+}
+
+// clear 删除所有记录并截断文件，对每条有效记录调用 fn
+func (d *diskLog) clear(fn func(key string, val []byte)) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if fn != nil {
+		for key, e := range d.index {
+			if _, val, err := d.read(e); err == nil {
+				fn(key, val)
+			}
+		}
+	}
+
+	d.index = make(map[string]diskEntry)
+	d.live, d.size = 0, 0
+	return d.file.Truncate(0)
+}
+
+// keys 返回所有未过期记录的键
+func (d *diskLog) keys() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now().UnixNano()
+	keys := make([]string, 0, len(d.index))
+	for key, e := range d.index {
+		if e.expireAt > 0 && now >= e.expireAt {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// len 返回记录数
+func (d *diskLog) len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.index)
+}
+
+// usedBytes 返回文件大小
+func (d *diskLog) usedBytes() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.size
+}
+
+// removeExpired 删除所有已过期的记录
+func (d *diskLog) removeExpired() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now().UnixNano()
+	for key, e := range d.index {
+		if e.expireAt > 0 && now >= e.expireAt {
+			d.drop(key, e)
+		}
+	}
+}
+
+// close 关闭并删除日志文件
+func (d *diskLog) close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.file == nil {
+		return nil
+	}
+	err := d.file.Close()
+	os.Remove(d.path)
+	d.file = nil
+	return err
+}
+
+// 以下方法调用前必须持有锁
+
+func (d *diskLog) remove(key string, e diskEntry) {
+	delete(d.index, key)
+	d.live -= e.size
+}
+
+// drop 删除记录并触发 onDropped
+func (d *diskLog) drop(key string, e diskEntry) {
+	if d.onDropped != nil {
+		if _, val, err := d.read(e); err == nil {
+			d.onDropped(key, val)
+		}
+	}
+	d.remove(key, e)
+}
+
+func (d *diskLog) read(e diskEntry) (string, []byte, error) {
+	buf := make([]byte, e.size)
+	if _, err := d.file.ReadAt(buf, e.offset); err != nil {
+		return "", nil, fmt.Errorf("failed to read disk record: %v", err)
+	}
+	return decodeDiskRecord(buf)
+}
+
+// compact 清理过期记录，有效数据超出预算时按写入顺序丢弃最旧的记录，然后将剩余记录重写到新文件
+func (d *diskLog) compact() error {
+	now := time.Now().UnixNano()
+
+	entries := make([]string, 0, len(d.index))
+	for key, e := range d.index {
+		if e.expireAt > 0 && now >= e.expireAt {
+			d.drop(key, e)
+			continue
+		}
+		entries = append(entries, key)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return d.index[entries[i]].offset < d.index[entries[j]].offset
+	})
+
+	target := int64(float64(d.maxBytes) * diskCompactTarget)
+	for len(entries) > 0 && d.live > target {
+		d.drop(entries[0], d.index[entries[0]])
+		entries = entries[1:]
+	}
+
+	f, err := os.CreateTemp(d.dir, "lcache-tier-*.log")
+	if err != nil {
+		return fmt.Errorf("failed to create compaction file: %v", err)
+	}
+
+	w := bufio.NewWriter(f)
+	index := make(map[string]diskEntry, len(entries))
+	var offset int64
+	for _, key := range entries {
+		e := d.index[key]
+		buf := make([]byte, e.size)
+		if _, err := d.file.ReadAt(buf, e.offset); err != nil {
+			continue
+		}
+		if _, err := w.Write(buf); err != nil {
+			f.Close()
+			os.Remove(f.Name())
+			return fmt.Errorf("failed to write compaction file: %v", err)
+		}
+		index[key] = diskEntry{offset: offset, size: e.size, expireAt: e.expireAt}
+		offset += e.size
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to flush compaction file: %v", err)
+	}
+
+	d.file.Close()
+	os.Remove(d.path)
+	d.file, d.path = f, f.Name()
+	d.index, d.size, d.live = index, offset, offset
+	return nil
+}
+
+func encodeDiskRecord(key string, val []byte, expireAt int64) []byte {
+	buf := make([]byte, diskHeaderSize+len(key)+len(val))
+	binary.LittleEndian.PutUint64(buf[4:12], uint64(expireAt))
+	binary.LittleEndian.PutUint32(buf[12:16], uint32(len(key)))
+	binary.LittleEndian.PutUint32(buf[16:20], uint32(len(val)))
+	copy(buf[diskHeaderSize:], key)
+	copy(buf[diskHeaderSize+len(key):], val)
+	binary.LittleEndian.PutUint32(buf[0:4], crc32.ChecksumIEEE(buf[4:]))
+	return buf
+}
+
+func decodeDiskRecord(buf []byte) (string, []byte, error) {
+	if len(buf) < diskHeaderSize {
+		return "", nil, io.ErrUnexpectedEOF
+	}
+	if crc32.ChecksumIEEE(buf[4:]) != binary.LittleEndian.Uint32(buf[0:4]) {
+		return "", nil, ErrCorruptRecord
+	}
+	keyLen := int(binary.LittleEndian.Uint32(buf[12:16]))
+	valLen := int(binary.LittleEndian.Uint32(buf[16:20]))
+	if diskHeaderSize+keyLen+valLen != len(buf) {
+		return "", nil, ErrCorruptRecord
+	}
+	key := string(buf[diskHeaderSize : diskHeaderSize+keyLen])
+	return key, buf[diskHeaderSize+keyLen:], nil
+}
+
+// diskTierDir 返回磁盘层目录，未配置时使用系统临时目录
+func diskTierDir(dir string) string {
+	if dir == "" {
+		return filepath.Join(os.TempDir(), "lcache")
+	}
+	return dir
+}
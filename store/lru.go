@@ -169,6 +169,45 @@ func (c *lruCache) Len() int {
 	return c.list.Len()
 }
 
+// Range 遍历所有未过期的条目
+func (c *lruCache) Range(fn func(key string, value Value, ttl time.Duration) bool) {
+	c.mu.RLock()
+	entries := make([]rangeEntry, 0, len(c.items))
+	for elem := c.list.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*lruEntry)
+		var expireAt int64
+		if expTime, ok := c.expires[entry.key]; ok {
+			expireAt = expTime.UnixNano()
+		}
+		entries = append(entries, rangeEntry{key: entry.key, value: entry.value, expireAt: expireAt})
+	}
+	c.mu.RUnlock()
+
+	rangeEntries(entries, time.Now().UnixNano(), fn)
+}
+
+// Scan 分页返回匹配的键，锁内只复制未过期的键，匹配和排序在锁外进行
+func (c *lruCache) Scan(cursor, match string, count int) ([]string, string, error) {
+	filter, err := newScanFilter(cursor, match)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	c.mu.RLock()
+	keys := make([]string, 0, len(c.items))
+	for key := range c.items {
+		if expTime, ok := c.expires[key]; ok && !now.Before(expTime) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	c.mu.RUnlock()
+
+	keys, next := filter.page(keys, count)
+	return keys, next, nil
+}
+
 // removeElement 从缓存中删除元素，调用此方法前必须持有锁
 func (c *lruCache) removeElement(elem *list.Element) {
 	entry := elem.Value.(*lruEntry)
@@ -305,3 +344,28 @@ func (c *lruCache) SetMaxBytes(maxBytes int64) {
 		c.evict()
 	}
 }
+
+// Evict 从最久未使用的条目开始淘汰，直到至少释放 bytes 字节
+func (c *lruCache) Evict(bytes int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var freed int64
+	for freed < bytes && c.list.Len() > 0 {
+		before := c.usedBytes
+		c.removeElement(c.list.Front())
+		freed += before - c.usedBytes
+	}
+	return freed
+}
+
+// SetCleanupInterval 调整清理过期条目的间隔，d <= 0 时不变
+func (c *lruCache) SetCleanupInterval(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	c.mu.Lock()
+	c.cleanupInterval = d
+	c.mu.Unlock()
+	c.cleanupTicker.Reset(d)
+}
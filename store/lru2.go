@@ -1,7 +1,7 @@
 package store
 
 import (
-	"fmt"
+	"math"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -13,6 +13,7 @@ type lru2Store struct {
 	onEvicted   func(key string, value Value)
 	cleanupTick *time.Ticker
 	mask        int32
+	usedBytes   int64 // 所有有效条目的键和值的字节数之和，由各桶的 cache 维护
 }
 
 func newLRU2Cache(opts Options) *lru2Store {
@@ -41,6 +42,8 @@ func newLRU2Cache(opts Options) *lru2Store {
 	for i := range s.caches {
 		s.caches[i][0] = Create(opts.CapPerBucket)
 		s.caches[i][1] = Create(opts.Level2Cap)
+		s.caches[i][0].used = &s.usedBytes
+		s.caches[i][1].used = &s.usedBytes
 	}
 
 	if opts.CleanupInterval > 0 {
@@ -64,13 +67,11 @@ func (s *lru2Store) Get(key string) (Value, bool) {
 		if expireAt > 0 && currentTime >= expireAt {
 			// 项目已过期，删除它
 			s.delete(key, idx)
-			fmt.Println("找到项目已过期，删除它")
 			return nil, false
 		}
 
 		// 项目有效，将其移至二级缓存
 		s.caches[idx][1].put(key, n1.v, expireAt, s.onEvicted)
-		fmt.Println("项目有效，将其移至二级缓存")
 		return n1.v, true
 	}
 
@@ -80,7 +81,6 @@ func (s *lru2Store) Get(key string) (Value, bool) {
 		if n2.expireAt > 0 && currentTime >= n2.expireAt {
 			// 项目已过期，删除它
 			s.delete(key, idx)
-			fmt.Println("找到项目已过期，删除它")
 			return nil, false
 		}
 
@@ -91,12 +91,13 @@ func (s *lru2Store) Get(key string) (Value, bool) {
 }
 
 func (s *lru2Store) Set(key string, value Value) error {
-	return s.SetWithExpiration(key, value, 9999999999999999)
+	return s.SetWithExpiration(key, value, 0)
 }
 
 func (s *lru2Store) SetWithExpiration(key string, value Value, expiration time.Duration) error {
 	// 计算过期时间 - 确保单位一致
-	expireAt := int64(0)
+	// expireAt = 0 在节点中表示已删除，因此永不过期的项使用最大时间戳
+	expireAt := int64(math.MaxInt64)
 	if expiration > 0 {
 		// now() 返回纳秒时间戳，确保 expiration 也是纳秒单位
 		expireAt = Now() + int64(expiration.Nanoseconds())
@@ -175,6 +176,67 @@ func (s *lru2Store) Len() int {
 	return count
 }
 
+// Range 逐个桶遍历所有未过期的条目，每次只持有一个桶的锁
+func (s *lru2Store) Range(fn func(key string, value Value, ttl time.Duration) bool) {
+	for i := range s.caches {
+		var entries []rangeEntry
+		seen := make(map[string]struct{})
+
+		s.locks[i].Lock()
+		// 一级缓存中的值总是比二级缓存中的新
+		for level := 0; level < 2; level++ {
+			s.caches[i][level].walk(func(key string, value Value, expireAt int64) bool {
+				if _, ok := seen[key]; ok {
+					return true
+				}
+				seen[key] = struct{}{}
+				if expireAt == math.MaxInt64 {
+					expireAt = 0
+				}
+				entries = append(entries, rangeEntry{key: key, value: value, expireAt: expireAt})
+				return true
+			})
+		}
+		s.locks[i].Unlock()
+
+		if !rangeEntries(entries, Now(), fn) {
+			return
+		}
+	}
+}
+
+// Scan 分页返回匹配的键，逐个桶复制未过期的键，每次只持有一个桶的锁，匹配和排序在锁外进行
+func (s *lru2Store) Scan(cursor, match string, count int) ([]string, string, error) {
+	filter, err := newScanFilter(cursor, match)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var keys []string
+	for i := range s.caches {
+		seen := make(map[string]struct{})
+
+		s.locks[i].Lock()
+		now := Now()
+		for level := 0; level < 2; level++ {
+			s.caches[i][level].walk(func(key string, value Value, expireAt int64) bool {
+				if _, ok := seen[key]; ok {
+					return true
+				}
+				seen[key] = struct{}{}
+				if now < expireAt {
+					keys = append(keys, key)
+				}
+				return true
+			})
+		}
+		s.locks[i].Unlock()
+	}
+
+	keys, next := filter.page(keys, count)
+	return keys, next, nil
+}
+
 // Close 关闭缓存相关资源
 func (s *lru2Store) Close() {
 	if s.cleanupTick != nil {
@@ -182,6 +244,61 @@ func (s *lru2Store) Close() {
 	}
 }
 
+// SetCapacity 调整每个桶一级和二级缓存的容量（条目数），参数为 0 时对应的容量不变
+// 缩容时按最近使用顺序保留条目，多出的最久未使用的条目被淘汰并触发回调
+func (s *lru2Store) SetCapacity(capPerBucket, level2Cap uint16) {
+	for i := range s.caches {
+		s.locks[i].Lock()
+		if capPerBucket > 0 {
+			s.caches[i][0] = s.caches[i][0].resize(capPerBucket, s.onEvicted)
+		}
+		if level2Cap > 0 {
+			s.caches[i][1] = s.caches[i][1].resize(level2Cap, s.onEvicted)
+		}
+		s.locks[i].Unlock()
+	}
+}
+
+// UsedBytes 返回所有有效条目的键和值的字节数之和，已过期但尚未清理的条目也计算在内
+func (s *lru2Store) UsedBytes() int64 {
+	return atomic.LoadInt64(&s.usedBytes)
+}
+
+// Evict 轮流从每个桶中淘汰最久未使用的条目（先一级缓存，再二级缓存），直到至少释放 bytes 字节
+func (s *lru2Store) Evict(bytes int64) int64 {
+	var freed int64
+	for freed < bytes {
+		evicted := false
+		for i := range s.caches {
+			if freed >= bytes {
+				break
+			}
+			s.locks[i].Lock()
+			for level := 0; level < 2; level++ {
+				if nd, ok := s.caches[i][level].oldest(); ok {
+					key, size := nd.k, int64(len(nd.k)+nd.v.Len())
+					s.delete(key, int32(i))
+					freed += size
+					evicted = true
+					break
+				}
+			}
+			s.locks[i].Unlock()
+		}
+		if !evicted {
+			break
+		}
+	}
+	return freed
+}
+
+// SetCleanupInterval 调整清理过期条目的间隔，d <= 0 时不变
+func (s *lru2Store) SetCleanupInterval(d time.Duration) {
+	if d > 0 {
+		s.cleanupTick.Reset(d)
+	}
+}
+
 // 内部时钟，减少 time.Now() 调用造成的 GC 压力
 var clock, p, n = time.Now().UnixNano(), uint16(0), uint16(1)
 
@@ -237,6 +354,8 @@ type cache struct {
 	m    []node            // 预分配内存存储节点
 	hmap map[string]uint16 // 键到节点索引的映射
 	last uint16            // 最后一个节点元素的索引
+	size int64             // 有效条目的键和值的字节数之和
+	used *int64            // 所属存储的字节数计数器，为 nil 时不汇总
 }
 
 func Create(cap uint16) *cache {
@@ -251,13 +370,17 @@ func Create(cap uint16) *cache {
 // 向缓存中添加项，如果是新增返回 1，更新返回 0
 func (c *cache) put(key string, val Value, expireAt int64, onEvicted func(string, Value)) int {
 	if idx, ok := c.hmap[key]; ok {
+		c.release(&c.m[idx-1])
 		c.m[idx-1].v, c.m[idx-1].expireAt = val, expireAt
+		c.account(entrySize(key, val))
 		c.adjust(idx, p, n) // 刷新到链表头部
 		return 0
 	}
 
+	c.account(entrySize(key, val))
 	if c.last == uint16(cap(c.m)) {
 		tail := &c.m[c.dlnk[0][p]-1]
+		c.release(tail)
 		if onEvicted != nil && (*tail).expireAt > 0 {
 			onEvicted((*tail).k, (*tail).v)
 		}
@@ -300,6 +423,7 @@ func (c *cache) get(key string) (*node, int) {
 func (c *cache) del(key string) (*node, int, int64) {
 	if idx, ok := c.hmap[key]; ok && c.m[idx-1].expireAt > 0 {
 		e := c.m[idx-1].expireAt
+		c.release(&c.m[idx-1])
 		c.m[idx-1].expireAt = 0 // 标记为已删除
 		c.adjust(idx, n, p)     // 移动到链表尾部
 		return &c.m[idx-1], 1, e
@@ -317,6 +441,51 @@ func (c *cache) walk(walker func(key string, value Value, expireAt int64) bool)
 	}
 }
 
+// resize 返回容量为 cap 的新缓存，从最久未使用的条目开始依次放入，容量不足时淘汰最久未使用的条目
+func (c *cache) resize(cap uint16, onEvicted func(string, Value)) *cache {
+	if int(cap) == len(c.m) {
+		return c
+	}
+	nc := Create(cap)
+	nc.used = c.used
+	c.account(-c.size) // 条目重新放入 nc 时再计入
+	for idx := c.dlnk[0][p]; idx != 0; idx = c.dlnk[idx][p] {
+		if e := &c.m[idx-1]; e.expireAt > 0 {
+			nc.put(e.k, e.v, e.expireAt, onEvicted)
+		}
+	}
+	return nc
+}
+
+// account 记录有效条目字节数的变化，同时更新所属存储的计数器
+func (c *cache) account(delta int64) {
+	c.size += delta
+	if c.used != nil {
+		atomic.AddInt64(c.used, delta)
+	}
+}
+
+// release 从字节数中扣除仍然有效的节点，已删除的节点在删除时已经扣除
+func (c *cache) release(nd *node) {
+	if nd.expireAt > 0 {
+		c.account(-entrySize(nd.k, nd.v))
+	}
+}
+
+func entrySize(key string, val Value) int64 {
+	return int64(len(key) + val.Len())
+}
+
+// oldest 返回最久未使用的有效节点
+func (c *cache) oldest() (*node, bool) {
+	for idx := c.dlnk[0][p]; idx != 0; idx = c.dlnk[idx][p] {
+		if c.m[idx-1].expireAt > 0 {
+			return &c.m[idx-1], true
+		}
+	}
+	return nil, false
+}
+
 // 调整节点在链表中的位置
 // 当 f=0, t=1 时，移动到链表头部；否则移动到链表尾部
 func (c *cache) adjust(idx, f, t uint16) {
@@ -0,0 +1,294 @@
+package store
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// policyEntry 表示策略型存储（TinyLFU / ARC / S3-FIFO）中的一个条目
+type policyEntry struct {
+	key      string
+	value    Value
+	size     int64         // 条目占用的字节数：len(key) + value.Len()
+	expireAt int64         // 过期时间戳（纳秒），0 表示永不过期
+	elem     *list.Element // 条目在所属队列中的位置
+	queue    uint8         // 所属队列，由具体策略解释
+	freq     uint8         // 访问频次，由具体策略解释
+}
+
+// evictionPolicy 淘汰策略接口，所有方法都在 policyStore 持有锁时调用
+type evictionPolicy interface {
+	add(e *policyEntry)                   // 新条目写入
+	access(e *policyEntry)                // 条目被命中
+	update(e *policyEntry, oldSize int64) // 条目被覆盖写入，size 已更新为新值
+	remove(e *policyEntry)                // 条目被删除或过期，需要从策略结构中摘除
+	victim() *policyEntry                 // 选出下一个要淘汰的条目并从策略结构中摘除，没有可淘汰的条目时返回 nil
+	reset()                               // 清空策略状态
+	resize(maxBytes int64)                // 容量被修改，重新划分按容量计算的各区大小
+}
+
+// policyStore 是按字节容量淘汰的通用存储，淘汰顺序交给 evictionPolicy 决定
+type policyStore struct {
+	mu              sync.Mutex
+	items           map[string]*policyEntry
+	policy          evictionPolicy
+	maxBytes        int64 // 最大允许字节数，<= 0 表示不限制
+	usedBytes       int64 // 当前使用的字节数
+	onEvicted       func(key string, value Value)
+	cleanupInterval time.Duration
+	cleanupTicker   *time.Ticker
+	closeCh         chan struct{}
+	closeOnce       sync.Once
+}
+
+// newPolicyStore 创建一个使用指定淘汰策略的存储实例
+func newPolicyStore(opts Options, policy evictionPolicy) *policyStore {
+	cleanupInterval := opts.CleanupInterval
+	if cleanupInterval <= 0 {
+		cleanupInterval = time.Minute
+	}
+
+	s := &policyStore{
+		items:           make(map[string]*policyEntry),
+		policy:          policy,
+		maxBytes:        opts.MaxBytes,
+		onEvicted:       opts.OnEvicted,
+		cleanupInterval: cleanupInterval,
+		cleanupTicker:   time.NewTicker(cleanupInterval),
+		closeCh:         make(chan struct{}),
+	}
+
+	go s.cleanupLoop()
+
+	return s
+}
+
+// Get 获取缓存项，过期的条目会被立即移除
+func (s *policyStore) Get(key string) (Value, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	if e.expireAt > 0 && time.Now().UnixNano() >= e.expireAt {
+		s.removeEntry(e)
+		return nil, false
+	}
+
+	s.policy.access(e)
+	return e.value, true
+}
+
+// Set 添加或更新缓存项
+func (s *policyStore) Set(key string, value Value) error {
+	return s.SetWithExpiration(key, value, 0)
+}
+
+// SetWithExpiration 添加或更新缓存项，并设置过期时间
+func (s *policyStore) SetWithExpiration(key string, value Value, expiration time.Duration) error {
+	if value == nil {
+		s.Delete(key)
+		return nil
+	}
+
+	var expireAt int64
+	if expiration > 0 {
+		expireAt = time.Now().Add(expiration).UnixNano()
+	}
+	size := int64(len(key) + value.Len())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.items[key]; ok {
+		oldSize := e.size
+		e.value, e.size, e.expireAt = value, size, expireAt
+		s.usedBytes += size - oldSize
+		s.policy.update(e, oldSize)
+	} else {
+		e := &policyEntry{key: key, value: value, size: size, expireAt: expireAt}
+		s.items[key] = e
+		s.usedBytes += size
+		s.policy.add(e)
+	}
+
+	s.evict()
+	return nil
+}
+
+// Delete 从缓存中删除指定键的项
+func (s *policyStore) Delete(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.items[key]; ok {
+		s.removeEntry(e)
+		return true
+	}
+	return false
+}
+
+// Clear 清空缓存
+func (s *policyStore) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.onEvicted != nil {
+		for _, e := range s.items {
+			s.onEvicted(e.key, e.value)
+		}
+	}
+
+	s.items = make(map[string]*policyEntry)
+	s.usedBytes = 0
+	s.policy.reset()
+}
+
+// Len 返回缓存中的项数
+func (s *policyStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+// Close 关闭缓存，停止清理协程
+func (s *policyStore) Close() {
+	s.closeOnce.Do(func() {
+		s.cleanupTicker.Stop()
+		close(s.closeCh)
+	})
+}
+
+// Range 遍历所有未过期的条目
+func (s *policyStore) Range(fn func(key string, value Value, ttl time.Duration) bool) {
+	s.mu.Lock()
+	entries := make([]rangeEntry, 0, len(s.items))
+	for _, e := range s.items {
+		entries = append(entries, rangeEntry{key: e.key, value: e.value, expireAt: e.expireAt})
+	}
+	s.mu.Unlock()
+
+	rangeEntries(entries, time.Now().UnixNano(), fn)
+}
+
+// Scan 分页返回匹配的键，锁内只复制未过期的键，匹配和排序在锁外进行
+func (s *policyStore) Scan(cursor, match string, count int) ([]string, string, error) {
+	filter, err := newScanFilter(cursor, match)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now().UnixNano()
+	s.mu.Lock()
+	keys := make([]string, 0, len(s.items))
+	for key, e := range s.items {
+		if e.expireAt > 0 && now >= e.expireAt {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	s.mu.Unlock()
+
+	keys, next := filter.page(keys, count)
+	return keys, next, nil
+}
+
+// UsedBytes 返回当前使用的字节数
+func (s *policyStore) UsedBytes() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usedBytes
+}
+
+// SetMaxBytes 设置最大允许字节数并按策略淘汰多出的条目
+func (s *policyStore) SetMaxBytes(maxBytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxBytes = maxBytes
+	s.policy.resize(maxBytes)
+	s.evict()
+}
+
+// Evict 按策略淘汰条目，直到至少释放 bytes 字节
+func (s *policyStore) Evict(bytes int64) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var freed int64
+	for freed < bytes {
+		victim := s.policy.victim()
+		if victim == nil {
+			break
+		}
+		freed += victim.size
+		s.drop(victim)
+	}
+	return freed
+}
+
+// SetCleanupInterval 调整清理过期条目的间隔，d <= 0 时不变
+func (s *policyStore) SetCleanupInterval(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	s.mu.Lock()
+	s.cleanupInterval = d
+	s.mu.Unlock()
+	s.cleanupTicker.Reset(d)
+}
+
+// removeEntry 从策略结构和索引中删除条目，调用此方法前必须持有锁
+func (s *policyStore) removeEntry(e *policyEntry) {
+	s.policy.remove(e)
+	s.drop(e)
+}
+
+// drop 从索引中删除已脱离策略结构的条目并触发回调，调用此方法前必须持有锁
+func (s *policyStore) drop(e *policyEntry) {
+	delete(s.items, e.key)
+	s.usedBytes -= e.size
+
+	if s.onEvicted != nil {
+		s.onEvicted(e.key, e.value)
+	}
+}
+
+// evict 按策略淘汰条目直到满足容量限制，调用此方法前必须持有锁
+func (s *policyStore) evict() {
+	for s.maxBytes > 0 && s.usedBytes > s.maxBytes {
+		victim := s.policy.victim()
+		if victim == nil {
+			return
+		}
+		s.drop(victim)
+	}
+}
+
+// removeExpired 清理所有已过期的条目，调用此方法前必须持有锁
+func (s *policyStore) removeExpired() {
+	now := time.Now().UnixNano()
+	for _, e := range s.items {
+		if e.expireAt > 0 && now >= e.expireAt {
+			s.removeEntry(e)
+		}
+	}
+}
+
+// cleanupLoop 定期清理过期缓存的协程
+func (s *policyStore) cleanupLoop() {
+	for {
+		select {
+		case <-s.cleanupTicker.C:
+			s.mu.Lock()
+			s.removeExpired()
+			s.mu.Unlock()
+		case <-s.closeCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package store
+
+import "container/list"
+
+// S3-FIFO 的两个常驻队列
+const (
+	s3fifoSmall uint8 = iota
+	s3fifoMain
+)
+
+const (
+	s3fifoSmallRatio = 0.1 // 小队列占总容量的比例
+	s3fifoMaxFreq    = 3   // 访问频次的上限
+)
+
+// s3fifoPolicy 实现 S3-FIFO 淘汰策略
+// 新条目进入小 FIFO 队列，被淘汰时只有访问过多次的条目才会进入主 FIFO 队列，
+// 其余的键记入幽灵队列；幽灵队列中的键再次写入时直接进入主队列。
+// 主队列淘汰时访问过的条目会降低频次后重新入队（类似 CLOCK）
+type s3fifoPolicy struct {
+	queues     [2]*list.List // 小队列、主队列
+	bytes      [2]int64
+	smallMax   int64
+	ghost      *list.List // 幽灵队列，只保存键
+	ghostIndex map[string]*list.Element
+}
+
+// newS3FIFOCache 创建一个 S3-FIFO 缓存实例
+func newS3FIFOCache(opts Options) *policyStore {
+	return newPolicyStore(opts, newS3FIFOPolicy(opts.MaxBytes))
+}
+
+func newS3FIFOPolicy(maxBytes int64) *s3fifoPolicy {
+	p := &s3fifoPolicy{
+		smallMax:   int64(float64(maxBytes) * s3fifoSmallRatio),
+		ghost:      list.New(),
+		ghostIndex: make(map[string]*list.Element),
+	}
+	for i := range p.queues {
+		p.queues[i] = list.New()
+	}
+	return p
+}
+
+func (p *s3fifoPolicy) add(e *policyEntry) {
+	e.freq = 0
+	if elem, ok := p.ghostIndex[e.key]; ok {
+		p.ghost.Remove(elem)
+		delete(p.ghostIndex, e.key)
+		p.push(e, s3fifoMain)
+		return
+	}
+	p.push(e, s3fifoSmall)
+}
+
+func (p *s3fifoPolicy) access(e *policyEntry) {
+	if e.freq < s3fifoMaxFreq {
+		e.freq++
+	}
+}
+
+func (p *s3fifoPolicy) update(e *policyEntry, oldSize int64) {
+	p.bytes[e.queue] += e.size - oldSize
+	p.access(e)
+}
+
+func (p *s3fifoPolicy) remove(e *policyEntry) {
+	p.unlink(e)
+}
+
+func (p *s3fifoPolicy) victim() *policyEntry {
+	small, main := p.queues[s3fifoSmall], p.queues[s3fifoMain]
+
+	for small.Len() > 0 || main.Len() > 0 {
+		if small.Len() > 0 && (p.bytes[s3fifoSmall] > p.smallMax || main.Len() == 0) {
+			e := small.Back().Value.(*policyEntry)
+			p.unlink(e)
+			if e.freq > 1 {
+				// 在小队列中被多次访问，晋升到主队列
+				e.freq = 0
+				p.push(e, s3fifoMain)
+				continue
+			}
+			p.pushGhost(e.key)
+			return e
+		}
+
+		e := main.Back().Value.(*policyEntry)
+		if e.freq > 0 {
+			// 访问过的条目降低频次后重新入队
+			e.freq--
+			main.MoveToFront(e.elem)
+			continue
+		}
+		p.unlink(e)
+		return e
+	}
+
+	return nil
+}
+
+func (p *s3fifoPolicy) reset() {
+	for i := range p.queues {
+		p.queues[i].Init()
+		p.bytes[i] = 0
+	}
+	p.ghost.Init()
+	p.ghostIndex = make(map[string]*list.Element)
+}
+
+// resize 按新容量重新计算小队列的大小
+func (p *s3fifoPolicy) resize(maxBytes int64) {
+	p.smallMax = int64(float64(maxBytes) * s3fifoSmallRatio)
+}
+
+func (p *s3fifoPolicy) push(e *policyEntry, queue uint8) {
+	e.queue = queue
+	e.elem = p.queues[queue].PushFront(e)
+	p.bytes[queue] += e.size
+}
+
+func (p *s3fifoPolicy) unlink(e *policyEntry) {
+	p.queues[e.queue].Remove(e.elem)
+	p.bytes[e.queue] -= e.size
+	e.elem = nil
+}
+
+func (p *s3fifoPolicy) pushGhost(key string) {
+	p.ghostIndex[key] = p.ghost.PushFront(key)
+
+	// 幽灵队列最多记录与常驻条目数相同数量的键
+	limit := p.queues[s3fifoSmall].Len() + p.queues[s3fifoMain].Len()
+	for p.ghost.Len() > limit && p.ghost.Len() > 0 {
+		elem := p.ghost.Back()
+		p.ghost.Remove(elem)
+		delete(p.ghostIndex, elem.Value.(string))
+	}
+}
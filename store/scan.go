@@ -0,0 +1,231 @@
+package store
+
+import (
+	"container/heap"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ErrBadPattern Scan 的匹配模式格式错误
+var ErrBadPattern = errors.New("syntax error in scan pattern")
+
+// DefaultScanCount Scan 未指定 count 时每页返回的最大键数
+const DefaultScanCount = 10
+
+// scanFilter 描述一次 Scan 的游标和匹配模式
+// 游标是上一页返回的最后一个键，Scan 按键的字典序分页：每页返回大于游标且匹配模式的最小的 count 个键。
+// 这样即使两次调用之间发生并发写入，整个遍历期间一直存在的键也一定会被返回且只返回一次
+type scanFilter struct {
+	cursor string
+	match  string
+}
+
+func newScanFilter(cursor, match string) (scanFilter, error) {
+	if match == "*" {
+		match = ""
+	}
+	if match != "" && !validGlob(match) {
+		return scanFilter{}, ErrBadPattern
+	}
+	return scanFilter{cursor: cursor, match: match}, nil
+}
+
+// keep 判断键是否属于本页之后的候选范围
+func (f scanFilter) keep(key string) bool {
+	return key > f.cursor && (f.match == "" || matchGlob(f.match, key))
+}
+
+// page 过滤存储在锁内复制出的键并取出一页，匹配和选择都在锁外进行，存储只需要在锁内复制键
+func (f scanFilter) page(keys []string, count int) ([]string, string) {
+	kept := keys[:0]
+	for _, key := range keys {
+		if f.keep(key) {
+			kept = append(kept, key)
+		}
+	}
+	return scanPage(kept, count)
+}
+
+// scanPage 从候选键中取出字典序最小的 count 个，返回这一页和下一页的游标，没有更多的键时游标为空
+// 只维护 count 个键的最大堆，不对全部候选键排序，复杂度为 O(N log count)
+func scanPage(keys []string, count int) ([]string, string) {
+	if count <= 0 {
+		count = DefaultScanCount
+	}
+	if len(keys) <= count {
+		sort.Strings(keys)
+		return keys, ""
+	}
+
+	h := maxStringHeap(keys[:count:count])
+	heap.Init(&h)
+	for _, key := range keys[count:] {
+		if key < h[0] {
+			h[0] = key
+			heap.Fix(&h, 0)
+		}
+	}
+	sort.Strings(h)
+	return h, h[count-1]
+}
+
+// maxStringHeap 字典序最大的键在堆顶
+type maxStringHeap []string
+
+func (h maxStringHeap) Len() int           { return len(h) }
+func (h maxStringHeap) Less(i, j int) bool { return h[i] > h[j] }
+func (h maxStringHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *maxStringHeap) Push(x any)        { *h = append(*h, x.(string)) }
+func (h *maxStringHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// MatchPattern 判断 key 是否匹配 glob 模式，语义与 Redis 的 SCAN MATCH 相同：
+// * 匹配任意字符序列（包括 /），? 匹配单个字符，[abc]、[a-z]、[^a] 匹配字符集合，\ 转义下一个字符
+func MatchPattern(pattern, key string) (bool, error) {
+	if !validGlob(pattern) {
+		return false, ErrBadPattern
+	}
+	return matchGlob(pattern, key), nil
+}
+
+// matchGlob 匹配 glob 模式，调用前 pattern 必须已通过 validGlob 校验
+func matchGlob(pattern, key string) bool {
+	// 回溯点：最近一个 * 在模式和键中的位置
+	px, kx := 0, 0
+	starPx, starKx := -1, 0
+	for kx < len(key) {
+		if px < len(pattern) {
+			switch c := pattern[px]; c {
+			case '*':
+				starPx, starKx = px, kx
+				px++
+				continue
+			case '?':
+				px++
+				kx++
+				continue
+			case '[':
+				if n, ok := matchClass(pattern[px:], key[kx]); ok {
+					px += n
+					kx++
+					continue
+				}
+			case '\\':
+				if pattern[px+1] == key[kx] {
+					px += 2
+					kx++
+					continue
+				}
+			default:
+				if c == key[kx] {
+					px++
+					kx++
+					continue
+				}
+			}
+		}
+		if starPx < 0 {
+			return false
+		}
+		starKx++
+		px, kx = starPx+1, starKx
+	}
+	for px < len(pattern) && pattern[px] == '*' {
+		px++
+	}
+	return px == len(pattern)
+}
+
+// matchClass 匹配 [...] 字符集合，返回集合在模式中的长度
+func matchClass(pattern string, c byte) (int, bool) {
+	i := 1
+	negate := false
+	if i < len(pattern) && pattern[i] == '^' {
+		negate = true
+		i++
+	}
+	matched := false
+	for first := true; first || pattern[i] != ']'; first = false {
+		lo := pattern[i]
+		if lo == '\\' {
+			i++
+			lo = pattern[i]
+		}
+		i++
+		hi := lo
+		if pattern[i] == '-' && pattern[i+1] != ']' {
+			hi = pattern[i+1]
+			if hi == '\\' {
+				hi = pattern[i+2]
+				i++
+			}
+			i += 2
+		}
+		if lo <= c && c <= hi {
+			matched = true
+		}
+	}
+	return i + 1, matched != negate
+}
+
+// validGlob 检查模式中的转义和字符集合是否完整
+func validGlob(pattern string) bool {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			if i+1 >= len(pattern) {
+				return false
+			}
+			i++
+		case '[':
+			end := classEnd(pattern[i:])
+			if end < 0 {
+				return false
+			}
+			i += end
+		}
+	}
+	return true
+}
+
+// classEnd 返回字符集合结尾 ] 的下标，集合不完整时返回 -1
+func classEnd(pattern string) int {
+	i := 1
+	if i < len(pattern) && pattern[i] == '^' {
+		i++
+	}
+	for first := true; i < len(pattern); first = false {
+		switch pattern[i] {
+		case ']':
+			if !first {
+				return i
+			}
+		case '\\':
+			i++
+		case '-':
+			if i+1 < len(pattern) && pattern[i+1] == '\\' {
+				i += 2
+			}
+		}
+		i++
+	}
+	return -1
+}
+
+// EscapePattern 转义 s 中的 glob 元字符，使其在模式中按字面匹配，例如 EscapePattern(prefix)+"*" 匹配所有以 prefix 开头的键
+func EscapePattern(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
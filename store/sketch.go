@@ -0,0 +1,81 @@
+package store
+
+import "hash/maphash"
+
+// cmSketch 是一个 4 行的 Count-Min Sketch，用于估算键的访问频次
+// 每个计数器 4 位，最大值为 15；累计增量达到 resetAt 时所有计数器减半，使旧的热点逐渐冷却
+type cmSketch struct {
+	rows    [4][]uint64 // 每个 uint64 打包 16 个 4 位计数器
+	seeds   [4]maphash.Seed
+	mask    uint64 // 每行计数器数量减一
+	added   int    // 自上次衰减以来的增量次数
+	resetAt int    // 触发衰减的增量次数
+}
+
+// newCMSketch 创建一个至少包含 width 个计数器/行 的 sketch
+func newCMSketch(width int) *cmSketch {
+	n := uint64(16)
+	for n < uint64(width) {
+		n <<= 1
+	}
+
+	s := &cmSketch{
+		mask:    n - 1,
+		resetAt: int(n) * 10,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint64, n/16)
+		s.seeds[i] = maphash.MakeSeed()
+	}
+	return s
+}
+
+// increment 为键的所有计数器加一（饱和于 15）
+func (s *cmSketch) increment(key string) {
+	for i := range s.rows {
+		idx := maphash.String(s.seeds[i], key) & s.mask
+		word, shift := idx/16, (idx%16)*4
+		if (s.rows[i][word]>>shift)&0xf < 15 {
+			s.rows[i][word] += 1 << shift
+		}
+	}
+
+	s.added++
+	if s.added >= s.resetAt {
+		s.halve()
+	}
+}
+
+// estimate 返回键的估算频次，即所有行中的最小计数
+func (s *cmSketch) estimate(key string) uint8 {
+	min := uint8(15)
+	for i := range s.rows {
+		idx := maphash.String(s.seeds[i], key) & s.mask
+		word, shift := idx/16, (idx%16)*4
+		if v := uint8((s.rows[i][word] >> shift) & 0xf); v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+// halve 将所有计数器减半
+func (s *cmSketch) halve() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			// 每个 4 位计数器右移一位，并屏蔽掉从相邻计数器移入的最高位
+			s.rows[i][j] = (s.rows[i][j] >> 1) & 0x7777777777777777
+		}
+	}
+	s.added /= 2
+}
+
+// clear 将所有计数器清零
+func (s *cmSketch) clear() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] = 0
+		}
+	}
+	s.added = 0
+}
@@ -1,12 +1,46 @@
 package store
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
 
 // Value 缓存值接口
 type Value interface {
 	Len() int // 返回数据大小
 }
 
+// BytesValue 可以导出为字节序列的值
+// 序列化型存储（如 arena）只能保存实现了该接口的值，读取时再通过 Options.DecodeValue 还原
+type BytesValue interface {
+	Value
+	Bytes() []byte
+}
+
+// EncodedValue 自定义序列化格式的值，序列化型存储优先使用 Encode 而不是 Bytes，
+// 用于在数据之外一并保存元信息（如版本号），读取时由 Options.DecodeValue 负责解析
+type EncodedValue interface {
+	BytesValue
+	Encode() []byte
+}
+
+// encodeValue 返回值在序列化型存储中保存的字节
+func encodeValue(v BytesValue) []byte {
+	if e, ok := v.(EncodedValue); ok {
+		return e.Encode()
+	}
+	return v.Bytes()
+}
+
+// RawBytes 是未配置 DecodeValue 时序列化型存储返回的值
+type RawBytes []byte
+
+func (b RawBytes) Len() int      { return len(b) }
+func (b RawBytes) Bytes() []byte { return b }
+
 // Store 缓存接口
 type Store interface {
 	Get(key string) (Value, bool)
@@ -16,24 +50,67 @@ type Store interface {
 	Clear()
 	Len() int
 	Close()
+	// Range 遍历所有未过期的条目，fn 返回 false 时停止；ttl 为剩余存活时间，0 表示永不过期。
+	// 实现会先在锁内复制一批条目，再在锁外调用 fn，因此 fn 中可以安全地访问存储；
+	// 遍历期间的并发写入可能不会反映在结果中
+	Range(fn func(key string, value Value, ttl time.Duration) bool)
+	// Scan 按键的字典序分页返回未过期且匹配 glob 模式 match 的键（match 为空时匹配所有键），
+	// 每页最多 count 个；cursor 为空表示从头开始，返回的 next 为空表示遍历结束，否则作为下一次调用的 cursor。
+	// 实现只在收集候选键时短暂持有锁
+	Scan(cursor, match string, count int) (keys []string, next string, err error)
+}
+
+// Resizable 支持运行时调整字节容量的存储，缩容时立即按淘汰策略淘汰多出的条目
+type Resizable interface {
+	SetMaxBytes(maxBytes int64)
+}
+
+// CapacityResizable 按条目数限制容量、支持运行时调整的存储（如 lru2），参数为 0 时对应的容量不变
+type CapacityResizable interface {
+	SetCapacity(capPerBucket, level2Cap uint16)
+}
+
+// UsageReporter 可以报告当前占用字节数的存储
+type UsageReporter interface {
+	UsedBytes() int64
+}
+
+// Evictor 支持按需淘汰的存储，用于在内存压力下回收空间
+type Evictor interface {
+	// Evict 按淘汰策略淘汰条目直到至少释放 bytes 字节或没有可淘汰的条目，返回释放的字节数；被淘汰的条目触发 OnEvicted
+	Evict(bytes int64) int64
+}
+
+// CleanupScheduler 支持运行时调整过期清理间隔的存储
+type CleanupScheduler interface {
+	SetCleanupInterval(d time.Duration)
 }
 
 // CacheType 缓存类型
 type CacheType string
 
 const (
-	LRU  CacheType = "lru"
-	LRU2 CacheType = "lru2"
+	LRU     CacheType = "lru"
+	LRU2    CacheType = "lru2"
+	TinyLFU CacheType = "tinylfu" // Window-TinyLFU，基于 Count-Min Sketch 的准入策略
+	ARC     CacheType = "arc"     // 自适应替换缓存
+	S3FIFO  CacheType = "s3fifo"  // 基于三个 FIFO 队列的淘汰策略
+	Arena   CacheType = "arena"   // 基于预分配字节环形缓冲区的低 GC 开销存储
+	Tiered  CacheType = "tiered"  // 内存 + 磁盘两级存储
 )
 
 // Options 通用缓存配置选项
 type Options struct {
-	MaxBytes        int64  // 最大的缓存字节数（用于 lru）
-	BucketCount     uint16 // 缓存的桶数量（用于 lru-2）
+	MaxBytes        int64  // 最大的缓存字节数（用于 lru、tinylfu、arc、s3fifo、arena）
+	BucketCount     uint16 // 缓存的桶数量（用于 lru-2、arena）
 	CapPerBucket    uint16 // 每个桶的容量（用于 lru-2）
 	Level2Cap       uint16 // lru-2 中二级缓存的容量（用于 lru-2）
 	CleanupInterval time.Duration
 	OnEvicted       func(key string, value Value)
+	DecodeValue     func(b []byte) Value // 将字节还原为 Value（用于 arena 等序列化型存储），为空时返回 RawBytes
+	MemoryTier      CacheType            // 内存层的缓存类型（用于 tiered），默认 lru
+	DiskDir         string               // 磁盘层日志文件所在目录（用于 tiered），默认系统临时目录
+	DiskMaxBytes    int64                // 磁盘层的字节预算（用于 tiered），默认 1GB
 }
 
 func NewOptions() Options {
@@ -47,14 +124,96 @@ func NewOptions() Options {
 	}
 }
 
-// NewStore 创建缓存存储实例
-func NewStore(cacheType CacheType, opts Options) Store {
-	switch cacheType {
-	case LRU2:
-		return newLRU2Cache(opts)
-	case LRU:
-		return newLRUCache(opts)
-	default:
-		return newLRUCache(opts)
+// rangeEntry Range 在锁内复制出的条目
+type rangeEntry struct {
+	key      string
+	value    Value
+	expireAt int64 // 过期时间戳（纳秒），0 表示永不过期
+}
+
+// rangeEntries 在锁外依次回调条目，跳过已过期的条目；now 为与 expireAt 同一时钟的当前时间
+// 返回 false 表示 fn 要求停止遍历
+func rangeEntries(entries []rangeEntry, now int64, fn func(key string, value Value, ttl time.Duration) bool) bool {
+	for _, e := range entries {
+		var ttl time.Duration
+		if e.expireAt > 0 {
+			if now >= e.expireAt {
+				continue
+			}
+			ttl = time.Duration(e.expireAt - now)
+		}
+		if !fn(e.key, e.value, ttl) {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrUnknownCacheType 未注册的缓存类型错误
+var ErrUnknownCacheType = errors.New("unknown cache type")
+
+// Factory 根据配置创建存储实例的工厂函数
+type Factory func(opts Options) (Store, error)
+
+// 已注册的存储工厂，键为缓存类型
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[CacheType]Factory)
+)
+
+func init() {
+	Register(LRU, func(opts Options) (Store, error) { return newLRUCache(opts), nil })
+	Register(LRU2, func(opts Options) (Store, error) { return newLRU2Cache(opts), nil })
+	Register(TinyLFU, func(opts Options) (Store, error) { return newTinyLFUCache(opts), nil })
+	Register(ARC, func(opts Options) (Store, error) { return newARCCache(opts), nil })
+	Register(S3FIFO, func(opts Options) (Store, error) { return newS3FIFOCache(opts), nil })
+	Register(Arena, func(opts Options) (Store, error) { return newArenaCache(opts), nil })
+	Register(Tiered, func(opts Options) (Store, error) {
+		s, err := newTieredCache(opts)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+}
+
+// Register 注册一种缓存类型，之后可以通过 CacheOptions.CacheType 选择它
+// 通常在应用的 init 函数中调用；重复注册同一类型或 factory 为 nil 时 panic
+func Register(cacheType CacheType, factory Factory) {
+	if factory == nil {
+		panic("store: Register factory is nil")
+	}
+
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if _, exists := factories[cacheType]; exists {
+		panic(fmt.Sprintf("store: Register called twice for cache type %q", cacheType))
+	}
+	factories[cacheType] = factory
+}
+
+// CacheTypes 返回所有已注册的缓存类型（按名称排序）
+func CacheTypes() []CacheType {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	types := make([]CacheType, 0, len(factories))
+	for t := range factories {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// NewStore 创建缓存存储实例，未注册的缓存类型返回 ErrUnknownCacheType
+func NewStore(cacheType CacheType, opts Options) (Store, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[cacheType]
+	factoriesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCacheType, cacheType)
 	}
+	return factory(opts)
 }
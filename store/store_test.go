@@ -0,0 +1,18 @@
+package store_test
+
+import (
+	"LCache/store"
+	"LCache/store/storetest"
+	"testing"
+)
+
+// TestStores 对每种已注册的缓存类型运行一致性测试套件
+func TestStores(t *testing.T) {
+	for _, cacheType := range store.CacheTypes() {
+		t.Run(string(cacheType), func(t *testing.T) {
+			storetest.Run(t, func(opts store.Options) (store.Store, error) {
+				return store.NewStore(cacheType, opts)
+			})
+		})
+	}
+}
@@ -0,0 +1,413 @@
+// Package storetest 提供 store.Store 实现的一致性测试套件
+//
+// 自定义存储在自己的测试中调用 Run 即可验证是否满足 Store 接口的约定：
+//
+//	func TestMyStore(t *testing.T) {
+//		storetest.Run(t, func(opts store.Options) (store.Store, error) {
+//			return NewMyStore(opts), nil
+//		})
+//	}
+package storetest
+
+import (
+	"LCache/store"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Value 测试中写入存储的值
+type Value string
+
+// Len 实现 store.Value 接口
+func (v Value) Len() int { return len(v) }
+
+// Bytes 实现 store.BytesValue 接口，使序列化型存储也能运行测试套件
+func (v Value) Bytes() []byte { return []byte(v) }
+
+func decodeValue(b []byte) store.Value { return Value(b) }
+
+// 容量淘汰测试使用的配置：字节型存储约可容纳 10 个条目，计数型存储每层 8 个条目，磁盘层约可容纳 20 个条目
+const (
+	capacityValueSize = 100
+	capacityEntries   = 200
+)
+
+// Run 运行全部一致性测试，newStore 每次调用都应返回一个全新的存储实例
+func Run(t *testing.T, newStore store.Factory) {
+	t.Run("SetGet", func(t *testing.T) { testSetGet(t, newStore) })
+	t.Run("Overwrite", func(t *testing.T) { testOverwrite(t, newStore) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newStore) })
+	t.Run("TTL", func(t *testing.T) { testTTL(t, newStore) })
+	t.Run("EvictionCallback", func(t *testing.T) { testEvictionCallback(t, newStore) })
+	t.Run("CapacityEviction", func(t *testing.T) { testCapacityEviction(t, newStore) })
+	t.Run("Clear", func(t *testing.T) { testClear(t, newStore) })
+	t.Run("Len", func(t *testing.T) { testLen(t, newStore) })
+	t.Run("Range", func(t *testing.T) { testRange(t, newStore) })
+	t.Run("Scan", func(t *testing.T) { testScan(t, newStore) })
+	t.Run("Concurrency", func(t *testing.T) { testConcurrency(t, newStore) })
+}
+
+// defaultOptions 返回容量足够大、不会触发淘汰的配置
+func defaultOptions() store.Options {
+	opts := store.NewOptions()
+	opts.MaxBytes = 8 << 20
+	opts.BucketCount = 4
+	opts.CapPerBucket = 1024
+	opts.Level2Cap = 1024
+	opts.CleanupInterval = 50 * time.Millisecond
+	opts.DecodeValue = decodeValue
+	return opts
+}
+
+func open(t *testing.T, newStore store.Factory, opts store.Options) store.Store {
+	t.Helper()
+	if opts.DiskDir == "" {
+		opts.DiskDir = t.TempDir()
+	}
+	s, err := newStore(opts)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+func mustSet(t *testing.T, s store.Store, key string, value store.Value) {
+	t.Helper()
+	if err := s.Set(key, value); err != nil {
+		t.Fatalf("Set(%q) failed: %v", key, err)
+	}
+}
+
+func expectValue(t *testing.T, s store.Store, key string, want Value) {
+	t.Helper()
+	got, ok := s.Get(key)
+	if !ok {
+		t.Fatalf("Get(%q) missed, want %q", key, want)
+	}
+	if v, ok := got.(Value); !ok || v != want {
+		t.Fatalf("Get(%q) = %v, want %q", key, got, want)
+	}
+}
+
+func expectMiss(t *testing.T, s store.Store, key string) {
+	t.Helper()
+	if v, ok := s.Get(key); ok {
+		t.Fatalf("Get(%q) = %v, want miss", key, v)
+	}
+}
+
+func testSetGet(t *testing.T, newStore store.Factory) {
+	s := open(t, newStore, defaultOptions())
+
+	expectMiss(t, s, "missing")
+	mustSet(t, s, "k1", Value("v1"))
+	expectValue(t, s, "k1", "v1")
+
+	// 重复读取不应改变结果
+	expectValue(t, s, "k1", "v1")
+
+	if err := s.SetWithExpiration("k2", Value("v2"), 0); err != nil {
+		t.Fatalf("SetWithExpiration failed: %v", err)
+	}
+	expectValue(t, s, "k2", "v2")
+}
+
+func testOverwrite(t *testing.T, newStore store.Factory) {
+	s := open(t, newStore, defaultOptions())
+
+	mustSet(t, s, "k", Value("old"))
+	mustSet(t, s, "k", Value("new-value"))
+	expectValue(t, s, "k", "new-value")
+
+	if n := s.Len(); n != 1 {
+		t.Fatalf("Len() = %d after overwrite, want 1", n)
+	}
+}
+
+func testDelete(t *testing.T, newStore store.Factory) {
+	s := open(t, newStore, defaultOptions())
+
+	mustSet(t, s, "k", Value("v"))
+	if !s.Delete("k") {
+		t.Fatalf("Delete of existing key returned false")
+	}
+	expectMiss(t, s, "k")
+	if s.Delete("k") {
+		t.Fatalf("Delete of missing key returned true")
+	}
+}
+
+func testTTL(t *testing.T, newStore store.Factory) {
+	s := open(t, newStore, defaultOptions())
+
+	if err := s.SetWithExpiration("short", Value("v"), 150*time.Millisecond); err != nil {
+		t.Fatalf("SetWithExpiration failed: %v", err)
+	}
+	if err := s.SetWithExpiration("long", Value("v"), time.Hour); err != nil {
+		t.Fatalf("SetWithExpiration failed: %v", err)
+	}
+	mustSet(t, s, "forever", Value("v"))
+
+	expectValue(t, s, "short", "v")
+
+	time.Sleep(500 * time.Millisecond)
+
+	expectMiss(t, s, "short")
+	expectValue(t, s, "long", "v")
+	expectValue(t, s, "forever", "v")
+
+	// 过期项应被后台清理，不再计入 Len
+	if n := s.Len(); n != 2 {
+		t.Fatalf("Len() = %d after expiration, want 2", n)
+	}
+}
+
+func testEvictionCallback(t *testing.T, newStore store.Factory) {
+	var mu sync.Mutex
+	evicted := make(map[string]store.Value)
+
+	opts := defaultOptions()
+	opts.OnEvicted = func(key string, value store.Value) {
+		mu.Lock()
+		evicted[key] = value
+		mu.Unlock()
+	}
+	s := open(t, newStore, opts)
+
+	mustSet(t, s, "k", Value("v"))
+	s.Delete("k")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if v, ok := evicted["k"]; !ok || v != Value("v") {
+		t.Fatalf("OnEvicted not called with deleted entry, got %v", evicted)
+	}
+}
+
+func testCapacityEviction(t *testing.T, newStore store.Factory) {
+	var evictions int64
+
+	opts := defaultOptions()
+	opts.MaxBytes = 10 * capacityValueSize
+	opts.BucketCount = 1
+	opts.CapPerBucket = 8
+	opts.Level2Cap = 8
+	opts.DiskMaxBytes = 20 * capacityValueSize
+	opts.OnEvicted = func(key string, value store.Value) {
+		atomic.AddInt64(&evictions, 1)
+	}
+	s := open(t, newStore, opts)
+
+	value := Value(make([]byte, capacityValueSize))
+	for i := 0; i < capacityEntries; i++ {
+		mustSet(t, s, fmt.Sprintf("key-%03d", i), value)
+	}
+
+	n := s.Len()
+	if n >= capacityEntries {
+		t.Fatalf("Len() = %d, want fewer than %d entries after exceeding capacity", n, capacityEntries)
+	}
+	if got := atomic.LoadInt64(&evictions); got != int64(capacityEntries-n) {
+		t.Fatalf("OnEvicted called %d times, want %d (entries written - Len)", got, capacityEntries-n)
+	}
+}
+
+func testClear(t *testing.T, newStore store.Factory) {
+	var evictions int64
+
+	opts := defaultOptions()
+	opts.OnEvicted = func(key string, value store.Value) {
+		atomic.AddInt64(&evictions, 1)
+	}
+	s := open(t, newStore, opts)
+
+	for i := 0; i < 10; i++ {
+		mustSet(t, s, fmt.Sprintf("k%d", i), Value("v"))
+	}
+	s.Clear()
+
+	if n := s.Len(); n != 0 {
+		t.Fatalf("Len() = %d after Clear, want 0", n)
+	}
+	for i := 0; i < 10; i++ {
+		expectMiss(t, s, fmt.Sprintf("k%d", i))
+	}
+	if got := atomic.LoadInt64(&evictions); got != 10 {
+		t.Fatalf("OnEvicted called %d times during Clear, want 10", got)
+	}
+
+	// 清空后仍可继续使用
+	mustSet(t, s, "after", Value("v"))
+	expectValue(t, s, "after", "v")
+}
+
+func testLen(t *testing.T, newStore store.Factory) {
+	s := open(t, newStore, defaultOptions())
+
+	if n := s.Len(); n != 0 {
+		t.Fatalf("Len() = %d on empty store, want 0", n)
+	}
+	for i := 0; i < 50; i++ {
+		mustSet(t, s, fmt.Sprintf("k%d", i), Value("v"))
+	}
+	// 读取不应影响条目数
+	for i := 0; i < 50; i++ {
+		s.Get(fmt.Sprintf("k%d", i))
+	}
+	if n := s.Len(); n != 50 {
+		t.Fatalf("Len() = %d, want 50", n)
+	}
+	for i := 0; i < 20; i++ {
+		s.Delete(fmt.Sprintf("k%d", i))
+	}
+	if n := s.Len(); n != 30 {
+		t.Fatalf("Len() = %d after deletes, want 30", n)
+	}
+}
+
+func testRange(t *testing.T, newStore store.Factory) {
+	s := open(t, newStore, defaultOptions())
+
+	for i := 0; i < 20; i++ {
+		mustSet(t, s, fmt.Sprintf("k%d", i), Value(fmt.Sprintf("v%d", i)))
+	}
+	if err := s.SetWithExpiration("ttl", Value("v"), time.Hour); err != nil {
+		t.Fatalf("SetWithExpiration failed: %v", err)
+	}
+	if err := s.SetWithExpiration("expired", Value("v"), 100*time.Millisecond); err != nil {
+		t.Fatalf("SetWithExpiration failed: %v", err)
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	seen := make(map[string]time.Duration)
+	s.Range(func(key string, value store.Value, ttl time.Duration) bool {
+		if _, dup := seen[key]; dup {
+			t.Errorf("Range visited %q twice", key)
+		}
+		seen[key] = ttl
+		// 回调中访问存储不应死锁
+		s.Get(key)
+		return true
+	})
+
+	if len(seen) != 21 {
+		t.Fatalf("Range visited %d entries, want 21", len(seen))
+	}
+	if _, ok := seen["expired"]; ok {
+		t.Fatalf("Range visited expired entry")
+	}
+	if ttl := seen["ttl"]; ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("Range reported ttl %v for entry with 1h expiration", ttl)
+	}
+	if ttl := seen["k0"]; ttl != 0 {
+		t.Fatalf("Range reported ttl %v for entry without expiration, want 0", ttl)
+	}
+
+	visited := 0
+	s.Range(func(key string, value store.Value, ttl time.Duration) bool {
+		visited++
+		return visited < 5
+	})
+	if visited != 5 {
+		t.Fatalf("Range visited %d entries after fn returned false, want 5", visited)
+	}
+}
+
+func testScan(t *testing.T, newStore store.Factory) {
+	s := open(t, newStore, defaultOptions())
+
+	for i := 0; i < 25; i++ {
+		mustSet(t, s, fmt.Sprintf("user:%02d", i), Value("v"))
+	}
+	for i := 0; i < 5; i++ {
+		mustSet(t, s, fmt.Sprintf("order:%d", i), Value("v"))
+	}
+	if err := s.SetWithExpiration("user:expired", Value("v"), 100*time.Millisecond); err != nil {
+		t.Fatalf("SetWithExpiration failed: %v", err)
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	// 分页遍历，期间写入新键不应导致已有的键被漏掉或重复返回
+	var all []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatalf("Scan did not terminate")
+		}
+		keys, next, err := s.Scan(cursor, "user:*", 7)
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if len(keys) > 7 {
+			t.Fatalf("Scan returned %d keys, want at most 7", len(keys))
+		}
+		all = append(all, keys...)
+		mustSet(t, s, fmt.Sprintf("other:%d", pages), Value("v"))
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if len(all) != 25 {
+		t.Fatalf("Scan returned %d keys, want 25: %v", len(all), all)
+	}
+	for i, key := range all {
+		if want := fmt.Sprintf("user:%02d", i); key != want {
+			t.Fatalf("Scan returned %q at position %d, want %q", key, i, want)
+		}
+	}
+
+	keys, next, err := s.Scan("", "order:[0-2]", 0)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(keys) != 3 || next != "" {
+		t.Fatalf("Scan with class pattern returned %v (next %q), want 3 keys", keys, next)
+	}
+
+	if _, _, err := s.Scan("", "user:[", 10); !errors.Is(err, store.ErrBadPattern) {
+		t.Fatalf("Scan with malformed pattern returned %v, want ErrBadPattern", err)
+	}
+}
+
+func testConcurrency(t *testing.T, newStore store.Factory) {
+	s := open(t, newStore, defaultOptions())
+
+	const workers, ops = 8, 2000
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < ops; i++ {
+				key := fmt.Sprintf("k%d", (w*ops+i)%128)
+				switch i % 4 {
+				case 0:
+					_ = s.Set(key, Value(key))
+				case 1:
+					_ = s.SetWithExpiration(key, Value(key), time.Minute)
+				case 2:
+					if v, ok := s.Get(key); ok && v != Value(key) {
+						t.Errorf("Get(%q) = %v, want %q", key, v, key)
+					}
+				case 3:
+					s.Delete(key)
+				}
+				if i%500 == 0 {
+					s.Len()
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if n := s.Len(); n < 0 || n > 128 {
+		t.Fatalf("Len() = %d after concurrent access, want between 0 and 128", n)
+	}
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// TagIndex 维护 标签 -> 键 的倒排索引，用于按标签批量失效
+// 存储本身不感知标签，调用方在写入时通过 Set 记录键的标签，并在存储的 OnEvicted 回调中调用 Remove，
+// 这样被淘汰、过期或删除的键会及时从索引中清除
+type TagIndex struct {
+	mu     sync.Mutex
+	byTag  map[string]map[string]struct{} // 标签 -> 键集合
+	byKey  map[string][]string            // 键 -> 标签
+	tagged int64                          // 带标签的键数，为 0 时 Remove 无需加锁
+}
+
+// NewTagIndex 创建一个空的标签索引
+func NewTagIndex() *TagIndex {
+	return &TagIndex{
+		byTag: make(map[string]map[string]struct{}),
+		byKey: make(map[string][]string),
+	}
+}
+
+// Set 用 tags 替换键原有的标签，tags 为空时清除键的标签
+func (t *TagIndex) Set(key string, tags []string) {
+	if len(tags) == 0 && atomic.LoadInt64(&t.tagged) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.remove(key)
+	if len(tags) == 0 {
+		return
+	}
+
+	owned := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		keys, ok := t.byTag[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			t.byTag[tag] = keys
+		}
+		if _, dup := keys[key]; dup {
+			continue
+		}
+		keys[key] = struct{}{}
+		owned = append(owned, tag)
+	}
+	t.byKey[key] = owned
+	atomic.AddInt64(&t.tagged, 1)
+}
+
+// Remove 从索引中删除键
+func (t *TagIndex) Remove(key string) {
+	if atomic.LoadInt64(&t.tagged) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	t.remove(key)
+	t.mu.Unlock()
+}
+
+// Keys 返回带有指定标签的所有键
+func (t *TagIndex) Keys(tag string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keys := make([]string, 0, len(t.byTag[tag]))
+	for key := range t.byTag[tag] {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Tags 返回键的标签
+func (t *TagIndex) Tags(key string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.byKey[key]...)
+}
+
+// Len 返回带标签的键数
+func (t *TagIndex) Len() int {
+	return int(atomic.LoadInt64(&t.tagged))
+}
+
+// Reset 清空索引
+func (t *TagIndex) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.byTag = make(map[string]map[string]struct{})
+	t.byKey = make(map[string][]string)
+	atomic.StoreInt64(&t.tagged, 0)
+}
+
+// remove 删除键的所有标签，调用此方法前必须持有锁
+func (t *TagIndex) remove(key string) {
+	tags, ok := t.byKey[key]
+	if !ok {
+		return
+	}
+	for _, tag := range tags {
+		keys := t.byTag[tag]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(t.byTag, tag)
+		}
+	}
+	delete(t.byKey, key)
+	atomic.AddInt64(&t.tagged, -1)
+}
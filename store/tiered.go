@@ -0,0 +1,536 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidMemoryTier 内存层必须是原样保存 Value 的存储类型
+var ErrInvalidMemoryTier = errors.New("invalid memory tier cache type")
+
+const defaultDiskMaxBytes = 1 << 30 // 磁盘层默认预算 1GB
+
+const tieredKeyLocks = 64 // 键锁的分片数，必须是 2 的幂
+
+// tieredEntry 内存层中保存的条目，额外记录过期时间以便降级到磁盘层时保留 TTL
+type tieredEntry struct {
+	value    Value
+	expireAt int64 // 过期时间戳（纳秒），0 表示永不过期
+}
+
+func (e *tieredEntry) Len() int { return e.value.Len() }
+
+// tieredStore 内存 + 磁盘两级存储
+// 内存层（lru / lru2 等）因容量被淘汰的条目通过 OnEvicted 钩子进入降级队列，由后台协程写入磁盘层的追加日志，
+// 淘汰回调在内存层的锁内执行，不做磁盘 I/O；Get 在内存层未命中时查找降级队列和磁盘层，命中后将条目提升回内存层。
+// 提升、写入和删除同一个键时持有该键的分片锁，提升不会用旧值覆盖并发写入的新值
+type tieredStore struct {
+	memory    Store
+	disk      *diskLog
+	onEvicted func(key string, value Value)
+	decode    func(b []byte) Value
+
+	keyLocks [tieredKeyLocks]sync.Mutex
+
+	droppingMu sync.Mutex
+	dropping   map[string]int // 正在被显式删除的键，其淘汰回调不做降级
+	clearing   int32          // 正在清空，所有淘汰回调都不做降级
+
+	pendingMu sync.Mutex
+	pending   map[string]*tieredEntry // 等待写入磁盘层的条目
+	queue     []string                // 降级顺序，被提升、覆盖或删除的键在出队时跳过
+	demoteCh  chan struct{}           // 通知降级协程有新条目
+	demoteMu  sync.Mutex              // 串行化降级写入，出队到写入磁盘层之间条目不会在计数中消失
+
+	cleanupTicker *time.Ticker
+	closeCh       chan struct{}
+	closeOnce     sync.Once
+}
+
+// newTieredCache 创建一个两级存储实例
+func newTieredCache(opts Options) (*tieredStore, error) {
+	memoryType := opts.MemoryTier
+	if memoryType == "" {
+		memoryType = LRU
+	}
+	// 内存层的值是 *tieredEntry，不能是序列化型存储或嵌套的两级存储
+	if memoryType == Arena || memoryType == Tiered {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidMemoryTier, memoryType)
+	}
+
+	diskMaxBytes := opts.DiskMaxBytes
+	if diskMaxBytes <= 0 {
+		diskMaxBytes = defaultDiskMaxBytes
+	}
+	cleanupInterval := opts.CleanupInterval
+	if cleanupInterval <= 0 {
+		cleanupInterval = time.Minute
+	}
+
+	t := &tieredStore{
+		onEvicted:     opts.OnEvicted,
+		decode:        opts.DecodeValue,
+		dropping:      make(map[string]int),
+		pending:       make(map[string]*tieredEntry),
+		demoteCh:      make(chan struct{}, 1),
+		cleanupTicker: time.NewTicker(cleanupInterval),
+		closeCh:       make(chan struct{}),
+	}
+
+	disk, err := openDiskLog(diskTierDir(opts.DiskDir), diskMaxBytes, t.onDiskDropped)
+	if err != nil {
+		t.cleanupTicker.Stop()
+		return nil, err
+	}
+	t.disk = disk
+
+	memOpts := opts
+	memOpts.OnEvicted = t.demote
+	memory, err := NewStore(memoryType, memOpts)
+	if err != nil {
+		t.cleanupTicker.Stop()
+		disk.close()
+		return nil, err
+	}
+	t.memory = memory
+
+	go t.cleanupLoop()
+	go t.demoteLoop()
+
+	return t, nil
+}
+
+// Get 先查内存层，未命中时查降级队列和磁盘层并提升回内存层
+func (t *tieredStore) Get(key string) (Value, bool) {
+	if v, ok := t.memory.Get(key); ok {
+		e := v.(*tieredEntry)
+		if e.expireAt > 0 && time.Now().UnixNano() >= e.expireAt {
+			t.Delete(key)
+			return nil, false
+		}
+		return e.value, true
+	}
+
+	unlock := t.lockKey(key)
+	defer unlock()
+
+	// 等待键锁期间可能有并发的写入或提升
+	if v, ok := t.memory.Get(key); ok {
+		return v.(*tieredEntry).value, true
+	}
+
+	var value Value
+	var expireAt int64
+	if e, ok := t.takePending(key); ok {
+		value, expireAt = e.value, e.expireAt
+	} else {
+		val, exp, ok, err := t.disk.take(key)
+		if err != nil {
+			logrus.Warnf("[tiered] failed to read key %s from disk tier: %v", key, err)
+			return nil, false
+		}
+		if !ok {
+			return nil, false
+		}
+		value, expireAt = t.decodeValue(val), exp
+	}
+
+	if expireAt > 0 && time.Now().UnixNano() >= expireAt {
+		if t.onEvicted != nil {
+			t.onEvicted(key, value)
+		}
+		return nil, false
+	}
+
+	// 提升回内存层
+	if err := t.setMemory(key, value, expireAt); err != nil {
+		logrus.Warnf("[tiered] failed to promote key %s: %v", key, err)
+	}
+	return value, true
+}
+
+// Set 添加或更新缓存项
+func (t *tieredStore) Set(key string, value Value) error {
+	return t.SetWithExpiration(key, value, 0)
+}
+
+// SetWithExpiration 添加或更新缓存项，并设置过期时间
+func (t *tieredStore) SetWithExpiration(key string, value Value, expiration time.Duration) error {
+	if value == nil {
+		t.Delete(key)
+		return nil
+	}
+
+	var expireAt int64
+	if expiration > 0 {
+		expireAt = time.Now().Add(expiration).UnixNano()
+	}
+
+	unlock := t.lockKey(key)
+	defer unlock()
+
+	// 降级队列和磁盘层中的旧值已经过时
+	t.takePending(key)
+	t.disk.delete(key)
+	return t.setMemory(key, value, expireAt)
+}
+
+// Delete 从两级存储中删除指定键
+func (t *tieredStore) Delete(key string) bool {
+	unlock := t.lockKey(key)
+	defer unlock()
+
+	t.droppingMu.Lock()
+	t.dropping[key]++
+	t.droppingMu.Unlock()
+
+	deleted := t.memory.Delete(key)
+
+	t.droppingMu.Lock()
+	if t.dropping[key]--; t.dropping[key] == 0 {
+		delete(t.dropping, key)
+	}
+	t.droppingMu.Unlock()
+
+	if e, ok := t.takePending(key); ok {
+		if !deleted && t.onEvicted != nil {
+			t.onEvicted(key, e.value)
+		}
+		deleted = true
+	}
+	if val, ok := t.disk.delete(key); ok {
+		if !deleted && t.onEvicted != nil && val != nil {
+			t.onEvicted(key, t.decodeValue(val))
+		}
+		deleted = true
+	}
+	return deleted
+}
+
+// Clear 清空两级存储
+func (t *tieredStore) Clear() {
+	atomic.StoreInt32(&t.clearing, 1)
+	t.memory.Clear()
+	atomic.StoreInt32(&t.clearing, 0)
+
+	t.pendingMu.Lock()
+	pending := t.pending
+	t.pending = make(map[string]*tieredEntry)
+	t.queue = nil
+	t.pendingMu.Unlock()
+	if t.onEvicted != nil {
+		for key, e := range pending {
+			t.onEvicted(key, e.value)
+		}
+	}
+
+	var notify func(key string, val []byte)
+	if t.onEvicted != nil {
+		notify = func(key string, val []byte) {
+			t.onEvicted(key, t.decodeValue(val))
+		}
+	}
+	if err := t.disk.clear(notify); err != nil {
+		logrus.Warnf("[tiered] failed to clear disk tier: %v", err)
+	}
+}
+
+// Len 返回两级存储中的项数，计数前先写完降级队列，使磁盘层的预算生效
+func (t *tieredStore) Len() int {
+	for t.demoteNext() {
+	}
+
+	t.demoteMu.Lock()
+	defer t.demoteMu.Unlock()
+	t.pendingMu.Lock()
+	pending := len(t.pending)
+	t.pendingMu.Unlock()
+	return t.memory.Len() + pending + t.disk.len()
+}
+
+// Close 关闭内存层并删除磁盘层文件，降级队列中的条目被丢弃
+func (t *tieredStore) Close() {
+	t.closeOnce.Do(func() {
+		t.cleanupTicker.Stop()
+		close(t.closeCh)
+		t.memory.Close()
+
+		t.pendingMu.Lock()
+		t.pending = make(map[string]*tieredEntry)
+		t.queue = nil
+		t.pendingMu.Unlock()
+
+		if err := t.disk.close(); err != nil {
+			logrus.Warnf("[tiered] failed to close disk tier: %v", err)
+		}
+	})
+}
+
+// Range 先遍历内存层，再遍历磁盘层；磁盘层的记录逐条读取，不会长时间持有锁
+func (t *tieredStore) Range(fn func(key string, value Value, ttl time.Duration) bool) {
+	seen := make(map[string]struct{})
+	stopped := false
+	t.memory.Range(func(key string, v Value, ttl time.Duration) bool {
+		seen[key] = struct{}{}
+		if !fn(key, v.(*tieredEntry).value, ttl) {
+			stopped = true
+			return false
+		}
+		return true
+	})
+	if stopped {
+		return
+	}
+
+	var entries []rangeEntry
+	t.pendingMu.Lock()
+	for key, e := range t.pending {
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			entries = append(entries, rangeEntry{key: key, value: e.value, expireAt: e.expireAt})
+		}
+	}
+	t.pendingMu.Unlock()
+	if !rangeEntries(entries, time.Now().UnixNano(), fn) {
+		return
+	}
+
+	for _, key := range t.disk.keys() {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		val, expireAt, ok, err := t.disk.get(key)
+		if err != nil || !ok {
+			continue
+		}
+		entries := []rangeEntry{{key: key, value: t.decodeValue(val), expireAt: expireAt}}
+		if !rangeEntries(entries, time.Now().UnixNano(), fn) {
+			return
+		}
+	}
+}
+
+// Scan 合并内存层与磁盘层的候选键后分页，同一个键只返回一次
+func (t *tieredStore) Scan(cursor, match string, count int) ([]string, string, error) {
+	filter, err := newScanFilter(cursor, match)
+	if err != nil {
+		return nil, "", err
+	}
+
+	seen := make(map[string]struct{})
+	var keys []string
+	t.memory.Range(func(key string, _ Value, _ time.Duration) bool {
+		seen[key] = struct{}{}
+		if filter.keep(key) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	t.pendingMu.Lock()
+	for key := range t.pending {
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			if filter.keep(key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	t.pendingMu.Unlock()
+	for _, key := range t.disk.keys() {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		if filter.keep(key) {
+			keys = append(keys, key)
+		}
+	}
+
+	keys, next := scanPage(keys, count)
+	return keys, next, nil
+}
+
+// DiskBytes 返回磁盘层文件的字节数
+func (t *tieredStore) DiskBytes() int64 {
+	return t.disk.usedBytes()
+}
+
+// setMemory 写入内存层，内存层也设置相同的 TTL 以便过期条目被及时清理
+func (t *tieredStore) setMemory(key string, value Value, expireAt int64) error {
+	e := &tieredEntry{value: value, expireAt: expireAt}
+	if expireAt == 0 {
+		return t.memory.Set(key, e)
+	}
+
+	ttl := time.Duration(expireAt - time.Now().UnixNano())
+	if ttl <= 0 {
+		return nil
+	}
+	return t.memory.SetWithExpiration(key, e, ttl)
+}
+
+// demote 是内存层的淘汰回调：容量淘汰的条目进入降级队列，删除、清空和过期的条目直接丢弃
+// 回调在内存层的锁内执行，只入队，磁盘写入由 demoteLoop 完成
+func (t *tieredStore) demote(key string, v Value) {
+	e := v.(*tieredEntry)
+
+	if atomic.LoadInt32(&t.clearing) == 1 || t.isDropping(key) ||
+		(e.expireAt > 0 && time.Now().UnixNano() >= e.expireAt) {
+		if t.onEvicted != nil {
+			t.onEvicted(key, e.value)
+		}
+		return
+	}
+
+	if _, ok := e.value.(BytesValue); !ok {
+		if t.onEvicted != nil {
+			t.onEvicted(key, e.value)
+		}
+		return
+	}
+
+	t.pendingMu.Lock()
+	t.pending[key] = e
+	t.queue = append(t.queue, key)
+	t.pendingMu.Unlock()
+
+	select {
+	case t.demoteCh <- struct{}{}:
+	default:
+	}
+}
+
+// demoteLoop 把降级队列中的条目依次写入磁盘层
+func (t *tieredStore) demoteLoop() {
+	for {
+		select {
+		case <-t.demoteCh:
+			for t.demoteNext() {
+			}
+		case <-t.closeCh:
+			return
+		}
+	}
+}
+
+// demoteNext 写入降级队列中的下一个条目，队列为空时返回 false
+func (t *tieredStore) demoteNext() bool {
+	t.demoteMu.Lock()
+	defer t.demoteMu.Unlock()
+
+	t.pendingMu.Lock()
+	if len(t.queue) == 0 {
+		t.pendingMu.Unlock()
+		return false
+	}
+	key := t.queue[0]
+	t.queue = t.queue[1:]
+	t.pendingMu.Unlock()
+
+	// 持有键锁时出队，与提升、写入和删除互斥；条目已被取走时跳过
+	unlock := t.lockKey(key)
+	defer unlock()
+
+	e, ok := t.takePending(key)
+	if !ok {
+		return true
+	}
+	if e.expireAt > 0 && time.Now().UnixNano() >= e.expireAt {
+		if t.onEvicted != nil {
+			t.onEvicted(key, e.value)
+		}
+		return true
+	}
+	if err := t.disk.put(key, encodeValue(e.value.(BytesValue)), e.expireAt); err != nil {
+		logrus.Warnf("[tiered] failed to demote key %s to disk tier: %v", key, err)
+		if t.onEvicted != nil {
+			t.onEvicted(key, e.value)
+		}
+	}
+	return true
+}
+
+// takePending 从降级队列中取走键的条目
+func (t *tieredStore) takePending(key string) (*tieredEntry, bool) {
+	t.pendingMu.Lock()
+	defer t.pendingMu.Unlock()
+
+	e, ok := t.pending[key]
+	if ok {
+		delete(t.pending, key)
+		if len(t.pending) == 0 {
+			t.queue = nil
+		}
+	}
+	return e, ok
+}
+
+// lockKey 锁定键所在的分片，返回解锁函数
+func (t *tieredStore) lockKey(key string) func() {
+	mu := &t.keyLocks[uint32(hashBKRD(key))&(tieredKeyLocks-1)]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// onDiskDropped 磁盘层因预算或过期丢弃条目时，条目彻底离开缓存
+func (t *tieredStore) onDiskDropped(key string, val []byte) {
+	if t.onEvicted != nil {
+		t.onEvicted(key, t.decodeValue(val))
+	}
+}
+
+func (t *tieredStore) isDropping(key string) bool {
+	t.droppingMu.Lock()
+	defer t.droppingMu.Unlock()
+	return t.dropping[key] > 0
+}
+
+func (t *tieredStore) decodeValue(b []byte) Value {
+	if t.decode != nil {
+		return t.decode(b)
+	}
+	return RawBytes(b)
+}
+
+// UsedBytes 返回内存层占用的字节数，内存层不支持统计时返回 0
+func (t *tieredStore) UsedBytes() int64 {
+	if r, ok := t.memory.(UsageReporter); ok {
+		return r.UsedBytes()
+	}
+	return 0
+}
+
+// Evict 从内存层淘汰条目，被淘汰的条目降级到磁盘层
+func (t *tieredStore) Evict(bytes int64) int64 {
+	if e, ok := t.memory.(Evictor); ok {
+		return e.Evict(bytes)
+	}
+	return 0
+}
+
+// SetCleanupInterval 调整两级存储清理过期条目的间隔，d <= 0 时不变
+func (t *tieredStore) SetCleanupInterval(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	t.cleanupTicker.Reset(d)
+	if s, ok := t.memory.(CleanupScheduler); ok {
+		s.SetCleanupInterval(d)
+	}
+}
+
+// cleanupLoop 定期清理磁盘层中过期的条目，内存层由其自身的清理协程负责
+func (t *tieredStore) cleanupLoop() {
+	for {
+		select {
+		case <-t.cleanupTicker.C:
+			t.disk.removeExpired()
+		case <-t.closeCh:
+			return
+		}
+	}
+}
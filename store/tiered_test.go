@@ -0,0 +1,184 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestTiered 创建内存层约可容纳 9 个 100 字节条目的两级存储
+func newTestTiered(t *testing.T, diskMaxBytes int64, onEvicted func(string, Value)) *tieredStore {
+	t.Helper()
+	opts := NewOptions()
+	opts.MaxBytes = 1000
+	opts.MemoryTier = LRU
+	opts.DiskDir = t.TempDir()
+	opts.DiskMaxBytes = diskMaxBytes
+	opts.OnEvicted = onEvicted
+	s, err := newTieredCache(opts)
+	if err != nil {
+		t.Fatalf("failed to create tiered store: %v", err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+func testValue(i int) RawBytes {
+	return RawBytes(fmt.Sprintf("%-100d", i))
+}
+
+// waitDemoted 等待降级队列中的条目全部写入磁盘层
+func waitDemoted(t *testing.T, s *tieredStore) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		s.pendingMu.Lock()
+		n := len(s.pending)
+		s.pendingMu.Unlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("demotion queue was not drained")
+}
+
+func TestTieredDemoteAndPromote(t *testing.T) {
+	s := newTestTiered(t, 1<<20, nil)
+
+	for i := 0; i < 30; i++ {
+		if err := s.Set(fmt.Sprint("k", i), testValue(i)); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	waitDemoted(t, s)
+
+	if s.disk.len() == 0 {
+		t.Fatal("no entries were demoted to the disk tier")
+	}
+	if got := s.memory.Len() + s.disk.len(); got != 30 {
+		t.Fatalf("memory + disk entries = %d, want 30", got)
+	}
+
+	if _, ok, _ := s.diskEntry("k0"); !ok {
+		t.Fatal("k0 should have been demoted")
+	}
+	v, ok := s.Get("k0")
+	if !ok || string(v.(RawBytes)) != string(testValue(0)) {
+		t.Fatalf("Get(k0) = %v, %v", v, ok)
+	}
+	if _, ok := s.memory.Get("k0"); !ok {
+		t.Fatal("k0 was not promoted to the memory tier")
+	}
+	if _, ok, _ := s.diskEntry("k0"); ok {
+		t.Fatal("k0 is still on the disk tier after promotion")
+	}
+
+	for i := 0; i < 30; i++ {
+		v, ok := s.Get(fmt.Sprint("k", i))
+		if !ok || string(v.(RawBytes)) != string(testValue(i)) {
+			t.Fatalf("Get(k%d) = %v, %v", i, v, ok)
+		}
+	}
+}
+
+func TestTieredTTLAcrossTiers(t *testing.T) {
+	s := newTestTiered(t, 1<<20, nil)
+
+	if err := s.SetWithExpiration("ttl", testValue(0), 300*time.Millisecond); err != nil {
+		t.Fatalf("SetWithExpiration failed: %v", err)
+	}
+	memExpire := s.memoryExpireAt(t, "ttl")
+	for i := 1; i < 30; i++ {
+		s.Set(fmt.Sprint("k", i), testValue(i))
+	}
+	waitDemoted(t, s)
+
+	expireAt, ok, err := s.diskEntry("ttl")
+	if err != nil || !ok {
+		t.Fatalf("ttl entry not on disk tier: %v", err)
+	}
+	if expireAt != memExpire {
+		t.Fatalf("disk tier expireAt = %d, want %d", expireAt, memExpire)
+	}
+
+	// 提升后仍然保留原来的过期时间
+	if _, ok := s.Get("ttl"); !ok {
+		t.Fatal("ttl entry missed before expiry")
+	}
+	if got := s.memoryExpireAt(t, "ttl"); got != memExpire {
+		t.Fatalf("promoted expireAt = %d, want %d", got, memExpire)
+	}
+
+	time.Sleep(350 * time.Millisecond)
+	if v, ok := s.Get("ttl"); ok {
+		t.Fatalf("Get(ttl) = %v after expiry", v)
+	}
+}
+
+func TestTieredDiskBudget(t *testing.T) {
+	const budget = 3000
+	var dropped int64
+	s := newTestTiered(t, budget, func(string, Value) { atomic.AddInt64(&dropped, 1) })
+
+	for i := 0; i < 100; i++ {
+		s.Set(fmt.Sprint("k", i), testValue(i))
+	}
+	waitDemoted(t, s)
+
+	if used := s.DiskBytes(); used > budget {
+		t.Fatalf("disk tier uses %d bytes, budget %d", used, budget)
+	}
+	if atomic.LoadInt64(&dropped) == 0 {
+		t.Fatal("entries over the disk budget were not reported as evicted")
+	}
+	if got := s.Len(); int64(got)+atomic.LoadInt64(&dropped) != 100 {
+		t.Fatalf("Len() = %d with %d dropped, want 100 in total", got, dropped)
+	}
+}
+
+func TestTieredPromoteDoesNotOverwriteNewerValue(t *testing.T) {
+	s := newTestTiered(t, 1<<20, nil)
+
+	for round := 0; round < 50; round++ {
+		s.Set("k", RawBytes("old"))
+		// 把 k 挤出内存层
+		for i := 0; i < 12; i++ {
+			s.Set(fmt.Sprint("fill", i), testValue(i))
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			s.Get("k")
+		}()
+		go func() {
+			defer wg.Done()
+			s.Set("k", RawBytes("new"))
+		}()
+		wg.Wait()
+
+		v, ok := s.Get("k")
+		if !ok || string(v.(RawBytes)) != "new" {
+			t.Fatalf("round %d: Get(k) = %v, %v, want new", round, v, ok)
+		}
+	}
+}
+
+// diskEntry 返回磁盘层中键的过期时间
+func (t *tieredStore) diskEntry(key string) (int64, bool, error) {
+	_, expireAt, ok, err := t.disk.get(key)
+	return expireAt, ok, err
+}
+
+func (t *tieredStore) memoryExpireAt(tb testing.TB, key string) int64 {
+	tb.Helper()
+	v, ok := t.memory.Get(key)
+	if !ok {
+		tb.Fatalf("%s is not in the memory tier", key)
+	}
+	return v.(*tieredEntry).expireAt
+}
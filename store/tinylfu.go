@@ -0,0 +1,170 @@
+package store
+
+import "container/list"
+
+// W-TinyLFU 的三个队列
+const (
+	tinyLFUWindow uint8 = iota
+	tinyLFUProbation
+	tinyLFUProtected
+)
+
+const (
+	tinyLFUWindowRatio    = 0.01 // 窗口区占总容量的比例
+	tinyLFUProtectedRatio = 0.8  // 保护段占主区容量的比例
+	tinyLFUEntryBytesHint = 64   // 估算条目数量时假设的平均条目大小，用于确定 sketch 宽度
+)
+
+// tinyLFUPolicy 实现 Window-TinyLFU 淘汰策略
+// 新条目先进入小的 LRU 窗口区；被窗口区淘汰的候选者需要与主区（分段 LRU）的淘汰者比较频次，
+// 频次更高者才能进入主区，从而过滤掉只访问一次的冷数据
+type tinyLFUPolicy struct {
+	sketch       *cmSketch
+	queues       [3]*list.List // 窗口区、试用段、保护段
+	bytes        [3]int64
+	windowMax    int64
+	mainMax      int64
+	protectedMax int64
+}
+
+// newTinyLFUCache 创建一个 W-TinyLFU 缓存实例
+func newTinyLFUCache(opts Options) *policyStore {
+	return newPolicyStore(opts, newTinyLFUPolicy(opts.MaxBytes))
+}
+
+func newTinyLFUPolicy(maxBytes int64) *tinyLFUPolicy {
+	width := 1 << 16
+	if maxBytes > 0 {
+		width = int(maxBytes / tinyLFUEntryBytesHint)
+		if width < 1024 {
+			width = 1024
+		} else if width > 1<<22 {
+			width = 1 << 22
+		}
+	}
+
+	p := &tinyLFUPolicy{sketch: newCMSketch(width)}
+	for i := range p.queues {
+		p.queues[i] = list.New()
+	}
+	p.resize(maxBytes)
+	return p
+}
+
+// resize 按容量重新划分窗口区、主区和保护段，保护段超额的部分降级回试用段，多出的条目由之后的 victim 淘汰
+func (p *tinyLFUPolicy) resize(maxBytes int64) {
+	p.windowMax = int64(float64(maxBytes) * tinyLFUWindowRatio)
+	if p.windowMax < 1 {
+		p.windowMax = 1
+	}
+	p.mainMax = maxBytes - p.windowMax
+	p.protectedMax = int64(float64(p.mainMax) * tinyLFUProtectedRatio)
+	p.demoteProtected()
+}
+
+// demoteProtected 保护段超额时，将其最久未使用的条目降级回试用段
+func (p *tinyLFUPolicy) demoteProtected() {
+	for p.bytes[tinyLFUProtected] > p.protectedMax && p.queues[tinyLFUProtected].Len() > 1 {
+		demoted := p.queues[tinyLFUProtected].Back().Value.(*policyEntry)
+		p.unlink(demoted)
+		p.push(demoted, tinyLFUProbation)
+	}
+}
+
+func (p *tinyLFUPolicy) add(e *policyEntry) {
+	p.sketch.increment(e.key)
+	p.push(e, tinyLFUWindow)
+}
+
+func (p *tinyLFUPolicy) access(e *policyEntry) {
+	p.sketch.increment(e.key)
+
+	switch e.queue {
+	case tinyLFUWindow, tinyLFUProtected:
+		p.queues[e.queue].MoveToFront(e.elem)
+	case tinyLFUProbation:
+		// 试用段再次命中，晋升到保护段
+		p.unlink(e)
+		p.push(e, tinyLFUProtected)
+		p.demoteProtected()
+	}
+}
+
+func (p *tinyLFUPolicy) update(e *policyEntry, oldSize int64) {
+	p.bytes[e.queue] += e.size - oldSize
+	p.access(e)
+}
+
+func (p *tinyLFUPolicy) remove(e *policyEntry) {
+	p.unlink(e)
+}
+
+func (p *tinyLFUPolicy) victim() *policyEntry {
+	// 窗口区超额：窗口区的淘汰者作为候选者尝试进入主区
+	for p.bytes[tinyLFUWindow] > p.windowMax && p.queues[tinyLFUWindow].Len() > 0 {
+		candidate := p.queues[tinyLFUWindow].Back().Value.(*policyEntry)
+		p.unlink(candidate)
+
+		mainBytes := p.bytes[tinyLFUProbation] + p.bytes[tinyLFUProtected]
+		if mainBytes+candidate.size <= p.mainMax {
+			p.push(candidate, tinyLFUProbation)
+			continue
+		}
+
+		victim := p.mainVictim()
+		if victim == nil {
+			p.push(candidate, tinyLFUProbation)
+			continue
+		}
+
+		// 准入判断：只有候选者的估算频次高于主区淘汰者时才替换
+		if p.sketch.estimate(candidate.key) > p.sketch.estimate(victim.key) {
+			p.unlink(victim)
+			p.push(candidate, tinyLFUProbation)
+			return victim
+		}
+		return candidate
+	}
+
+	if victim := p.mainVictim(); victim != nil {
+		p.unlink(victim)
+		return victim
+	}
+	if elem := p.queues[tinyLFUWindow].Back(); elem != nil {
+		victim := elem.Value.(*policyEntry)
+		p.unlink(victim)
+		return victim
+	}
+	return nil
+}
+
+func (p *tinyLFUPolicy) reset() {
+	for i := range p.queues {
+		p.queues[i].Init()
+		p.bytes[i] = 0
+	}
+	p.sketch.clear()
+}
+
+// mainVictim 返回主区的淘汰者（优先试用段），不摘除
+func (p *tinyLFUPolicy) mainVictim() *policyEntry {
+	if elem := p.queues[tinyLFUProbation].Back(); elem != nil {
+		return elem.Value.(*policyEntry)
+	}
+	if elem := p.queues[tinyLFUProtected].Back(); elem != nil {
+		return elem.Value.(*policyEntry)
+	}
+	return nil
+}
+
+func (p *tinyLFUPolicy) push(e *policyEntry, queue uint8) {
+	e.queue = queue
+	e.elem = p.queues[queue].PushFront(e)
+	p.bytes[queue] += e.size
+}
+
+func (p *tinyLFUPolicy) unlink(e *policyEntry) {
+	p.queues[e.queue].Remove(e.elem)
+	p.bytes[e.queue] -= e.size
+	e.elem = nil
+}
@@ -0,0 +1,130 @@
+// Package trace 提供访问日志回放工具，用于比较不同淘汰策略在真实访问序列上的命中率
+package trace
+
+import (
+	"LCache/store"
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Access 表示访问日志中的一次访问
+type Access struct {
+	Key  string
+	Size int // 值大小（字节），未指定时使用 DefaultValueSize
+}
+
+// DefaultValueSize 访问日志未记录值大小时使用的默认值
+const DefaultValueSize = 64
+
+// Result 一次回放的结果
+type Result struct {
+	CacheType store.CacheType
+	Requests  int64
+	Hits      int64
+	Evictions int64
+	Duration  time.Duration
+}
+
+// HitRatio 返回命中率
+func (r Result) HitRatio() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Hits) / float64(r.Requests)
+}
+
+// sizedValue 回放时写入缓存的值，只关心大小
+type sizedValue int
+
+func (v sizedValue) Len() int { return int(v) }
+
+// Bytes 使序列化型存储（如 arena）也能参与回放
+func (v sizedValue) Bytes() []byte { return make([]byte, v) }
+
+// ReadAccesses 解析访问日志
+// 每行一次访问，格式为 "key" 或 "key size"（以空白或逗号分隔），空行和以 # 开头的行会被忽略
+func ReadAccesses(r io.Reader) ([]Access, error) {
+	var accesses []Access
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+
+		access := Access{Key: fields[0], Size: DefaultValueSize}
+		if len(fields) > 1 {
+			size, err := strconv.Atoi(fields[1])
+			if err != nil || size < 0 {
+				return nil, fmt.Errorf("line %d: invalid size %q", lineNo, fields[1])
+			}
+			access.Size = size
+		}
+		accesses = append(accesses, access)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read trace: %v", err)
+	}
+	return accesses, nil
+}
+
+// Replay 在指定类型的缓存上回放访问序列
+// 每次访问先执行 Get，未命中时按记录的大小执行 Set，模拟读穿透缓存的行为
+func Replay(cacheType store.CacheType, opts store.Options, accesses []Access) (Result, error) {
+	var evictions int64
+	userOnEvicted := opts.OnEvicted
+	opts.OnEvicted = func(key string, value store.Value) {
+		atomic.AddInt64(&evictions, 1)
+		if userOnEvicted != nil {
+			userOnEvicted(key, value)
+		}
+	}
+
+	s, err := store.NewStore(cacheType, opts)
+	if err != nil {
+		return Result{}, err
+	}
+	defer s.Close()
+
+	result := Result{CacheType: cacheType}
+	start := time.Now()
+	for _, access := range accesses {
+		result.Requests++
+		if _, ok := s.Get(access.Key); ok {
+			result.Hits++
+			continue
+		}
+		_ = s.Set(access.Key, sizedValue(access.Size))
+	}
+	result.Duration = time.Since(start)
+	result.Evictions = atomic.LoadInt64(&evictions)
+
+	return result, nil
+}
+
+// Compare 在多种缓存类型上分别回放同一访问序列
+func Compare(cacheTypes []store.CacheType, opts store.Options, accesses []Access) ([]Result, error) {
+	results := make([]Result, 0, len(cacheTypes))
+	for _, cacheType := range cacheTypes {
+		result, err := Replay(cacheType, opts, accesses)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
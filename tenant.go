@@ -0,0 +1,533 @@
+package LCache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tenantHeader 请求所属租户的元数据键
+const tenantHeader = "lcache-tenant"
+
+// defaultQuotaCheckInterval 检查租户内存配额的默认间隔
+const defaultQuotaCheckInterval = time.Second
+
+// ErrUnknownTenant 请求的租户未注册
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// ErrTenantExists 租户已存在
+var ErrTenantExists = errors.New("tenant already exists")
+
+// TenantGroupName 返回租户的组在注册中心中的名称 "<tenant>/<group>"
+// 携带租户元数据的请求按这个名称查找组并鉴权，ACL 中为租户的组授权时使用它
+func TenantGroupName(tenant, group string) string {
+	return tenant + "/" + group
+}
+
+// tenantFromContext 从请求元数据中取出租户，未携带时为空
+func tenantFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if v := md.Get(tenantHeader); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// requestGroup 返回请求访问的组在注册中心中的名称，携带租户元数据时为租户的组
+func requestGroup(ctx context.Context, group string) string {
+	if tenant := tenantFromContext(ctx); tenant != "" {
+		return TenantGroupName(tenant, group)
+	}
+	return group
+}
+
+// TenantOptions 租户的配额
+type TenantOptions struct {
+	MemoryQuota int64     // 租户所有组合计的内存上限（字节），超出时周期性地从其组中淘汰条目，<= 0 表示不限制
+	Requests    RateLimit // 租户的请求配额，Rate <= 0 表示不限制
+}
+
+// TenantsOption 定义 Tenants 的配置选项
+type TenantsOption func(*Tenants)
+
+// WithTenantRegistry 设置租户的组所在的注册中心，默认 DefaultRegistry；服务端应使用同一个注册中心
+func WithTenantRegistry(r *Registry) TenantsOption {
+	return func(ts *Tenants) {
+		ts.registry = r
+	}
+}
+
+// WithNodeMemoryLimit 设置本节点所有租户合计的内存上限，超出时先从超出配额的租户中淘汰
+func WithNodeMemoryLimit(bytes int64) TenantsOption {
+	return func(ts *Tenants) {
+		ts.memoryLimit = bytes
+	}
+}
+
+// WithQuotaCheckInterval 设置检查内存配额的间隔，默认 1 秒
+func WithQuotaCheckInterval(d time.Duration) TenantsOption {
+	return func(ts *Tenants) {
+		ts.interval = d
+	}
+}
+
+// Tenants 共享集群的租户集合
+// 租户的组以 TenantGroupName 命名注册在同一个注册中心中，请求通过元数据 lcache-tenant 指定租户，
+// 服务端按租户查找组、计入租户的请求配额，并周期性地把租户的内存用量限制在配额以内
+type Tenants struct {
+	registry    *Registry
+	memoryLimit int64
+	interval    time.Duration
+
+	mu      sync.RWMutex
+	tenants map[string]*Tenant
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewTenants 创建租户集合并启动内存配额检查
+func NewTenants(opts ...TenantsOption) *Tenants {
+	ts := &Tenants{
+		registry: DefaultRegistry,
+		interval: defaultQuotaCheckInterval,
+		tenants:  make(map[string]*Tenant),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(ts)
+	}
+	if ts.registry == nil {
+		ts.registry = DefaultRegistry
+	}
+	if ts.interval <= 0 {
+		ts.interval = defaultQuotaCheckInterval
+	}
+
+	go ts.run()
+	return ts
+}
+
+// Add 注册租户，租户名不能为空或包含 "/"
+func (ts *Tenants) Add(name string, opts TenantOptions) (*Tenant, error) {
+	if name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid tenant name %q", name)
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if _, exists := ts.tenants[name]; exists {
+		return nil, fmt.Errorf("%w: %s", ErrTenantExists, name)
+	}
+	t := &Tenant{
+		name:     name,
+		opts:     opts,
+		registry: ts.registry,
+		groups:   make(map[string]*Group),
+	}
+	if opts.Requests.Rate > 0 {
+		t.bucket = newTokenBucket(opts.Requests)
+	}
+	ts.tenants[name] = t
+	logrus.Infof("[LCache] added tenant [%s] with memory quota %d", name, opts.MemoryQuota)
+	return t, nil
+}
+
+// Get 返回指定名称的租户
+func (ts *Tenants) Get(name string) *Tenant {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	return ts.tenants[name]
+}
+
+// Remove 移除租户并销毁它的所有组
+func (ts *Tenants) Remove(name string) bool {
+	ts.mu.Lock()
+	t, ok := ts.tenants[name]
+	delete(ts.tenants, name)
+	ts.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	for _, g := range t.groupList() {
+		g.Close()
+	}
+	logrus.Infof("[LCache] removed tenant [%s]", name)
+	return true
+}
+
+// Stats 返回每个租户的统计信息
+func (ts *Tenants) Stats() map[string]map[string]interface{} {
+	stats := make(map[string]map[string]interface{})
+	for _, t := range ts.list() {
+		stats[t.name] = t.Stats()
+	}
+	return stats
+}
+
+// Close 停止内存配额检查，租户的组保留
+func (ts *Tenants) Close() {
+	ts.closeOnce.Do(func() {
+		close(ts.stop)
+		<-ts.done
+	})
+}
+
+func (ts *Tenants) list() []*Tenant {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	tenants := make([]*Tenant, 0, len(ts.tenants))
+	for _, t := range ts.tenants {
+		tenants = append(tenants, t)
+	}
+	return tenants
+}
+
+func (ts *Tenants) run() {
+	defer close(ts.done)
+
+	ticker := time.NewTicker(ts.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ts.stop:
+			return
+		case <-ticker.C:
+			ts.enforce()
+		}
+	}
+}
+
+// enforce 节点内存超出上限时先回收，再把每个租户的用量限制在配额以内
+func (ts *Tenants) enforce() {
+	tenants := ts.list()
+	usage := make([]int64, len(tenants))
+	var total int64
+	for i, t := range tenants {
+		usage[i] = t.usedBytes()
+		total += usage[i]
+	}
+
+	if ts.memoryLimit > 0 && total > ts.memoryLimit {
+		reclaimFrom(tenants, usage, total-ts.memoryLimit)
+	}
+	for i, t := range tenants {
+		if quota := t.opts.MemoryQuota; quota > 0 && usage[i] > quota {
+			t.evict(usage[i] - quota)
+		}
+	}
+}
+
+// Reclaim 在内存压力下从租户的组中淘汰至少 bytes 字节，先从超出配额的租户中淘汰超出的部分，
+// 仍然不足时再按用量比例从所有租户中淘汰，返回实际释放的字节数
+func (ts *Tenants) Reclaim(bytes int64) int64 {
+	tenants := ts.list()
+	usage := make([]int64, len(tenants))
+	for i, t := range tenants {
+		usage[i] = t.usedBytes()
+	}
+	return reclaimFrom(tenants, usage, bytes)
+}
+
+// reclaimOverQuota 只从超出配额的租户中淘汰超出的部分，最多淘汰 bytes 字节，返回实际释放的字节数
+func (ts *Tenants) reclaimOverQuota(bytes int64) int64 {
+	tenants := ts.list()
+	usage := make([]int64, len(tenants))
+	for i, t := range tenants {
+		usage[i] = t.usedBytes()
+	}
+	return reclaimOverQuota(tenants, usage, bytes)
+}
+
+// reclaimFrom 按 Reclaim 的顺序淘汰，usage 为各租户的用量，淘汰后同步更新
+func reclaimFrom(tenants []*Tenant, usage []int64, bytes int64) int64 {
+	freed := reclaimOverQuota(tenants, usage, bytes)
+	if freed >= bytes {
+		return freed
+	}
+
+	var total int64
+	for _, u := range usage {
+		total += u
+	}
+	if total <= 0 {
+		return freed
+	}
+	remaining := bytes - freed
+	for i, t := range tenants {
+		if share := remaining * usage[i] / total; share > 0 {
+			n := t.evict(share)
+			usage[i] -= n
+			freed += n
+		}
+	}
+	return freed
+}
+
+// reclaimOverQuota 从超出配额最多的租户开始淘汰其超出的部分，usage 为各租户的用量，淘汰后同步更新
+func reclaimOverQuota(tenants []*Tenant, usage []int64, bytes int64) int64 {
+	order := make([]int, len(tenants))
+	for i := range order {
+		order[i] = i
+	}
+	over := func(i int) int64 {
+		if quota := tenants[i].opts.MemoryQuota; quota > 0 {
+			return usage[i] - quota
+		}
+		return 0
+	}
+	sort.Slice(order, func(a, b int) bool { return over(order[a]) > over(order[b]) })
+
+	var freed int64
+	for _, i := range order {
+		if freed >= bytes || over(i) <= 0 {
+			break
+		}
+		n := tenants[i].evict(min(over(i), bytes-freed))
+		usage[i] -= n
+		freed += n
+	}
+	return freed
+}
+
+// Tenant 租户，拥有自己的组、内存配额和请求配额
+type Tenant struct {
+	name     string
+	opts     TenantOptions
+	registry *Registry
+
+	mu     sync.Mutex
+	groups map[string]*Group // 组名（不含租户前缀）-> 组
+	bucket *tokenBucket      // 请求配额，为 nil 时不限制
+
+	requests     int64 // 请求总数
+	throttled    int64 // 因请求配额被拒绝的请求数
+	evictedBytes int64 // 因内存配额或节点内存压力淘汰的字节数
+}
+
+// Name 返回租户名称
+func (t *Tenant) Name() string {
+	return t.name
+}
+
+// NewGroup 为租户创建组，组在注册中心中的名称为 TenantGroupName(租户, name)
+func (t *Tenant) NewGroup(name string, cacheBytes int64, getter Getter, opts ...GroupOption) *Group {
+	g := t.registry.NewGroup(TenantGroupName(t.name, name), cacheBytes, getter, opts...)
+
+	t.mu.Lock()
+	t.groups[name] = g
+	t.mu.Unlock()
+	return g
+}
+
+// Group 返回租户的组
+func (t *Tenant) Group(name string) *Group {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	g := t.groups[name]
+	if g != nil && atomic.LoadInt32(&g.closed) == 1 {
+		return nil
+	}
+	return g
+}
+
+// groupList 返回租户仍未关闭的组，已关闭的组被移除
+func (t *Tenant) groupList() []*Group {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	groups := make([]*Group, 0, len(t.groups))
+	for name, g := range t.groups {
+		if atomic.LoadInt32(&g.closed) == 1 {
+			delete(t.groups, name)
+			continue
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// usedBytes 返回租户所有组合计的内存用量
+func (t *Tenant) usedBytes() int64 {
+	var used int64
+	for _, g := range t.groupList() {
+		used += g.mainCache.usedBytes()
+	}
+	return used
+}
+
+// evict 按各组的用量比例从租户的组中淘汰至少 bytes 字节，返回实际释放的字节数
+func (t *Tenant) evict(bytes int64) int64 {
+	groups := t.groupList()
+	usage := make([]int64, len(groups))
+	var total int64
+	for i, g := range groups {
+		usage[i] = g.mainCache.usedBytes()
+		total += usage[i]
+	}
+	if total <= 0 {
+		return 0
+	}
+
+	var freed int64
+	for i, g := range groups {
+		if usage[i] > 0 {
+			freed += g.mainCache.evict(max(bytes*usage[i]/total, 1))
+		}
+	}
+	atomic.AddInt64(&t.evictedBytes, freed)
+	if freed > 0 {
+		logrus.Debugf("[LCache] evicted %d bytes from tenant [%s]", freed, t.name)
+	}
+	return freed
+}
+
+// allow 计入一次请求并检查请求配额，被限流时返回建议的等待时间
+func (t *Tenant) allow() time.Duration {
+	atomic.AddInt64(&t.requests, 1)
+	if t.bucket == nil {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if wait := t.bucket.delay(time.Now(), 0); wait > 0 {
+		atomic.AddInt64(&t.throttled, 1)
+		return wait
+	}
+	t.bucket.take()
+	return 0
+}
+
+// Stats 返回租户的统计信息，命中和加载次数为其所有组的合计
+func (t *Tenant) Stats() map[string]interface{} {
+	groups := t.groupList()
+	stats := map[string]interface{}{
+		"name":          t.name,
+		"groups":        len(groups),
+		"memory_quota":  t.opts.MemoryQuota,
+		"requests":      atomic.LoadInt64(&t.requests),
+		"throttled":     atomic.LoadInt64(&t.throttled),
+		"evicted_bytes": atomic.LoadInt64(&t.evictedBytes),
+	}
+
+	var used, hits, misses, loads int64
+	for _, g := range groups {
+		used += g.mainCache.usedBytes()
+		hits += atomic.LoadInt64(&g.stats.localHits)
+		misses += atomic.LoadInt64(&g.stats.localMisses)
+		loads += atomic.LoadInt64(&g.stats.loads)
+	}
+	stats["used_bytes"] = used
+	stats["local_hits"] = hits
+	stats["local_misses"] = misses
+	stats["loads"] = loads
+	return stats
+}
+
+// tenantQuotaInterceptor 按租户请求配额限流的一元拦截器，不属于任何租户的请求不受影响，未注册的租户返回 NotFound
+// authEnabled 和 acl 用于判断请求是否来自可信的节点，见 checkTenantQuota
+func tenantQuotaInterceptor(ts *Tenants, authEnabled bool, acl *ACL) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !strings.HasPrefix(info.FullMethod, "/pb.LCache/") {
+			return handler(ctx, req)
+		}
+		var group string
+		if r, ok := req.(interface{ GetGroup() string }); ok {
+			group = r.GetGroup()
+		}
+		setTrailer := func(md metadata.MD) { grpc.SetTrailer(ctx, md) }
+		if err := checkTenantQuota(ctx, ts, authEnabled, acl, group, setTrailer); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// tenantQuotaStreamInterceptor 流式 RPC 的租户配额拦截器，组名在请求消息中，因此在收到第一条请求后检查，只计一次请求
+func tenantQuotaStreamInterceptor(ts *Tenants, authEnabled bool, acl *ACL) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !strings.HasPrefix(info.FullMethod, "/pb.LCache/") {
+			return handler(srv, ss)
+		}
+
+		checked := false
+		stream := &recvHookStream{ServerStream: ss}
+		stream.onRecv = func(m interface{}) error {
+			if checked {
+				return nil
+			}
+			checked = true
+			var group string
+			if r, ok := m.(interface{ GetGroup() string }); ok {
+				group = r.GetGroup()
+			}
+			return checkTenantQuota(ss.Context(), ts, authEnabled, acl, group, ss.SetTrailer)
+		}
+		return handler(srv, stream)
+	}
+}
+
+// checkTenantQuota 检查请求访问的租户并计入它的请求配额，被限流时通过 setTrailer 给出建议的重试等待时间
+// 租户取自请求最终访问的组名：其他调用方只能通过 lcache-tenant 指定租户，组名中带有租户前缀的请求只接受可信的节点
+// （节点间同步租户的组时直接使用完整的组名）；启用认证时 lcache-tenant 必须与调用方的身份相同
+func checkTenantQuota(ctx context.Context, ts *Tenants, authEnabled bool, acl *ACL, group string, setTrailer func(metadata.MD)) error {
+	header := tenantFromContext(ctx)
+	if strings.Contains(group, "/") && (header != "" || !trustedPeer(ctx, authEnabled, acl, group)) {
+		return status.Errorf(codes.InvalidArgument, "group name %q must not contain \"/\", use %s metadata to select a tenant", group, tenantHeader)
+	}
+	if header != "" && authEnabled {
+		if identity, _ := IdentityFromContext(ctx); identity != header {
+			return status.Errorf(codes.PermissionDenied, "identity %q cannot act as tenant %s", identity, header)
+		}
+	}
+
+	name, _, ok := strings.Cut(requestGroup(ctx, group), "/")
+	if !ok {
+		return nil
+	}
+	t := ts.Get(name)
+	if t == nil {
+		return status.Errorf(codes.NotFound, "%v: %s", ErrUnknownTenant, name)
+	}
+	if wait := t.allow(); wait > 0 {
+		setTrailer(metadata.Pairs(retryAfterHeader, strconv.FormatInt(wait.Milliseconds(), 10)))
+		return status.Errorf(codes.ResourceExhausted, "request quota exceeded for tenant %s", name)
+	}
+	return nil
+}
+
+// tenantClientInterceptor 客户端拦截器：在请求上附加租户
+func tenantClientInterceptor(tenant string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(metadata.AppendToOutgoingContext(ctx, tenantHeader, tenant), method, req, reply, cc, opts...)
+	}
+}
+
+// tenantStreamClientInterceptor 客户端流拦截器：在流上附加租户
+func tenantStreamClientInterceptor(tenant string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(metadata.AppendToOutgoingContext(ctx, tenantHeader, tenant), desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,111 @@
+package LCache
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestGroup 在独立的注册中心中创建以测试名命名的组，测试结束时关闭
+// getter 为 nil 时加载总是失败，便于区分缓存命中和加载
+func newTestGroup(t *testing.T, getter Getter, opts ...GroupOption) *Group {
+	t.Helper()
+	return newTestGroupIn(t, NewRegistry(), getter, opts...)
+}
+
+// newTestGroupIn 与 newTestGroup 相同，但在注册中心 reg 中创建组，用于模拟多个节点上的同名组
+func newTestGroupIn(t *testing.T, reg *Registry, getter Getter, opts ...GroupOption) *Group {
+	t.Helper()
+	if getter == nil {
+		getter = GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+			return nil, fmt.Errorf("key %s not found", key)
+		})
+	}
+	g := reg.NewGroup(t.Name(), 1<<20, getter, opts...)
+	t.Cleanup(func() { g.Close() })
+	return g
+}
+
+// cachedValue 返回本地缓存中键的值，不触发加载
+func cachedValue(t *testing.T, g *Group, key string) (ByteView, bool) {
+	t.Helper()
+	return g.mainCache.peek(key)
+}
+
+// startTestServer 在随机端口上启动服务注册中心 reg 中的组的 Server，返回监听地址
+func startTestServer(t *testing.T, reg *Registry, opts ...ServerOption) string {
+	t.Helper()
+	srv, err := NewServer("127.0.0.1:0", "lcache-test", append([]ServerOption{WithRegistry(reg)}, opts...)...)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go srv.grpcServer.Serve(lis)
+	t.Cleanup(srv.grpcServer.Stop)
+	return lis.Addr().String()
+}
+
+// newTestClient 连接 addr 上的 Server，测试结束时关闭
+func newTestClient(t *testing.T, addr string, opts ...ClientOption) *Client {
+	t.Helper()
+	c, err := NewClient(addr, "lcache-test", nil, opts...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// countingPeer 记录同步到节点的写入次数
+type countingPeer struct {
+	Peer
+	sets    atomic.Int64
+	deletes atomic.Int64
+}
+
+func (p *countingPeer) Set(ctx context.Context, group, key string, value []byte, opts ...WriteOption) error {
+	p.sets.Add(1)
+	return p.Peer.Set(ctx, group, key, value, opts...)
+}
+
+func (p *countingPeer) Delete(ctx context.Context, group, key string, opts ...WriteOption) (bool, error) {
+	p.deletes.Add(1)
+	return p.Peer.Delete(ctx, group, key, opts...)
+}
+
+// staticPicker 把所有键都路由到固定的节点，节点可以在创建组之后再设置
+type staticPicker struct {
+	mu    sync.Mutex
+	peers []Peer
+}
+
+func (p *staticPicker) set(peers ...Peer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.peers = peers
+}
+
+func (p *staticPicker) PickPeer(key string) (Peer, bool, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.peers) == 0 {
+		return nil, false, false
+	}
+	return p.peers[0], true, false
+}
+
+func (p *staticPicker) Peers() []Peer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Peer(nil), p.peers...)
+}
+
+func (p *staticPicker) Close() error {
+	return nil
+}
@@ -0,0 +1,229 @@
+package LCache
+
+import (
+	"LCache/store"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/credentials"
+)
+
+// ErrPeerNotAllowed 对端证书的 SAN 不在允许列表中
+var ErrPeerNotAllowed = errors.New("peer certificate SAN is not allowed")
+
+// defaultCertReloadInterval 默认检查证书文件是否更新的最小间隔
+const defaultCertReloadInterval = 10 * time.Second
+
+// certReloader 持有当前的证书和 CA，并在文件修改后重新加载，使证书轮换无需重启进程
+// 检查是惰性的：握手时若距上次检查超过 interval 才会查看文件修改时间，因此不需要后台协程
+type certReloader struct {
+	caFile   string
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate // 本端证书，未配置时为 nil
+	pool      *x509.CertPool   // 校验对端证书的 CA，未配置时为 nil
+	modTime   time.Time        // 已加载文件中最新的修改时间
+	checkedAt time.Time        // 上次检查文件的时间
+}
+
+func newCertReloader(caFile, certFile, keyFile string, interval time.Duration) (*certReloader, error) {
+	if interval <= 0 {
+		interval = defaultCertReloadInterval
+	}
+	r := &certReloader{
+		caFile:   caFile,
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: interval,
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load 读取证书文件并替换当前的证书和 CA
+func (r *certReloader) load() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	var cert *tls.Certificate
+	if r.certFile != "" {
+		c, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load key pair: %v", err)
+		}
+		cert = &c
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA file: %v", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in CA file %s", r.caFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.pool, r.modTime = cert, pool, modTime
+	r.checkedAt = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+// latestModTime 返回所有证书文件中最新的修改时间
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.caFile, r.certFile, r.keyFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// current 返回当前的证书和 CA，必要时先检查文件是否更新
+// 重新加载失败时继续使用旧证书，避免轮换过程中文件写了一半导致连接中断
+func (r *certReloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	check := time.Since(r.checkedAt) >= r.interval
+	if check {
+		r.checkedAt = time.Now()
+	}
+	loaded := r.modTime
+	r.mu.Unlock()
+
+	if check {
+		if modTime, err := r.latestModTime(); err == nil && modTime.After(loaded) {
+			if err := r.load(); err != nil {
+				logrus.Warnf("[LCache] failed to reload TLS certificates, keeping the previous ones: %v", err)
+			} else {
+				logrus.Infof("[LCache] reloaded TLS certificates from %s", r.certFile)
+			}
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, r.pool
+}
+
+// serverCredentials 创建服务端凭据：配置了 CA 时要求并校验客户端证书（mTLS），allowedSANs 非空时只接受 SAN 匹配的客户端
+func (r *certReloader) serverCredentials(allowedSANs []string) credentials.TransportCredentials {
+	return credentials.NewTLS(&tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool := r.current()
+			if cert == nil {
+				return nil, errors.New("no server certificate configured")
+			}
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+			}
+			if pool != nil {
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				cfg.ClientCAs = pool
+				cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+					return verifyPeerSAN(cs.PeerCertificates[0], allowedSANs)
+				}
+			}
+			return cfg, nil
+		},
+	})
+}
+
+// clientCredentials 创建客户端凭据：用当前的 CA 校验服务端证书并出示本端证书
+// allowedSANs 为空时按拨号地址校验服务端证书的主机名，否则按允许列表校验 SAN
+func (r *certReloader) clientCredentials(allowedSANs []string) credentials.TransportCredentials {
+	return credentials.NewTLS(&tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// 证书链在 VerifyConnection 中用当前的 CA 校验，这样 CA 轮换后无需重建凭据
+		InsecureSkipVerify: true,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := r.current()
+			if cert == nil {
+				return &tls.Certificate{}, nil
+			}
+			return cert, nil
+		},
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("server presented no certificate")
+			}
+			_, pool := r.current()
+			opts := x509.VerifyOptions{
+				Roots:         pool,
+				Intermediates: x509.NewCertPool(),
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			}
+			if len(allowedSANs) == 0 {
+				opts.DNSName = cs.ServerName
+			}
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			leaf := cs.PeerCertificates[0]
+			if _, err := leaf.Verify(opts); err != nil {
+				return err
+			}
+			return verifyPeerSAN(leaf, allowedSANs)
+		},
+	})
+}
+
+// verifyPeerSAN 检查证书的 DNS、IP 或 URI SAN 是否匹配允许列表中的任一 glob 模式，允许列表为空时不检查
+func verifyPeerSAN(cert *x509.Certificate, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	names := append([]string(nil), cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+
+	for _, pattern := range allowed {
+		for _, name := range names {
+			if ok, err := store.MatchPattern(pattern, name); err == nil && ok {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: %v", ErrPeerNotAllowed, names)
+}
+
+// validateSANPatterns 检查 SAN 允许列表中的 glob 模式是否合法
+func validateSANPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := store.MatchPattern(pattern, ""); err != nil {
+			return fmt.Errorf("invalid SAN pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package LCache
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// defaultDecodedCacheSize TypedGroup 默认缓存的已解码对象数
+const defaultDecodedCacheSize = 1024
+
+// Serializer 在类型 V 与字节之间转换，字节形式保存在 Group 中并在节点间传输
+type Serializer[V any] interface {
+	Marshal(v V) ([]byte, error)
+	Unmarshal(data []byte) (V, error)
+}
+
+// JSONSerializer 使用 encoding/json 序列化
+type JSONSerializer[V any] struct{}
+
+func (JSONSerializer[V]) Marshal(v V) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONSerializer[V]) Unmarshal(data []byte) (V, error) {
+	var v V
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
+
+// GobSerializer 使用 encoding/gob 序列化
+type GobSerializer[V any] struct{}
+
+func (GobSerializer[V]) Marshal(v V) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobSerializer[V]) Unmarshal(data []byte) (V, error) {
+	var v V
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	return v, err
+}
+
+// MsgpackSerializer 使用 MessagePack 序列化
+type MsgpackSerializer[V any] struct{}
+
+func (MsgpackSerializer[V]) Marshal(v V) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (MsgpackSerializer[V]) Unmarshal(data []byte) (V, error) {
+	var v V
+	err := msgpack.Unmarshal(data, &v)
+	return v, err
+}
+
+// ProtoSerializer 使用 protobuf 序列化，V 为生成的消息指针类型，例如 ProtoSerializer[*pb.User]
+type ProtoSerializer[V proto.Message] struct{}
+
+func (ProtoSerializer[V]) Marshal(v V) ([]byte, error) {
+	return proto.Marshal(v)
+}
+
+func (ProtoSerializer[V]) Unmarshal(data []byte) (V, error) {
+	var zero V
+	v := zero.ProtoReflect().Type().New().Interface().(V)
+	err := proto.Unmarshal(data, v)
+	return v, err
+}
+
+// TypedOption 定义 TypedGroup 的配置选项
+type TypedOption func(*typedOptions)
+
+type typedOptions struct {
+	decodedCacheSize int
+}
+
+// WithDecodedCacheSize 设置本地缓存的已解码对象数，0 表示不缓存，每次读取都反序列化
+func WithDecodedCacheSize(n int) TypedOption {
+	return func(o *typedOptions) {
+		o.decodedCacheSize = n
+	}
+}
+
+// TypedGroup 按类型 V 读写 Group 的包装
+// Group 中保存的始终是 Serializer 生成的字节，节点间同步、快照等都不受影响；
+// 同时在本地按条目版本号缓存已解码的对象，版本号未变时热点读取不需要解压、解密和反序列化。
+// Get 返回的对象可能被多个调用方共享，不能修改
+type TypedGroup[V any] struct {
+	group      *Group
+	serializer Serializer[V]
+	decoded    *decodedCache[V]
+}
+
+// NewTypedGroup 创建包装 g 的 TypedGroup
+func NewTypedGroup[V any](g *Group, s Serializer[V], opts ...TypedOption) *TypedGroup[V] {
+	options := typedOptions{decodedCacheSize: defaultDecodedCacheSize}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	t := &TypedGroup[V]{group: g, serializer: s}
+	if options.decodedCacheSize > 0 {
+		t.decoded = newDecodedCache[V](options.decodedCacheSize)
+	}
+	return t
+}
+
+// Group 返回底层的 Group
+func (t *TypedGroup[V]) Group() *Group {
+	return t.group
+}
+
+// Get 获取并反序列化键的值，本地缓存中版本号相同的已解码对象直接返回
+func (t *TypedGroup[V]) Get(ctx context.Context, key string) (V, error) {
+	view, err := t.group.Get(ctx, key)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+
+	if t.decoded != nil && view.version != 0 {
+		if v, ok := t.decoded.get(key, view.version); ok {
+			return v, nil
+		}
+	}
+
+	v, err := t.serializer.Unmarshal(view.data())
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	if t.decoded != nil && view.version != 0 {
+		t.decoded.add(key, view.version, v)
+	}
+	return v, nil
+}
+
+// Set 序列化后写入
+func (t *TypedGroup[V]) Set(ctx context.Context, key string, v V, opts ...WriteOption) error {
+	data, err := t.serializer.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return t.group.Set(ctx, key, data, opts...)
+}
+
+// Delete 删除键的值
+func (t *TypedGroup[V]) Delete(ctx context.Context, key string, opts ...WriteOption) error {
+	if t.decoded != nil {
+		t.decoded.remove(key)
+	}
+	return t.group.Delete(ctx, key, opts...)
+}
+
+// Stats 返回底层 Group 的统计信息以及已解码对象缓存的命中情况
+func (t *TypedGroup[V]) Stats() map[string]interface{} {
+	stats := t.group.Stats()
+	if t.decoded != nil {
+		hits, misses := atomic.LoadInt64(&t.decoded.hits), atomic.LoadInt64(&t.decoded.misses)
+		stats["decoded_size"] = t.decoded.len()
+		stats["decoded_hits"] = hits
+		stats["decoded_misses"] = misses
+		if hits+misses > 0 {
+			stats["decoded_hit_rate"] = float64(hits) / float64(hits+misses)
+		}
+	}
+	return stats
+}
+
+// decodedCache 按键缓存已解码对象的 LRU，条目带有解码时的版本号，版本号不一致时视为未命中
+type decodedCache[V any] struct {
+	mu      sync.Mutex
+	maxSize int
+	ll      *list.List
+	items   map[string]*list.Element
+
+	hits   int64
+	misses int64
+}
+
+type decodedEntry[V any] struct {
+	key     string
+	version uint64
+	value   V
+}
+
+func newDecodedCache[V any](maxSize int) *decodedCache[V] {
+	return &decodedCache[V]{
+		maxSize: maxSize,
+		ll:      list.New(),
+		items:   make(map[string]*list.Element),
+	}
+}
+
+func (c *decodedCache[V]) get(key string, version uint64) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*decodedEntry[V])
+		if entry.version == version {
+			c.ll.MoveToFront(elem)
+			atomic.AddInt64(&c.hits, 1)
+			return entry.value, true
+		}
+	}
+	atomic.AddInt64(&c.misses, 1)
+	var zero V
+	return zero, false
+}
+
+func (c *decodedCache[V]) add(key string, version uint64, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*decodedEntry[V])
+		// 并发读取时可能先解码出较新的版本，不用旧版本覆盖它
+		if entry.version <= version {
+			entry.version, entry.value = version, value
+		}
+		c.ll.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&decodedEntry[V]{key: key, version: version, value: value})
+	for c.ll.Len() > c.maxSize {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*decodedEntry[V]).key)
+	}
+}
+
+func (c *decodedCache[V]) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.ll.Remove(elem)
+		delete(c.items, key)
+	}
+}
+
+func (c *decodedCache[V]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
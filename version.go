@@ -0,0 +1,214 @@
+package LCache
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// hlcLogicalBits 混合逻辑时钟中逻辑计数器占用的低位数，高位为毫秒级物理时间
+	hlcLogicalBits = 16
+
+	// tombstoneTTL 删除墓碑的保留时间，需要覆盖节点间异步同步可能的最大延迟
+	tombstoneTTL = time.Minute
+
+	// maxClockDrift 接受的版本号最多领先本地物理时钟的时间，更大的版本号说明对端时钟错误或请求伪造
+	maxClockDrift = time.Minute
+)
+
+// ErrVersionTooNew 写入携带的版本号领先本地时钟超过 maxClockDrift
+var ErrVersionTooNew = errors.New("version is too far ahead of local clock")
+
+// hybridClock 混合逻辑时钟（HLC），用于生成条目版本号
+// 版本号 = 毫秒时间戳 << 16 | 逻辑计数器：本地生成的版本号单调递增，
+// 并且总是大于通过 observe 见到的其他节点的版本号，因此因果上更晚的写入一定有更大的版本号
+type hybridClock struct {
+	last uint64 // 最近发出或见到的版本号（原子访问）
+}
+
+// now 生成一个新的版本号
+func (c *hybridClock) now() uint64 {
+	for {
+		last := atomic.LoadUint64(&c.last)
+		next := uint64(time.Now().UnixMilli()) << hlcLogicalBits
+		if next <= last {
+			// observe 限制了时钟领先物理时间的幅度，正常情况下不会到达上限，到达时保持不变而不是回绕到 0
+			if last == math.MaxUint64 {
+				return last
+			}
+			next = last + 1
+		}
+		if atomic.CompareAndSwapUint64(&c.last, last, next) {
+			return next
+		}
+	}
+}
+
+// observe 记录从其他节点收到的版本号，之后生成的版本号都会大于它
+// 领先本地物理时钟超过 maxClockDrift 的版本号不被记录并返回 false，调用方应拒绝携带它的写入，
+// 否则一个伪造的极大版本号会让时钟停在该值，之后所有写入都被当作旧写入丢弃
+func (c *hybridClock) observe(version uint64) bool {
+	limit := uint64(time.Now().Add(maxClockDrift).UnixMilli()) << hlcLogicalBits
+	if version > limit {
+		return false
+	}
+	for {
+		last := atomic.LoadUint64(&c.last)
+		if version <= last || atomic.CompareAndSwapUint64(&c.last, last, version) {
+			return true
+		}
+	}
+}
+
+// tombstones 记录最近被删除的键及删除时的版本号，用于拒绝晚到的旧写入
+// 墓碑只保留 tombstoneTTL，超时后由写入路径顺带清理
+type tombstones struct {
+	mu        sync.Mutex
+	entries   map[string]tombstone
+	lastSweep time.Time
+}
+
+type tombstone struct {
+	version  uint64
+	expireAt time.Time
+}
+
+// add 记录删除墓碑，已有更新的墓碑时保留原墓碑
+func (t *tombstones) add(key string, version uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if t.entries == nil {
+		t.entries = make(map[string]tombstone)
+		t.lastSweep = now
+	}
+	if old, ok := t.entries[key]; ok && old.version > version {
+		return
+	}
+	t.entries[key] = tombstone{version: version, expireAt: now.Add(tombstoneTTL)}
+
+	if now.Sub(t.lastSweep) >= tombstoneTTL {
+		for k, ts := range t.entries {
+			if now.After(ts.expireAt) {
+				delete(t.entries, k)
+			}
+		}
+		t.lastSweep = now
+	}
+}
+
+// version 返回键的墓碑版本号，没有墓碑或已过期时返回 0
+func (t *tombstones) version(key string) uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ts, ok := t.entries[key]
+	if !ok || time.Now().After(ts.expireAt) {
+		return 0
+	}
+	return ts.version
+}
+
+// remove 删除键的墓碑
+func (t *tombstones) remove(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, key)
+}
+
+// len 返回墓碑数量
+func (t *tombstones) len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.entries)
+}
+
+// isStale 判断版本号为 version 的写入或删除是否比本节点已有的值旧，旧时计入 stale_writes，调用前必须持有键锁
+func (g *Group) isStale(key string, version uint64, op string) bool {
+	current := g.currentVersion(key)
+	if version >= current {
+		return false
+	}
+	atomic.AddInt64(&g.stats.staleWrites, 1)
+	logrus.Debugf("[LCache] rejected stale %s of key %s in group [%s]: version %d < %d",
+		op, key, g.name, version, current)
+	return true
+}
+
+// currentVersion 返回键在本节点上的最新版本号：缓存中的值与删除墓碑中较新的一个，调用前必须持有键锁
+func (g *Group) currentVersion(key string) uint64 {
+	version := g.tombstones.version(key)
+	if view, ok := g.mainCache.peek(key); ok && view.version > version {
+		version = view.version
+	}
+	return version
+}
+
+// addIfNewer 仅当 view 的版本号不旧于本节点已有的值或墓碑时写入，返回是否写入；notify 表示是否通知观察者
+func (g *Group) addIfNewer(key string, view ByteView, notify bool, tags ...string) bool {
+	unlock := g.keyLocks.lock(key)
+	defer unlock()
+
+	return g.addIfNewerLocked(key, view, notify, tags...)
+}
+
+// addIfNewerLocked 与 addIfNewer 相同，调用前必须持有键锁
+func (g *Group) addIfNewerLocked(key string, view ByteView, notify bool, tags ...string) bool {
+	if g.isStale(key, view.version, "write") {
+		return false
+	}
+	g.addLocal(key, view, tags...)
+	if notify {
+		g.notifySet(key, view)
+	}
+	return true
+}
+
+// restoreIfNewer 与 addIfNewer 相同地检查版本号，但按 expireAt 设置过期时间（零值表示永不过期），
+// 并且不通知观察者，用于从快照恢复；恢复的版本号会被时钟记录，之后的写入总是更新
+func (g *Group) restoreIfNewer(key string, view ByteView, expireAt time.Time) bool {
+	// 快照中领先本地时钟过多的版本号不可信，改用新生成的版本号
+	if !g.clock.observe(view.version) {
+		view.version = g.clock.now()
+	}
+
+	unlock := g.keyLocks.lock(key)
+	defer unlock()
+
+	if current := g.currentVersion(key); view.version < current {
+		atomic.AddInt64(&g.stats.staleWrites, 1)
+		return false
+	}
+	g.tombstones.remove(key)
+	if expireAt.IsZero() {
+		g.mainCache.Add(key, view)
+	} else {
+		g.mainCache.AddWithExpiration(key, view, expireAt)
+	}
+	return true
+}
+
+// deleteIfNewer 仅当 version 不旧于本节点已有的值时删除，并留下版本号为 version 的墓碑，返回是否删除
+func (g *Group) deleteIfNewer(key string, version uint64) bool {
+	unlock := g.keyLocks.lock(key)
+	defer unlock()
+
+	return g.deleteIfNewerLocked(key, version)
+}
+
+// deleteIfNewerLocked 与 deleteIfNewer 相同，调用前必须持有键锁
+func (g *Group) deleteIfNewerLocked(key string, version uint64) bool {
+	if g.isStale(key, version, "delete") {
+		return false
+	}
+	g.mainCache.Delete(key)
+	g.tombstones.add(key, version)
+	g.notifyDelete(key, version)
+	return true
+}
@@ -0,0 +1,161 @@
+package LCache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// physicalVersion 返回物理时间 t 对应的最小版本号
+func physicalVersion(t time.Time) uint64 {
+	return uint64(t.UnixMilli()) << hlcLogicalBits
+}
+
+func TestHybridClockObserveDrift(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset time.Duration
+		want   bool
+	}{
+		{"past", -time.Hour, true},
+		{"now", 0, true},
+		{"within drift", maxClockDrift - time.Second, true},
+		{"beyond drift", maxClockDrift + time.Second, false},
+		{"far future", 24 * time.Hour, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c hybridClock
+			version := physicalVersion(time.Now().Add(tt.offset))
+			if got := c.observe(version); got != tt.want {
+				t.Fatalf("observe(now%+v) = %v, want %v", tt.offset, got, tt.want)
+			}
+			next := c.now()
+			if tt.want && next <= version {
+				t.Errorf("now() = %d after observing %d, want greater", next, version)
+			}
+			if !tt.want && next >= version {
+				t.Errorf("rejected version %d moved the clock to %d", version, next)
+			}
+		})
+	}
+}
+
+func TestHybridClockMonotonic(t *testing.T) {
+	var c hybridClock
+	prev := c.now()
+	for i := 0; i < 1000; i++ {
+		next := c.now()
+		if next <= prev {
+			t.Fatalf("now() = %d after %d, want strictly increasing", next, prev)
+		}
+		prev = next
+	}
+}
+
+func TestSetRejectsVersionTooNew(t *testing.T) {
+	g := newTestGroup(t, nil)
+	ctx := context.Background()
+
+	version := physicalVersion(time.Now().Add(maxClockDrift + time.Minute))
+	if err := g.Set(ctx, "k", []byte("v"), withVersion(version)); !errors.Is(err, ErrVersionTooNew) {
+		t.Fatalf("Set error = %v, want ErrVersionTooNew", err)
+	}
+	if err := g.Delete(ctx, "k", withVersion(version)); !errors.Is(err, ErrVersionTooNew) {
+		t.Fatalf("Delete error = %v, want ErrVersionTooNew", err)
+	}
+}
+
+func TestIsStale(t *testing.T) {
+	g := newTestGroup(t, nil)
+	ctx := context.Background()
+	base := g.clock.now()
+
+	if err := g.Set(ctx, "k", []byte("v1"), withVersion(base)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		version uint64
+		want    bool
+	}{
+		{"older", base - 1, true},
+		{"equal", base, false},
+		{"newer", base + 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.isStale("k", tt.version, "write"); got != tt.want {
+				t.Errorf("isStale(%d) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+
+	// 旧版本的写入被丢弃，相同版本的写入被接受
+	if err := g.Set(ctx, "k", []byte("old"), withVersion(base-1)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if v, _ := cachedValue(t, g, "k"); v.String() != "v1" {
+		t.Fatalf("value after stale write = %q, want %q", v.String(), "v1")
+	}
+	if err := g.Set(ctx, "k", []byte("same"), withVersion(base)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if v, _ := cachedValue(t, g, "k"); v.String() != "same" {
+		t.Fatalf("value after equal-version write = %q, want %q", v.String(), "same")
+	}
+}
+
+func TestTombstoneRejectsOlderWrite(t *testing.T) {
+	g := newTestGroup(t, nil)
+	ctx := context.Background()
+	base := g.clock.now()
+
+	if err := g.Delete(ctx, "k", withVersion(base)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := g.Set(ctx, "k", []byte("late"), withVersion(base-1)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, ok := cachedValue(t, g, "k"); ok {
+		t.Fatal("write older than the tombstone resurrected the key")
+	}
+	if err := g.Set(ctx, "k", []byte("new"), withVersion(base+1)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, ok := cachedValue(t, g, "k"); !ok {
+		t.Fatal("write newer than the tombstone was rejected")
+	}
+}
+
+func TestTombstoneExpiry(t *testing.T) {
+	var ts tombstones
+	ts.add("k", 10)
+	if got := ts.version("k"); got != 10 {
+		t.Fatalf("version = %d, want 10", got)
+	}
+
+	// 更旧的墓碑不覆盖已有墓碑
+	ts.add("k", 5)
+	if got := ts.version("k"); got != 10 {
+		t.Fatalf("version after older add = %d, want 10", got)
+	}
+
+	// 过期的墓碑不再生效
+	ts.entries["k"] = tombstone{version: 10, expireAt: time.Now().Add(-time.Second)}
+	if got := ts.version("k"); got != 0 {
+		t.Fatalf("version of expired tombstone = %d, want 0", got)
+	}
+
+	// 距离上次清理超过 tombstoneTTL 后，下一次 add 会清理过期的墓碑
+	ts.lastSweep = time.Now().Add(-tombstoneTTL)
+	ts.add("other", 1)
+	if _, ok := ts.entries["k"]; ok {
+		t.Error("expired tombstone was not swept")
+	}
+	if got := ts.len(); got != 1 {
+		t.Errorf("len = %d, want 1", got)
+	}
+}
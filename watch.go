@@ -0,0 +1,310 @@
+package LCache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrSlowWatcher 观察者处理事件太慢，缓冲区已满，观察已被取消；调用方需要重新读取关心的键后重新观察
+var ErrSlowWatcher = errors.New("watcher is too slow, events dropped")
+
+// defaultWatchBuffer 观察者默认的事件缓冲区大小
+const defaultWatchBuffer = 256
+
+// WatchEventType 键变化事件的类型
+type WatchEventType int
+
+const (
+	WatchSet    WatchEventType = iota // 写入，包括原子操作
+	WatchDelete                       // 删除，包括按前缀和标签批量失效
+	WatchExpire                       // 过期
+	WatchEvict                        // 因容量被淘汰，后端数据并未变化
+)
+
+func (t WatchEventType) String() string {
+	switch t {
+	case WatchSet:
+		return "set"
+	case WatchDelete:
+		return "delete"
+	case WatchExpire:
+		return "expire"
+	case WatchEvict:
+		return "evict"
+	default:
+		return "unknown"
+	}
+}
+
+// WatchEvent 本节点缓存中键的变化事件
+type WatchEvent struct {
+	Type    WatchEventType
+	Key     string
+	Version uint64 // 写入为新版本号，删除为删除的版本号，过期和淘汰为离开缓存的值的版本号
+	Value   []byte // 使用 WithWatchValues 时携带，删除事件没有值
+
+	view ByteView // 值的保存形式，Watch RPC 原样转发给客户端
+}
+
+// WatchOption 定义 Watch 的配置选项
+type WatchOption func(*watchOptions)
+
+type watchOptions struct {
+	keys   map[string]struct{}
+	prefix string
+	values bool
+	buffer int
+}
+
+// WithWatchKeys 只观察指定的键，可以与 WithWatchPrefix 同时使用，匹配任一条件的事件都会送达
+func WithWatchKeys(keys ...string) WatchOption {
+	return func(o *watchOptions) {
+		if o.keys == nil {
+			o.keys = make(map[string]struct{}, len(keys))
+		}
+		for _, key := range keys {
+			o.keys[key] = struct{}{}
+		}
+	}
+}
+
+// WithWatchPrefix 只观察以 prefix 开头的键
+func WithWatchPrefix(prefix string) WatchOption {
+	return func(o *watchOptions) {
+		o.prefix = prefix
+	}
+}
+
+// WithWatchValues 在事件中携带值，值需要解密、解压，观察大量键时开销较大
+func WithWatchValues() WatchOption {
+	return func(o *watchOptions) {
+		o.values = true
+	}
+}
+
+// WithWatchBuffer 设置事件缓冲区大小，缓冲区满时观察会以 ErrSlowWatcher 结束
+func WithWatchBuffer(n int) WatchOption {
+	return func(o *watchOptions) {
+		o.buffer = n
+	}
+}
+
+func (o *watchOptions) match(key string) bool {
+	if len(o.keys) == 0 && o.prefix == "" {
+		return true
+	}
+	if _, ok := o.keys[key]; ok {
+		return true
+	}
+	return o.prefix != "" && strings.HasPrefix(key, o.prefix)
+}
+
+// Watcher 键变化事件的订阅
+type Watcher struct {
+	opts   watchOptions
+	events chan WatchEvent
+	stop   func()             // 从事件来源注销，只调用一次
+	cancel context.CancelFunc // 结束时先取消阻塞中的投递（见 deliver）
+
+	mu     sync.Mutex
+	closed bool
+	err    atomic.Value // watchErr，Err 不能获取 mu，否则会被阻塞中的 deliver 卡住
+}
+
+type watchErr struct{ err error }
+
+func newWatcher(opts watchOptions) *Watcher {
+	if opts.buffer <= 0 {
+		opts.buffer = defaultWatchBuffer
+	}
+	return &Watcher{opts: opts, events: make(chan WatchEvent, opts.buffer)}
+}
+
+// Events 返回事件通道，观察结束时通道关闭
+func (w *Watcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+// Err 返回观察结束的原因，调用 Close 或 ctx 结束时为 nil 或 ctx 的错误；观察仍在进行时为 nil
+func (w *Watcher) Err() error {
+	if e, ok := w.err.Load().(watchErr); ok {
+		return e.err
+	}
+	return nil
+}
+
+// Close 结束观察
+func (w *Watcher) Close() {
+	w.close(nil)
+}
+
+// close 以 err 结束观察，关闭事件通道
+func (w *Watcher) close(err error) {
+	if w.cancel != nil {
+		w.cancel()
+	}
+	w.mu.Lock()
+	closed := w.closeLocked(err)
+	w.mu.Unlock()
+
+	if closed && w.stop != nil {
+		w.stop()
+	}
+}
+
+// closeLocked 关闭事件通道，返回是否由本次调用关闭，调用前必须持有锁
+func (w *Watcher) closeLocked(err error) bool {
+	if w.closed {
+		return false
+	}
+	w.closed = true
+	w.err.Store(watchErr{err})
+	close(w.events)
+	return true
+}
+
+// offer 不阻塞地投递事件，缓冲区已满时以 ErrSlowWatcher 结束观察，避免拖慢缓存的写入路径
+func (w *Watcher) offer(ev WatchEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.events <- ev:
+	default:
+		// stop 需要获取 watchHub 的写锁，而投递时持有其读锁，因此异步注销
+		if w.closeLocked(ErrSlowWatcher) && w.stop != nil {
+			go w.stop()
+		}
+	}
+}
+
+// deliver 阻塞地投递事件，直到消费者取走事件或 ctx 结束，用于客户端从 Watch 流中读取的事件
+// 应当使用 cancel 对应的 ctx，使 close 可以打断阻塞中的投递
+func (w *Watcher) deliver(ctx context.Context, ev WatchEvent) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return false
+	}
+	select {
+	case w.events <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// watchHub 组内的观察者集合
+type watchHub struct {
+	mu       sync.RWMutex
+	watchers map[*Watcher]struct{}
+	closed   bool  // closeAll 之后不再接受新的观察者
+	count    int32 // 观察者数量，没有观察者时写入路径不做任何处理
+}
+
+// add 添加观察者，closeAll 之后返回 false
+func (h *watchHub) add(w *Watcher) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	if h.watchers == nil {
+		h.watchers = make(map[*Watcher]struct{})
+	}
+	h.watchers[w] = struct{}{}
+	atomic.StoreInt32(&h.count, int32(len(h.watchers)))
+	return true
+}
+
+func (h *watchHub) remove(w *Watcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.watchers, w)
+	atomic.StoreInt32(&h.count, int32(len(h.watchers)))
+}
+
+func (h *watchHub) len() int {
+	return int(atomic.LoadInt32(&h.count))
+}
+
+// emit 向所有匹配的观察者投递事件，值只在有观察者需要时解码一次
+func (h *watchHub) emit(typ WatchEventType, key string, version uint64, view ByteView) {
+	if h.len() == 0 {
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	ev := WatchEvent{Type: typ, Key: key, Version: version, view: view}
+	var value []byte
+	decoded := typ == WatchDelete
+	for w := range h.watchers {
+		if !w.opts.match(key) {
+			continue
+		}
+		e := ev
+		if w.opts.values {
+			if !decoded {
+				value, decoded = cloneBytes(view.data()), true
+			}
+			e.Value = value
+		}
+		w.offer(e)
+	}
+}
+
+// closeAll 以 err 结束所有观察，之后添加的观察者会被拒绝
+func (h *watchHub) closeAll(err error) {
+	h.mu.Lock()
+	h.closed = true
+	watchers := make([]*Watcher, 0, len(h.watchers))
+	for w := range h.watchers {
+		watchers = append(watchers, w)
+	}
+	h.mu.Unlock()
+
+	for _, w := range watchers {
+		w.close(err)
+	}
+}
+
+// Watch 观察本节点缓存中键的变化，直到 ctx 结束、调用 Watcher.Close 或组关闭
+// 只包含本节点缓存的变化（包括从其他节点同步过来的写入），观察整个集群需要分别观察每个节点。
+// 事件以不阻塞的方式投递，处理太慢导致缓冲区满时观察以 ErrSlowWatcher 结束
+func (g *Group) Watch(ctx context.Context, opts ...WatchOption) (*Watcher, error) {
+	if atomic.LoadInt32(&g.closed) == 1 {
+		return nil, ErrGroupClosed
+	}
+
+	var options watchOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	w := newWatcher(options)
+	done := make(chan struct{})
+	w.stop = func() {
+		g.watchers.remove(w)
+		close(done)
+	}
+	// 与 Close 并发时组可能在上面的检查之后关闭，此时不会再有 closeAll 结束这个观察
+	if !g.watchers.add(w) {
+		return nil, ErrGroupClosed
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			w.close(ctx.Err())
+		case <-done:
+		}
+	}()
+	return w, nil
+}
@@ -0,0 +1,406 @@
+package LCache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrWriteBehindFull 异步回写队列已满错误
+var ErrWriteBehindFull = errors.New("write-behind queue is full")
+
+// Setter 将写入同步到后端数据源（如数据库）的接口，与 Getter 对应
+type Setter interface {
+	Set(ctx context.Context, key string, value []byte) error
+}
+
+// SetterFunc 函数类型实现 Setter 接口
+type SetterFunc func(ctx context.Context, key string, value []byte) error
+
+// Set 实现 Setter 接口
+func (f SetterFunc) Set(ctx context.Context, key string, value []byte) error {
+	return f(ctx, key, value)
+}
+
+// Deleter 将删除同步到后端数据源的接口
+type Deleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// DeleterFunc 函数类型实现 Deleter 接口
+type DeleterFunc func(ctx context.Context, key string) error
+
+// Delete 实现 Deleter 接口
+func (f DeleterFunc) Delete(ctx context.Context, key string) error {
+	return f(ctx, key)
+}
+
+// BatchWriter 支持批量写入的后端数据源，Setter 实现了该接口时异步回写会整批提交
+// 一批中的写入和删除要么全部成功，要么整批重试
+type BatchWriter interface {
+	WriteBatch(ctx context.Context, sets map[string][]byte, deletes []string) error
+}
+
+// WriteBehindOptions 异步回写配置，零值字段使用默认值
+type WriteBehindOptions struct {
+	BatchSize     int           // 每批最多提交的键数，队列中待写入的键达到该数量时立即提交
+	FlushInterval time.Duration // 定时提交的间隔
+	MaxPending    int           // 队列中最多的待写入键数，超过时 Set/Delete 返回 ErrWriteBehindFull
+	MaxRetries    int           // 单个写入失败后的最大重试次数，小于 0 表示不重试
+	RetryBackoff  time.Duration // 首次重试的等待时间，之后每次翻倍
+	Timeout       time.Duration // 每批写入后端的超时时间
+}
+
+// DefaultWriteBehindOptions 返回默认的异步回写配置
+func DefaultWriteBehindOptions() WriteBehindOptions {
+	return WriteBehindOptions{
+		BatchSize:     100,
+		FlushInterval: time.Second,
+		MaxPending:    10000,
+		MaxRetries:    3,
+		RetryBackoff:  100 * time.Millisecond,
+		Timeout:       5 * time.Second,
+	}
+}
+
+// WithSetter 设置后端写入接口，Set 默认同步写穿（write-through）到后端，后端失败时不更新缓存
+func WithSetter(s Setter) GroupOption {
+	return func(g *Group) {
+		g.setter = s
+	}
+}
+
+// WithDeleter 设置后端删除接口，Delete 默认同步删除后端数据，后端失败时不删除缓存
+func WithDeleter(d Deleter) GroupOption {
+	return func(g *Group) {
+		g.deleter = d
+	}
+}
+
+// WithWriteBehind 将 Setter/Deleter 改为异步回写（write-behind）：写入先更新缓存并进入队列，
+// 同一个键的多次写入在提交前合并为最后一次，后台按批提交并在失败时重试，组关闭时提交剩余的写入
+func WithWriteBehind(opts WriteBehindOptions) GroupOption {
+	return func(g *Group) {
+		g.writeBehindOpts = &opts
+	}
+}
+
+// writeBack 将本节点发起的写入同步到后端，未配置对应接口时直接返回
+func (g *Group) writeBack(ctx context.Context, key string, value []byte, del bool) error {
+	if (del && g.deleter == nil) || (!del && g.setter == nil) {
+		return nil
+	}
+	if g.writeBehind != nil {
+		return g.writeBehind.enqueue(key, cloneBytes(value), del)
+	}
+
+	var err error
+	op := "set"
+	if del {
+		op = "delete"
+		err = g.deleter.Delete(ctx, key)
+	} else {
+		err = g.setter.Set(ctx, key, value)
+	}
+	if err != nil {
+		atomic.AddInt64(&g.stats.writeThroughErrors, 1)
+		return fmt.Errorf("failed to write through %s of key %s: %w", op, key, err)
+	}
+	return nil
+}
+
+// pendingWrite 队列中等待提交的写入
+type pendingWrite struct {
+	value      []byte
+	delete     bool
+	enqueuedAt time.Time // 首次进入队列的时间，合并写入不会更新它，用于计算延迟
+	attempts   int       // 已失败的次数
+	retryAt    time.Time // 重试前需要等待到的时间
+}
+
+// writeBehind 异步回写队列，同一个键只保留最新的写入
+type writeBehind struct {
+	group   string
+	setter  Setter
+	deleter Deleter
+	opts    WriteBehindOptions
+
+	mu       sync.Mutex
+	pending  map[string]*pendingWrite
+	inflight []*pendingWrite // 正在提交的写入，用于计算延迟
+	closed   bool
+
+	notify chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+
+	flushed   int64 // 成功提交的写入数
+	failed    int64 // 重试耗尽后丢弃的写入数
+	retries   int64 // 重试次数
+	coalesced int64 // 被后续写入合并的写入数
+}
+
+func newWriteBehind(group string, setter Setter, deleter Deleter, opts WriteBehindOptions) *writeBehind {
+	def := DefaultWriteBehindOptions()
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = def.BatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = def.FlushInterval
+	}
+	if opts.MaxPending <= 0 {
+		opts.MaxPending = def.MaxPending
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = def.MaxRetries
+	} else if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = def.RetryBackoff
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = def.Timeout
+	}
+
+	w := &writeBehind{
+		group:   group,
+		setter:  setter,
+		deleter: deleter,
+		opts:    opts,
+		pending: make(map[string]*pendingWrite),
+		notify:  make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// enqueue 将写入加入队列，键已在队列中时合并为本次写入
+func (w *writeBehind) enqueue(key string, value []byte, del bool) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrGroupClosed
+	}
+	if pw, ok := w.pending[key]; ok {
+		pw.value, pw.delete = value, del
+		pw.attempts, pw.retryAt = 0, time.Time{}
+		w.mu.Unlock()
+		atomic.AddInt64(&w.coalesced, 1)
+		return nil
+	}
+	if len(w.pending) >= w.opts.MaxPending {
+		w.mu.Unlock()
+		return ErrWriteBehindFull
+	}
+	w.pending[key] = &pendingWrite{value: value, delete: del, enqueuedAt: time.Now()}
+	full := len(w.pending) >= w.opts.BatchSize
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.notify <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// run 后台定时或在队列达到批大小时提交
+func (w *writeBehind) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		case <-w.notify:
+		}
+		w.flush()
+	}
+}
+
+// flush 提交队列中已到重试时间的写入，返回重试耗尽被丢弃的写入的错误
+func (w *writeBehind) flush() error {
+	now := time.Now()
+	batch := make(map[string]*pendingWrite)
+
+	w.mu.Lock()
+	for key, pw := range w.pending {
+		if now.Before(pw.retryAt) {
+			continue
+		}
+		batch[key] = pw
+		delete(w.pending, key)
+		w.inflight = append(w.inflight, pw)
+	}
+	w.mu.Unlock()
+
+	defer func() {
+		w.mu.Lock()
+		w.inflight = w.inflight[:0]
+		w.mu.Unlock()
+	}()
+
+	var errs []error
+	chunk := make(map[string]*pendingWrite, w.opts.BatchSize)
+	for key, pw := range batch {
+		chunk[key] = pw
+		if len(chunk) < w.opts.BatchSize {
+			continue
+		}
+		errs = append(errs, w.commit(chunk)...)
+		chunk = make(map[string]*pendingWrite, w.opts.BatchSize)
+	}
+	if len(chunk) > 0 {
+		errs = append(errs, w.commit(chunk)...)
+	}
+	return errors.Join(errs...)
+}
+
+// commit 提交一批写入，失败的写入重新进入队列等待重试
+func (w *writeBehind) commit(chunk map[string]*pendingWrite) []error {
+	ctx, cancel := context.WithTimeout(context.Background(), w.opts.Timeout)
+	defer cancel()
+
+	var errs []error
+	if bw, ok := w.setter.(BatchWriter); ok {
+		sets := make(map[string][]byte)
+		var deletes []string
+		for key, pw := range chunk {
+			if pw.delete {
+				deletes = append(deletes, key)
+			} else {
+				sets[key] = pw.value
+			}
+		}
+		if err := bw.WriteBatch(ctx, sets, deletes); err != nil {
+			for key, pw := range chunk {
+				if err := w.retry(key, pw, err); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			return errs
+		}
+		atomic.AddInt64(&w.flushed, int64(len(chunk)))
+		return nil
+	}
+
+	for key, pw := range chunk {
+		var err error
+		if pw.delete {
+			err = w.deleter.Delete(ctx, key)
+		} else {
+			err = w.setter.Set(ctx, key, pw.value)
+		}
+		if err != nil {
+			if err := w.retry(key, pw, err); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		atomic.AddInt64(&w.flushed, 1)
+	}
+	return errs
+}
+
+// retry 将失败的写入放回队列，队列中已有更新的写入时直接丢弃失败的写入；重试耗尽时返回错误
+func (w *writeBehind) retry(key string, pw *pendingWrite, err error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, newer := w.pending[key]; newer {
+		return nil
+	}
+
+	pw.attempts++
+	if pw.attempts > w.opts.MaxRetries {
+		atomic.AddInt64(&w.failed, 1)
+		logrus.Errorf("[LCache] dropped write-behind of key %s in group [%s] after %d attempts: %v",
+			key, w.group, pw.attempts, err)
+		return fmt.Errorf("failed to write behind key %s: %w", key, err)
+	}
+
+	atomic.AddInt64(&w.retries, 1)
+	pw.retryAt = time.Now().Add(w.opts.RetryBackoff << (pw.attempts - 1))
+	w.pending[key] = pw
+	return nil
+}
+
+// close 停止接收新的写入，并提交队列中剩余的写入，失败的写入仍按退避时间重试，重试耗尽的写入以错误返回
+func (w *writeBehind) close() error {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.stop)
+	<-w.done
+
+	var errs []error
+	for w.len() > 0 {
+		if err := w.flush(); err != nil {
+			errs = append(errs, err)
+		}
+		if wait := time.Until(w.nextRetry()); wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// nextRetry 返回队列中最早的重试时间，队列为空时返回零值
+func (w *writeBehind) nextRetry() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var next time.Time
+	for _, pw := range w.pending {
+		if next.IsZero() || pw.retryAt.Before(next) {
+			next = pw.retryAt
+		}
+	}
+	return next
+}
+
+// len 返回等待提交的写入数，包括正在提交的写入
+func (w *writeBehind) len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending) + len(w.inflight)
+}
+
+// stats 返回队列长度、最早未提交写入的延迟以及提交计数
+func (w *writeBehind) stats() map[string]interface{} {
+	now := time.Now()
+	var lag time.Duration
+
+	w.mu.Lock()
+	pending := len(w.pending) + len(w.inflight)
+	for _, pw := range w.pending {
+		lag = max(lag, now.Sub(pw.enqueuedAt))
+	}
+	for _, pw := range w.inflight {
+		lag = max(lag, now.Sub(pw.enqueuedAt))
+	}
+	w.mu.Unlock()
+
+	return map[string]interface{}{
+		"pending":   pending,
+		"lag_ms":    float64(lag) / float64(time.Millisecond),
+		"flushed":   atomic.LoadInt64(&w.flushed),
+		"failed":    atomic.LoadInt64(&w.failed),
+		"retries":   atomic.LoadInt64(&w.retries),
+		"coalesced": atomic.LoadInt64(&w.coalesced),
+	}
+}
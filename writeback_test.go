@@ -0,0 +1,257 @@
+package LCache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingBackend 记录写入后端的操作，fail 大于 0 时前 fail 次操作失败
+type recordingBackend struct {
+	mu      sync.Mutex
+	sets    map[string][]byte
+	deletes []string
+	calls   []time.Time
+	fail    int
+}
+
+func newRecordingBackend() *recordingBackend {
+	return &recordingBackend{sets: make(map[string][]byte)}
+}
+
+func (b *recordingBackend) Set(ctx context.Context, key string, value []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls = append(b.calls, time.Now())
+	if b.fail > 0 {
+		b.fail--
+		return errors.New("backend unavailable")
+	}
+	b.sets[key] = value
+	return nil
+}
+
+func (b *recordingBackend) Delete(ctx context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls = append(b.calls, time.Now())
+	if b.fail > 0 {
+		b.fail--
+		return errors.New("backend unavailable")
+	}
+	b.deletes = append(b.deletes, key)
+	return nil
+}
+
+func (b *recordingBackend) counts() (sets, deletes, calls int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.sets), len(b.deletes), len(b.calls)
+}
+
+func TestWriteBackOnlyOnOriginNode(t *testing.T) {
+	backends := [2]*recordingBackend{newRecordingBackend(), newRecordingBackend()}
+	var pickers [2]*staticPicker
+	var addrs [2]string
+	var groups [2]*Group
+	for i := range groups {
+		reg := NewRegistry()
+		pickers[i] = &staticPicker{}
+		groups[i] = newTestGroupIn(t, reg, nil, WithPeers(pickers[i]),
+			WithDefaultWriteConsistency(WriteSyncAll), WithSetter(backends[i]), WithDeleter(backends[i]))
+		addrs[i] = startTestServer(t, reg)
+	}
+	for i := range groups {
+		pickers[i].set(newTestClient(t, addrs[1-i], asPeer()))
+	}
+	ctx := context.Background()
+
+	// 本地调用：写入节点写后端，副本不写
+	if err := groups[0].Set(ctx, "local", []byte("v")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := groups[0].Delete(ctx, "local"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	// 客户端通过 gRPC 写入节点 1：节点 1 写后端并同步到节点 0，节点 0 不写
+	client := newTestClient(t, addrs[1])
+	if err := client.Set(ctx, t.Name(), "remote", []byte("v")); err != nil {
+		t.Fatalf("client Set: %v", err)
+	}
+	if _, ok := cachedValue(t, groups[0], "remote"); !ok {
+		t.Fatal("client set was not replicated")
+	}
+	if _, err := client.Delete(ctx, t.Name(), "remote"); err != nil {
+		t.Fatalf("client Delete: %v", err)
+	}
+
+	// 每个节点的后端只收到自己最先处理的写入和删除
+	tests := []struct {
+		node int
+		key  string
+	}{
+		{0, "local"},
+		{1, "remote"},
+	}
+	for _, tt := range tests {
+		b := backends[tt.node]
+		if _, _, calls := b.counts(); calls != 2 {
+			t.Errorf("node %d backend got %d calls, want 2", tt.node, calls)
+		}
+		if _, ok := b.sets[tt.key]; !ok {
+			t.Errorf("node %d backend missing set of %q", tt.node, tt.key)
+		}
+		if len(b.deletes) != 1 || b.deletes[0] != tt.key {
+			t.Errorf("node %d backend deletes = %v, want [%s]", tt.node, b.deletes, tt.key)
+		}
+	}
+}
+
+func TestWriteBehindCoalesces(t *testing.T) {
+	b := newRecordingBackend()
+	w := newWriteBehind("test", b, b, WriteBehindOptions{FlushInterval: time.Hour})
+	defer w.close()
+
+	for _, v := range []string{"v1", "v2", "v3"} {
+		if err := w.enqueue("k", []byte(v), false); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+	if err := w.enqueue("d", []byte("v"), false); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := w.enqueue("d", nil, true); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := w.flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	if got := string(b.sets["k"]); got != "v3" {
+		t.Errorf("backend value = %q, want %q", got, "v3")
+	}
+	if _, ok := b.sets["d"]; ok || len(b.deletes) != 1 {
+		t.Errorf("coalesced delete: sets %v, deletes %v", b.sets, b.deletes)
+	}
+	if _, _, calls := b.counts(); calls != 2 {
+		t.Errorf("backend calls = %d, want 2", calls)
+	}
+	if got := w.stats()["coalesced"]; got != int64(3) {
+		t.Errorf("coalesced = %v, want 3", got)
+	}
+}
+
+func TestWriteBehindRetriesWithBackoff(t *testing.T) {
+	b := newRecordingBackend()
+	b.fail = 2
+	backoff := 20 * time.Millisecond
+	w := newWriteBehind("test", b, b, WriteBehindOptions{
+		FlushInterval: 5 * time.Millisecond,
+		MaxRetries:    3,
+		RetryBackoff:  backoff,
+	})
+	defer w.close()
+
+	if err := w.enqueue("k", []byte("v"), false); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for w.len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.calls) != 3 || string(b.sets["k"]) != "v" {
+		t.Fatalf("backend calls = %d, value = %q; want 3 calls and %q", len(b.calls), b.sets["k"], "v")
+	}
+	// 第 n 次重试至少等待 backoff << (n-1)
+	for i := 1; i < len(b.calls); i++ {
+		want := backoff << (i - 1)
+		if gap := b.calls[i].Sub(b.calls[i-1]); gap < want {
+			t.Errorf("retry %d after %v, want at least %v", i, gap, want)
+		}
+	}
+	if got := w.stats()["retries"]; got != int64(2) {
+		t.Errorf("retries = %v, want 2", got)
+	}
+}
+
+func TestWriteBehindDropsAfterMaxRetries(t *testing.T) {
+	b := newRecordingBackend()
+	b.fail = 100
+	w := newWriteBehind("test", b, b, WriteBehindOptions{
+		FlushInterval: time.Hour,
+		MaxRetries:    -1,
+	})
+	if err := w.enqueue("k", []byte("v"), false); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := w.close(); err == nil {
+		t.Fatal("close succeeded although the backend always fails")
+	}
+	if got := w.stats()["failed"]; got != int64(1) {
+		t.Errorf("failed = %v, want 1", got)
+	}
+}
+
+func TestWriteBehindFull(t *testing.T) {
+	b := newRecordingBackend()
+	w := newWriteBehind("test", b, b, WriteBehindOptions{FlushInterval: time.Hour, MaxPending: 2})
+	defer w.close()
+
+	for _, key := range []string{"a", "b"} {
+		if err := w.enqueue(key, []byte("v"), false); err != nil {
+			t.Fatalf("enqueue(%q): %v", key, err)
+		}
+	}
+	if err := w.enqueue("c", []byte("v"), false); !errors.Is(err, ErrWriteBehindFull) {
+		t.Fatalf("enqueue over MaxPending error = %v, want ErrWriteBehindFull", err)
+	}
+	// 已在队列中的键合并写入，不占用新的位置
+	if err := w.enqueue("a", []byte("v2"), false); err != nil {
+		t.Fatalf("enqueue of pending key: %v", err)
+	}
+}
+
+func TestWriteBehindFullRejectsGroupWrite(t *testing.T) {
+	b := newRecordingBackend()
+	g := newTestGroup(t, nil, WithSetter(b), WithWriteBehind(WriteBehindOptions{FlushInterval: time.Hour, MaxPending: 1}))
+	ctx := context.Background()
+
+	if err := g.Set(ctx, "a", []byte("v")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := g.Set(ctx, "b", []byte("v")); !errors.Is(err, ErrWriteBehindFull) {
+		t.Fatalf("Set error = %v, want ErrWriteBehindFull", err)
+	}
+	if _, ok := cachedValue(t, g, "b"); ok {
+		t.Error("rejected write was cached")
+	}
+}
+
+func TestWriteBehindCloseDrains(t *testing.T) {
+	b := newRecordingBackend()
+	b.fail = 1
+	w := newWriteBehind("test", b, b, WriteBehindOptions{
+		FlushInterval: time.Hour,
+		RetryBackoff:  10 * time.Millisecond,
+	})
+	for _, key := range []string{"a", "b", "c"} {
+		if err := w.enqueue(key, []byte("v"), false); err != nil {
+			t.Fatalf("enqueue(%q): %v", key, err)
+		}
+	}
+	if err := w.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if sets, _, _ := b.counts(); sets != 3 {
+		t.Errorf("backend got %d sets after close, want 3", sets)
+	}
+	if err := w.enqueue("d", []byte("v"), false); !errors.Is(err, ErrGroupClosed) {
+		t.Errorf("enqueue after close error = %v, want ErrGroupClosed", err)
+	}
+}